@@ -3,9 +3,11 @@ package main
 import (
 	"debug/macho"
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 
+	"github.com/hirochachacha/goview/macho_analysis"
 	"github.com/hirochachacha/goview/macho_widgets"
 	"github.com/therecipe/qt/gui"
 	"github.com/therecipe/qt/widgets"
@@ -17,6 +19,15 @@ const (
 )
 
 func main() {
+	// goview dump doesn't need a display
+	if len(os.Args) > 1 && os.Args[1] == "dump" {
+		if err := dump(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	app := widgets.NewQApplication(len(os.Args), os.Args)
 	app.SetApplicationName("GoView")
 	app.SetApplicationVersion("0.0.1")
@@ -38,29 +49,43 @@ func main() {
 
 type MainWindow struct {
 	*widgets.QMainWindow
+
+	path string
+	arch string
+	dsym string
 }
 
 func NewMainWindow(args []string) (*MainWindow, error) {
-	mw := &MainWindow{widgets.NewQMainWindow(nil, 0)}
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	arch := fs.String("arch", "", "open the `architecture` (e.g. x86_64, arm64) of a universal binary")
+	sourceRoot := fs.String("source-root", "", "look up source files of the Source view under `dir`")
+	dsym := fs.String("dsym", "", "use DWARF and symbols of the dSYM at `path` (default: the sibling .dSYM bundle)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	macho_widgets.SourceRoot = *sourceRoot
+
+	mw := &MainWindow{QMainWindow: widgets.NewQMainWindow(nil, 0), arch: *arch, dsym: *dsym}
 
 	var path string
 
-	if len(args) <= 1 {
+	if fs.NArg() == 0 {
 		var err error
 		path, err = mw.openFile()
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		path = args[1]
+		path = fs.Arg(0)
 	}
 
-	f, err := macho.Open(path)
+	cw, err := newCentralWidget(path, *arch, *dsym)
 	if err != nil {
 		return nil, err
 	}
-	cw := macho_widgets.NewCentralWidget(nil, f)
 
+	mw.path = path
 	mw.addMenu()
 	mw.SetWindowTitle(path)
 	mw.SetCentralWidget(cw)
@@ -69,6 +94,110 @@ func NewMainWindow(args []string) (*MainWindow, error) {
 	return mw, nil
 }
 
+// newCentralWidget opens path as a universal binary if possible, otherwise as a thin one.
+// If arch isn't empty, only the architecture is opened.
+// If dsym is empty, the sibling .dSYM bundle is used if it exists and has the same UUID.
+func newCentralWidget(path, arch, dsym string) (widgets.QWidget_ITF, error) {
+	explicit := dsym != ""
+	if !explicit {
+		dsym = macho_analysis.FindDSYM(path)
+	}
+
+	ff, err := macho_analysis.OpenFat(path)
+	if err == nil {
+		if arch == "" {
+			cw, err := macho_widgets.NewFatCentralWidget(nil, ff, dsym, explicit)
+			if err != nil {
+				ff.Close()
+				return nil, err
+			}
+			return cw, nil
+		}
+		if a := ff.Arch(arch); a != nil {
+			if a.File == nil {
+				ff.Close()
+				return nil, a.Err
+			}
+			return newThinCentralWidget(a.File, dsym, explicit)
+		}
+		ff.Close()
+		return nil, fmt.Errorf("%s: no such architecture: %s", path, arch)
+	}
+	if err != macho.ErrNotFat {
+		return nil, err
+	}
+
+	f, err := macho.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if arch != "" && macho_analysis.ArchString(f.Cpu, f.SubCpu) != arch {
+		f.Close()
+		return nil, fmt.Errorf("%s: no such architecture: %s", path, arch)
+	}
+	return newThinCentralWidget(f, dsym, explicit)
+}
+
+// newThinCentralWidget loads the dSYM if dsym isn't empty.
+// A dSYM which doesn't match is an error if it's specified explicitly, otherwise it's ignored.
+func newThinCentralWidget(mf *macho.File, dsym string, explicit bool) (widgets.QWidget_ITF, error) {
+	f := macho_widgets.NewFile(mf)
+	if dsym != "" {
+		if err := f.LoadDSYM(dsym); err != nil && explicit {
+			return nil, err
+		}
+	}
+	return f.NewCentralWidget(nil), nil
+}
+
+// dump prints the structure, symbols and relocations in text or JSON.
+//
+//	goview dump [-format text|json] [-arch arch] [-demangle=false] file
+func dump(args []string) error {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	format := fs.String("format", "text", "output `format` (text or json)")
+	arch := fs.String("arch", "", "dump the `architecture` (e.g. x86_64, arm64) of a universal binary")
+	demangle := fs.Bool("demangle", true, "demangle C++ and Swift symbol names")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: goview dump [-format text|json] [-arch arch] [-demangle=false] file")
+	}
+
+	macho_analysis.Demangle = *demangle
+
+	path := fs.Arg(0)
+
+	ff, err := macho_analysis.OpenFat(path)
+	if err == nil {
+		defer ff.Close()
+		if *arch == "" {
+			return macho_analysis.DumpFat(os.Stdout, ff, *format)
+		}
+		if a := ff.Arch(*arch); a != nil {
+			if a.File == nil {
+				return a.Err
+			}
+			return macho_analysis.Dump(os.Stdout, a.File, *format)
+		}
+		return fmt.Errorf("%s: no such architecture: %s", path, *arch)
+	}
+	if err != macho.ErrNotFat {
+		return err
+	}
+
+	f, err := macho.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if *arch != "" && macho_analysis.ArchString(f.Cpu, f.SubCpu) != *arch {
+		return fmt.Errorf("%s: no such architecture: %s", path, *arch)
+	}
+	return macho_analysis.Dump(os.Stdout, f, *format)
+}
+
 func (mw *MainWindow) addMenu() {
 	menu := mw.MenuBar().AddMenu2("&File")
 	a := menu.AddAction2(gui.QIcon_FromTheme("document-open"), "&Open...")
@@ -79,15 +208,13 @@ func (mw *MainWindow) addMenu() {
 			msg.ShowMessage(err.Error())
 			return
 		}
-		f, err := macho.Open(path)
+		cw, err := newCentralWidget(path, "", "")
 		if err != nil {
-			f.Close()
 			msg := widgets.NewQErrorMessage(mw.QMainWindow)
 			msg.ShowMessage(err.Error())
 			return
 		}
-		cw := macho_widgets.NewCentralWidget(nil, f)
-		mw := &MainWindow{widgets.NewQMainWindow(nil, 0)}
+		mw := &MainWindow{QMainWindow: widgets.NewQMainWindow(nil, 0), path: path}
 		mw.addMenu()
 		mw.SetWindowTitle(path)
 		mw.SetCentralWidget(cw)
@@ -95,6 +222,41 @@ func (mw *MainWindow) addMenu() {
 		mw.Show()
 	})
 	a.SetShortcuts2(gui.QKeySequence__Open)
+
+	a = menu.AddAction("Open &dSYM...")
+	a.ConnectTriggered(func(checked bool) {
+		dsym, err := mw.openFile()
+		if err != nil {
+			msg := widgets.NewQErrorMessage(mw.QMainWindow)
+			msg.ShowMessage(err.Error())
+			return
+		}
+		cw, err := newCentralWidget(mw.path, mw.arch, dsym)
+		if err != nil {
+			msg := widgets.NewQErrorMessage(mw.QMainWindow)
+			msg.ShowMessage(err.Error())
+			return
+		}
+		mw.dsym = dsym
+		mw.SetCentralWidget(cw)
+	})
+
+	menu = mw.MenuBar().AddMenu2("&View")
+	a = menu.AddAction("&Demangle")
+	a.SetCheckable(true)
+	a.SetChecked(macho_analysis.Demangle)
+	a.ConnectTriggered(func(checked bool) {
+		macho_analysis.Demangle = checked
+
+		// symbol names are cached by views, so they are rebuilt
+		cw, err := newCentralWidget(mw.path, mw.arch, mw.dsym)
+		if err != nil {
+			msg := widgets.NewQErrorMessage(mw.QMainWindow)
+			msg.ShowMessage(err.Error())
+			return
+		}
+		mw.SetCentralWidget(cw)
+	})
 }
 
 func (mw *MainWindow) openFile() (string, error) {
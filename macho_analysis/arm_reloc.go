@@ -0,0 +1,251 @@
+package macho_analysis
+
+import (
+	"debug/macho"
+	"fmt"
+)
+
+// armRelocTarget returns the target of the i-th relocation of s for CpuArm.
+// data is the relocated instruction or pointer.
+func (f *File) armRelocTarget(s *macho.Section, i int, data []byte, uval uint64, ival int64) (string, *RelocTarget) {
+	r := s.Relocs[i]
+	addr := s.Addr + uint64(r.Addr)
+
+	switch macho.RelocTypeARM(r.Type) {
+	case macho.ARM_RELOC_VANILLA:
+		return f.vanillaRelocTarget(r, uval, ival, addr+8, "pc")
+	case macho.ARM_RELOC_PAIR:
+	case macho.ARM_RELOC_SECTDIFF, macho.ARM_RELOC_LOCAL_SECTDIFF:
+		return f.sectdiffRelocTarget(s, i, ival)
+	case macho.ARM_RELOC_PB_LA_PTR:
+		return pbLaPtrRelocTarget(r, uval)
+	case macho.ARM_RELOC_BR24:
+		// B, BL and BLX
+		inst := uint32(uval)
+		disp := signExtend(uint64(inst&0xffffff), 24) << 2
+		if inst>>28 == 0xf { // BLX
+			disp += int64(inst>>24&1) << 1
+		}
+		return armBranchTarget(r, disp, addr+8)
+	case macho.ARM_THUMB_RELOC_BR22:
+		// BL and BLX
+		if len(data) != 4 {
+			break
+		}
+		hw1 := uint64(f.ByteOrder.Uint16(data))
+		hw2 := uint64(f.ByteOrder.Uint16(data[2:]))
+		sign := hw1 >> 10 & 1
+		i1 := ^(hw2>>13 ^ sign) & 1
+		i2 := ^(hw2>>11 ^ sign) & 1
+		disp := signExtend(sign<<24|i1<<23|i2<<22|(hw1&0x3ff)<<12|(hw2&0x7ff)<<1, 25)
+		pc := addr + 4
+		if hw2&0x1000 == 0 { // BLX
+			pc &^= 3
+		}
+		return armBranchTarget(r, disp, pc)
+	case macho.ARM_THUMB_32BIT_BRANCH:
+		// obsolete
+	case macho.ARM_RELOC_HALF, macho.ARM_RELOC_HALF_SECTDIFF:
+		// MOVW and MOVT, which have the half of the address
+		n := nextReloc(s, i)
+		if n == nil || macho.RelocTypeARM(n.Type) != macho.ARM_RELOC_PAIR || len(data) != 4 {
+			// TODO warning
+			break
+		}
+
+		var half uint64
+		if r.Len&2 != 0 { // thumb
+			hw1 := uint64(f.ByteOrder.Uint16(data))
+			hw2 := uint64(f.ByteOrder.Uint16(data[2:]))
+			half = (hw1&0xf)<<12 | (hw1>>10&1)<<11 | (hw2>>12&7)<<8 | hw2&0xff
+		} else {
+			half = (uval>>16&0xf)<<12 | uval&0xfff
+		}
+
+		// the other half is in the pair
+		hi, lo := uint64(n.Addr)&0xffff, half
+		if r.Len&1 != 0 {
+			hi, lo = half, uint64(n.Addr)&0xffff
+		}
+		val := hi<<16 | lo
+
+		switch {
+		case macho.RelocTypeARM(r.Type) == macho.ARM_RELOC_HALF_SECTDIFF:
+			ns := f.symAddrString(uint64(n.Value), true)
+			rs := f.symAddrString(uint64(r.Value), true)
+			addend := int64(int32(val)) + int64(n.Value) - int64(r.Value)
+			return fmt.Sprintf(" (addend: %#x<<16|%#x+%s-%s = %+d)", hi, lo, ns, rs, addend), &RelocTarget{
+				Symnum:  -1,
+				Symaddr: uint64(r.Value),
+				Addend:  addend,
+				Size:    4,
+			}
+		case r.Extern:
+			addend := int64(int32(val))
+			return fmt.Sprintf(" (addend: %#x<<16|%#x = %+d)", hi, lo, addend), &RelocTarget{
+				Symnum: int(r.Value),
+				Addend: addend,
+				Size:   4,
+			}
+		default:
+			return fmt.Sprintf(" (addr: %#x<<16|%#x = %#x)", hi, lo, val), &RelocTarget{
+				Symnum:  -1,
+				Symaddr: val,
+				Size:    4,
+			}
+		}
+	}
+
+	return " (?)", nil
+}
+
+// armBranchTarget returns the target of the branch with disp, which is relative to pc.
+func armBranchTarget(r macho.Reloc, disp int64, pc uint64) (string, *RelocTarget) {
+	if r.Extern {
+		return fmt.Sprintf(" (addend: %#+x(pc) = %+d)", disp, disp+int64(pc)), &RelocTarget{
+			Symnum: int(r.Value),
+			Addend: disp + int64(pc),
+			Size:   4,
+		}
+	}
+	return fmt.Sprintf(" (addr: %#+x(pc) = %#x)", disp, addAddend(pc, disp)), &RelocTarget{
+		Symnum:  -1,
+		Symaddr: addAddend(pc, disp),
+		Size:    4,
+	}
+}
+
+// arm64RelocTarget returns the target of the i-th relocation of s for CpuArm64.
+// The addend of instructions is in the preceding ARM64_RELOC_ADDEND, not in the instructions.
+func (f *File) arm64RelocTarget(s *macho.Section, i int, uval uint64, ival int64) (string, *RelocTarget) {
+	r := s.Relocs[i]
+	pc := s.Addr + uint64(r.Addr)
+	inst := uint32(uval)
+
+	var addend int64
+	if p := prevReloc(s, i); p != nil && macho.RelocTypeARM64(p.Type) == macho.ARM64_RELOC_ADDEND {
+		addend = signExtend(uint64(p.Value), 24)
+	}
+
+	switch macho.RelocTypeARM64(r.Type) {
+	case macho.ARM64_RELOC_UNSIGNED:
+		if r.Extern {
+			return fmt.Sprintf(" (addend: %+d)", ival), &RelocTarget{
+				Symnum: int(r.Value),
+				Addend: ival,
+				Size:   1 << r.Len,
+			}
+		}
+		return fmt.Sprintf(" (addr: %#x)", uval), &RelocTarget{
+			Symnum:  -1,
+			Symaddr: uval,
+			Size:    1 << r.Len,
+		}
+	case macho.ARM64_RELOC_SUBTRACTOR:
+		return f.subtractorRelocTarget(s, i, ival)
+	case macho.ARM64_RELOC_BRANCH26:
+		// B and BL
+		if r.Extern {
+			return fmt.Sprintf(" (addend: %+d)", addend), &RelocTarget{
+				Symnum: int(r.Value),
+				Addend: addend,
+				Size:   4,
+			}
+		}
+		disp := signExtend(uint64(inst&0x3ffffff), 26) << 2
+		return fmt.Sprintf(" (addr: %#+x(pc) = %#x)", disp, addAddend(pc, disp)), &RelocTarget{
+			Symnum:  -1,
+			Symaddr: addAddend(pc, disp),
+			Size:    4,
+		}
+	case macho.ARM64_RELOC_PAGE21:
+		// ADRP
+		if r.Extern {
+			return fmt.Sprintf(" (addend: %+d)", addend), &RelocTarget{
+				Symnum: int(r.Value),
+				Addend: addend,
+				Size:   1 << r.Len,
+			}
+		}
+		disp := signExtend(uint64(inst>>5&0x7ffff)<<2|uint64(inst>>29&3), 21) << 12
+		page := addAddend(pc&^0xfff, disp)
+		return fmt.Sprintf(" (page: %#x)", page), &RelocTarget{
+			Symnum:  -1,
+			Symaddr: page,
+			Size:    1 << r.Len,
+		}
+	case macho.ARM64_RELOC_PAGEOFF12:
+		// ADD and LDR/STR, whose imm12 is scaled by the size of the access
+		scale, ldst := arm64PageOffScale(inst)
+		size := uint8(1) << r.Len
+		if ldst {
+			size = 1 << scale
+		}
+		if r.Extern {
+			return fmt.Sprintf(" (addend: %+d, scale: %d)", addend, 1<<scale), &RelocTarget{
+				Symnum: int(r.Value),
+				Addend: addend,
+				Size:   size,
+			}
+		}
+		off := uint64(inst>>10&0xfff) << scale
+		if addr, ok := f.pageOffTarget(r.Value, off); ok {
+			return fmt.Sprintf(" (pageoff: %#x = %#x)", off, addr), &RelocTarget{
+				Symnum:  -1,
+				Symaddr: addr,
+				Size:    size,
+			}
+		}
+		return fmt.Sprintf(" (pageoff: %#x)", off), nil
+	case macho.ARM64_RELOC_GOT_LOAD_PAGE21, macho.ARM64_RELOC_GOT_LOAD_PAGEOFF12, macho.ARM64_RELOC_POINTER_TO_GOT:
+		return " (GOT)", &RelocTarget{
+			Symnum: int(r.Value),
+			Addend: addend,
+			Size:   1 << r.Len,
+		}
+	case macho.ARM64_RELOC_TLVP_LOAD_PAGE21, macho.ARM64_RELOC_TLVP_LOAD_PAGEOFF12:
+		return " (TLV)", &RelocTarget{
+			Symnum: int(r.Value),
+			Addend: addend,
+			Size:   1 << r.Len,
+		}
+	case macho.ARM64_RELOC_ADDEND:
+		return fmt.Sprintf(" (addend: %+d)", signExtend(uint64(r.Value), 24)), nil
+	}
+
+	return " (?)", nil
+}
+
+// arm64PageOffScale returns the log2 of the scale of imm12 in inst,
+// and whether inst is LDR/STR (unsigned immediate).
+func arm64PageOffScale(inst uint32) (uint, bool) {
+	if inst&0x3b000000 != 0x39000000 {
+		return 0, false
+	}
+	if inst&0x04800000 == 0x04800000 { // 128-bit SIMD&FP
+		return 4, true
+	}
+	return uint(inst >> 30), true
+}
+
+// pageOffTarget returns the address in the section sectnum whose page offset is off,
+// unless the section has several such addresses.
+func (f *File) pageOffTarget(sectnum uint32, off uint64) (uint64, bool) {
+	if sectnum == 0 || int(sectnum) > len(f.Sections) {
+		return 0, false
+	}
+	s := f.Sections[sectnum-1]
+	addr := s.Addr&^0xfff | off
+	if addr < s.Addr {
+		addr += 0x1000
+	}
+	if s.Addr+s.Size <= addr || addr+0x1000 < s.Addr+s.Size {
+		return 0, false
+	}
+	return addr, true
+}
+
+// signExtend sign-extends the lower n bits of v.
+func signExtend(v uint64, n uint) int64 {
+	return int64(v<<(64-n)) >> (64 - n)
+}
@@ -0,0 +1,403 @@
+//go:generate stringer -type=DW_CFA -output call_frame_string.go
+
+package macho_analysis
+
+// reference:
+// http://www.dwarfstd.org/doc/DWARF5.pdf (6.4 Call Frame Information)
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type DW_CFA uint8
+
+const (
+	// high 2 bits
+
+	DW_CFA_advance_loc DW_CFA = 0x40
+	DW_CFA_offset      DW_CFA = 0x80
+	DW_CFA_restore     DW_CFA = 0xc0
+
+	// low 6 bits
+
+	DW_CFA_nop                          DW_CFA = 0x00
+	DW_CFA_set_loc                      DW_CFA = 0x01
+	DW_CFA_advance_loc1                 DW_CFA = 0x02
+	DW_CFA_advance_loc2                 DW_CFA = 0x03
+	DW_CFA_advance_loc4                 DW_CFA = 0x04
+	DW_CFA_offset_extended              DW_CFA = 0x05
+	DW_CFA_restore_extended             DW_CFA = 0x06
+	DW_CFA_undefined                    DW_CFA = 0x07
+	DW_CFA_same_value                   DW_CFA = 0x08
+	DW_CFA_register                     DW_CFA = 0x09
+	DW_CFA_remember_state               DW_CFA = 0x0a
+	DW_CFA_restore_state                DW_CFA = 0x0b
+	DW_CFA_def_cfa                      DW_CFA = 0x0c
+	DW_CFA_def_cfa_register             DW_CFA = 0x0d
+	DW_CFA_def_cfa_offset               DW_CFA = 0x0e
+	DW_CFA_def_cfa_expression           DW_CFA = 0x0f
+	DW_CFA_expression                   DW_CFA = 0x10
+	DW_CFA_offset_extended_sf           DW_CFA = 0x11
+	DW_CFA_def_cfa_sf                   DW_CFA = 0x12
+	DW_CFA_def_cfa_offset_sf            DW_CFA = 0x13
+	DW_CFA_val_offset                   DW_CFA = 0x14
+	DW_CFA_val_offset_sf                DW_CFA = 0x15
+	DW_CFA_val_expression               DW_CFA = 0x16
+	DW_CFA_GNU_window_save              DW_CFA = 0x2d // DW_CFA_AARCH64_negate_ra_state on arm64
+	DW_CFA_GNU_args_size                DW_CFA = 0x2e
+	DW_CFA_GNU_negative_offset_extended DW_CFA = 0x2f
+)
+
+// cfaInst is a decoded call frame instruction.
+// Offsets are already multiplied by the data alignment factor.
+type cfaInst struct {
+	addr uint64
+	data []byte
+	op   DW_CFA
+	loc  uint64 // DW_CFA_advance_loc*: delta, DW_CFA_set_loc: address
+	reg  uint64
+	reg2 uint64
+	off  int64
+	expr []byte
+}
+
+// cfaInsts decodes call frame instructions in [off, end) of r, which is located at base.
+func (p *parser) cfaInsts(r io.ReaderAt, base uint64, off, end int64, info *cieInfo) ([]cfaInst, error) {
+	data := make([]byte, end-off)
+	if _, err := r.ReadAt(data, off); err != nil {
+		return nil, err
+	}
+
+	var insts []cfaInst
+
+	b := data
+
+	var err error
+
+	uleb := func() uint64 {
+		x, n, e := readUleb128(b)
+		if e != nil && err == nil {
+			err = e
+		}
+		b = b[n:]
+		return x
+	}
+	sleb := func() int64 {
+		x, n, e := readSleb128(b)
+		if e != nil && err == nil {
+			err = e
+		}
+		b = b[n:]
+		return x
+	}
+	fixed := func(n int) uint64 {
+		if len(b) < n {
+			if err == nil {
+				err = errors.New("truncated call frame instruction")
+			}
+			b = nil
+			return 0
+		}
+		var x uint64
+		switch n {
+		case 1:
+			x = uint64(b[0])
+		case 2:
+			x = uint64(p.f.ByteOrder.Uint16(b))
+		case 4:
+			x = uint64(p.f.ByteOrder.Uint32(b))
+		}
+		b = b[n:]
+		return x
+	}
+	block := func() []byte {
+		n := uleb()
+		if uint64(len(b)) < n {
+			if err == nil {
+				err = errors.New("truncated call frame instruction")
+			}
+			b = nil
+			return nil
+		}
+		blk := b[:n]
+		b = b[n:]
+		return blk
+	}
+
+	for len(b) > 0 && err == nil {
+		start := len(data) - len(b)
+
+		inst := cfaInst{addr: base + uint64(off) + uint64(start)}
+
+		c := b[0]
+		b = b[1:]
+
+		switch op := DW_CFA(c & 0xc0); op {
+		case DW_CFA_advance_loc:
+			inst.op = op
+			inst.loc = uint64(c&0x3f) * info.caf
+		case DW_CFA_offset:
+			inst.op = op
+			inst.reg = uint64(c & 0x3f)
+			inst.off = int64(uleb()) * info.daf
+		case DW_CFA_restore:
+			inst.op = op
+			inst.reg = uint64(c & 0x3f)
+		default:
+			inst.op = DW_CFA(c)
+
+			switch inst.op {
+			case DW_CFA_set_loc:
+				n, e := p.pointer(r, off+int64(start)+1, info.fenc, &inst.loc)
+				if e != nil {
+					err = e
+					break
+				}
+				if addr, ok := p.pointerAddr(inst.loc, inst.addr+1, info.fenc); ok {
+					inst.loc = addr
+				}
+				if n > len(b) {
+					n = len(b)
+				}
+				b = b[n:]
+			case DW_CFA_advance_loc1:
+				inst.loc = fixed(1) * info.caf
+			case DW_CFA_advance_loc2:
+				inst.loc = fixed(2) * info.caf
+			case DW_CFA_advance_loc4:
+				inst.loc = fixed(4) * info.caf
+			case DW_CFA_offset_extended, DW_CFA_val_offset:
+				inst.reg = uleb()
+				inst.off = int64(uleb()) * info.daf
+			case DW_CFA_offset_extended_sf, DW_CFA_val_offset_sf:
+				inst.reg = uleb()
+				inst.off = sleb() * info.daf
+			case DW_CFA_GNU_negative_offset_extended:
+				inst.reg = uleb()
+				inst.off = -int64(uleb()) * info.daf
+			case DW_CFA_restore_extended, DW_CFA_undefined, DW_CFA_same_value, DW_CFA_def_cfa_register:
+				inst.reg = uleb()
+			case DW_CFA_register:
+				inst.reg = uleb()
+				inst.reg2 = uleb()
+			case DW_CFA_def_cfa:
+				inst.reg = uleb()
+				inst.off = int64(uleb())
+			case DW_CFA_def_cfa_sf:
+				inst.reg = uleb()
+				inst.off = sleb() * info.daf
+			case DW_CFA_def_cfa_offset, DW_CFA_GNU_args_size:
+				inst.off = int64(uleb())
+			case DW_CFA_def_cfa_offset_sf:
+				inst.off = sleb() * info.daf
+			case DW_CFA_def_cfa_expression:
+				inst.expr = block()
+			case DW_CFA_expression, DW_CFA_val_expression:
+				inst.reg = uleb()
+				inst.expr = block()
+			case DW_CFA_nop, DW_CFA_remember_state, DW_CFA_restore_state, DW_CFA_GNU_window_save:
+			default:
+				err = fmt.Errorf("unknown call frame instruction: %#02x", c)
+			}
+		}
+
+		inst.data = data[start : len(data)-len(b)]
+
+		insts = append(insts, inst)
+	}
+
+	return insts, err
+}
+
+// appendCFARows appends rows of insts to n.
+func (p *parser) appendCFARows(n *DataNode, insts []cfaInst) {
+	for i := range insts {
+		inst := &insts[i]
+		n.appendRow(
+			Text(fmt.Sprintf("%#016x", inst.addr)),
+			Text(fmt.Sprintf("% x", inst.data)),
+			Text(inst.op.String()),
+			p.cfaInstValue(inst),
+		)
+	}
+}
+
+func (p *parser) cfaInstValue(inst *cfaInst) Value {
+	reg := p.f.registerString
+	switch inst.op {
+	case DW_CFA_advance_loc, DW_CFA_advance_loc1, DW_CFA_advance_loc2, DW_CFA_advance_loc4:
+		return Text(fmt.Sprintf("+%d", inst.loc))
+	case DW_CFA_set_loc:
+		return p.f.addrValue(inst.loc, 0)
+	case DW_CFA_offset, DW_CFA_offset_extended, DW_CFA_offset_extended_sf, DW_CFA_GNU_negative_offset_extended:
+		return Text(fmt.Sprintf("%s at [cfa%+d]", reg(inst.reg), inst.off))
+	case DW_CFA_val_offset, DW_CFA_val_offset_sf:
+		return Text(fmt.Sprintf("%s = cfa%+d", reg(inst.reg), inst.off))
+	case DW_CFA_restore, DW_CFA_restore_extended, DW_CFA_undefined, DW_CFA_same_value, DW_CFA_def_cfa_register:
+		return Text(reg(inst.reg))
+	case DW_CFA_register:
+		return Text(fmt.Sprintf("%s = %s", reg(inst.reg), reg(inst.reg2)))
+	case DW_CFA_def_cfa, DW_CFA_def_cfa_sf:
+		return Text(fmt.Sprintf("%s%+d", reg(inst.reg), inst.off))
+	case DW_CFA_def_cfa_offset, DW_CFA_def_cfa_offset_sf, DW_CFA_GNU_args_size:
+		return Text(fmt.Sprint(inst.off))
+	case DW_CFA_def_cfa_expression:
+		return p.f.exprValue(inst.expr, p.ptrSize())
+	case DW_CFA_expression:
+		return append(Text(fmt.Sprintf("%s at [", reg(inst.reg))), p.f.exprValue(inst.expr, p.ptrSize())...).appendText("]")
+	case DW_CFA_val_expression:
+		return append(Text(fmt.Sprintf("%s = ", reg(inst.reg))), p.f.exprValue(inst.expr, p.ptrSize())...)
+	}
+	return nil
+}
+
+// cfaRule is a rule of a register, or CFA if op is DW_CFA_def_cfa*.
+type cfaRule struct {
+	op   DW_CFA // the instruction which defined the rule
+	reg  uint64
+	off  int64
+	expr []byte
+}
+
+type cfaState struct {
+	cfa  cfaRule
+	regs map[uint64]cfaRule
+}
+
+func (s *cfaState) clone() *cfaState {
+	c := &cfaState{cfa: s.cfa, regs: make(map[uint64]cfaRule, len(s.regs))}
+	for r, rule := range s.regs {
+		c.regs[r] = rule
+	}
+	return c
+}
+
+// execute runs insts from loc, and calls emit with each row of the unwind table.
+func (s *cfaState) execute(insts []cfaInst, init *cfaState, loc uint64, emit func(loc, next uint64, s *cfaState)) uint64 {
+	var stack []*cfaState
+
+	for i := range insts {
+		inst := &insts[i]
+		switch inst.op {
+		case DW_CFA_advance_loc, DW_CFA_advance_loc1, DW_CFA_advance_loc2, DW_CFA_advance_loc4:
+			emit(loc, loc+inst.loc, s)
+			loc += inst.loc
+		case DW_CFA_set_loc:
+			emit(loc, inst.loc, s)
+			loc = inst.loc
+		case DW_CFA_def_cfa, DW_CFA_def_cfa_sf:
+			s.cfa = cfaRule{op: inst.op, reg: inst.reg, off: inst.off}
+		case DW_CFA_def_cfa_register:
+			s.cfa.reg = inst.reg
+			s.cfa.expr = nil
+		case DW_CFA_def_cfa_offset, DW_CFA_def_cfa_offset_sf:
+			s.cfa.off = inst.off
+		case DW_CFA_def_cfa_expression:
+			s.cfa = cfaRule{op: inst.op, expr: inst.expr}
+		case DW_CFA_offset, DW_CFA_offset_extended, DW_CFA_offset_extended_sf, DW_CFA_GNU_negative_offset_extended,
+			DW_CFA_val_offset, DW_CFA_val_offset_sf, DW_CFA_register, DW_CFA_expression, DW_CFA_val_expression,
+			DW_CFA_undefined, DW_CFA_same_value:
+			s.regs[inst.reg] = cfaRule{op: inst.op, reg: inst.reg2, off: inst.off, expr: inst.expr}
+		case DW_CFA_restore, DW_CFA_restore_extended:
+			if rule, ok := init.regs[inst.reg]; ok {
+				s.regs[inst.reg] = rule
+			} else {
+				delete(s.regs, inst.reg)
+			}
+		case DW_CFA_remember_state:
+			stack = append(stack, s.clone())
+		case DW_CFA_restore_state:
+			if len(stack) == 0 {
+				// TODO warning
+				break
+			}
+			*s = *stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	return loc
+}
+
+// unwindNode returns the unwind table of the FDE, which covers [begin, end).
+func (p *parser) unwindNode(info *cieInfo, insts []cfaInst, begin, end uint64) *DataNode {
+	n := &DataNode{Values: []Value{
+		nil,
+		nil,
+		Text("Unwind Table"),
+		Text(fmt.Sprintf("%#x - %#x", begin, end)),
+	}}
+
+	init := &cfaState{regs: make(map[uint64]cfaRule)}
+	init.execute(info.insts, init, begin, func(loc, next uint64, s *cfaState) {})
+
+	emit := func(loc, next uint64, s *cfaState) {
+		if next <= loc {
+			return
+		}
+		n.appendRow(
+			Value{}.appendLink(fmt.Sprintf("%#016x", loc), addressLink(loc, next-loc)),
+			nil,
+			Text("CFA = "+p.cfaRuleString(s.cfa, true)),
+			Text(p.regRulesString(s.regs)),
+		)
+	}
+
+	s := init.clone()
+	loc := s.execute(insts, init, begin, emit)
+	emit(loc, end, s)
+
+	return n
+}
+
+func (p *parser) regRulesString(regs map[uint64]cfaRule) string {
+	rs := make([]uint64, 0, len(regs))
+	for r := range regs {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i] < rs[j] })
+
+	ss := make([]string, len(rs))
+	for i, r := range rs {
+		ss[i] = fmt.Sprintf("%s = %s", p.f.registerString(r), p.cfaRuleString(regs[r], false))
+	}
+	return strings.Join(ss, ", ")
+}
+
+func (p *parser) cfaRuleString(rule cfaRule, cfa bool) string {
+	if cfa {
+		if rule.expr != nil {
+			return p.f.exprValue(rule.expr, p.ptrSize()).String()
+		}
+		return fmt.Sprintf("%s%+d", p.f.registerString(rule.reg), rule.off)
+	}
+
+	switch rule.op {
+	case DW_CFA_offset, DW_CFA_offset_extended, DW_CFA_offset_extended_sf, DW_CFA_GNU_negative_offset_extended:
+		return fmt.Sprintf("[cfa%+d]", rule.off)
+	case DW_CFA_val_offset, DW_CFA_val_offset_sf:
+		return fmt.Sprintf("cfa%+d", rule.off)
+	case DW_CFA_register:
+		return p.f.registerString(rule.reg)
+	case DW_CFA_expression:
+		return fmt.Sprintf("[%s]", p.f.exprValue(rule.expr, p.ptrSize()))
+	case DW_CFA_val_expression:
+		return p.f.exprValue(rule.expr, p.ptrSize()).String()
+	case DW_CFA_undefined:
+		return "undefined"
+	case DW_CFA_same_value:
+		return "same"
+	}
+	return "?"
+}
+
+func (p *parser) ptrSize() int {
+	if p.f.Cpu&0x01000000 == 0 { // 32bit
+		return 4
+	}
+	return 8
+}
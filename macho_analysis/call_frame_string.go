@@ -0,0 +1,71 @@
+// Code generated by "stringer -type=DW_CFA -output call_frame_string.go"; DO NOT EDIT.
+
+package macho_analysis
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[DW_CFA_advance_loc-64]
+	_ = x[DW_CFA_offset-128]
+	_ = x[DW_CFA_restore-192]
+	_ = x[DW_CFA_nop-0]
+	_ = x[DW_CFA_set_loc-1]
+	_ = x[DW_CFA_advance_loc1-2]
+	_ = x[DW_CFA_advance_loc2-3]
+	_ = x[DW_CFA_advance_loc4-4]
+	_ = x[DW_CFA_offset_extended-5]
+	_ = x[DW_CFA_restore_extended-6]
+	_ = x[DW_CFA_undefined-7]
+	_ = x[DW_CFA_same_value-8]
+	_ = x[DW_CFA_register-9]
+	_ = x[DW_CFA_remember_state-10]
+	_ = x[DW_CFA_restore_state-11]
+	_ = x[DW_CFA_def_cfa-12]
+	_ = x[DW_CFA_def_cfa_register-13]
+	_ = x[DW_CFA_def_cfa_offset-14]
+	_ = x[DW_CFA_def_cfa_expression-15]
+	_ = x[DW_CFA_expression-16]
+	_ = x[DW_CFA_offset_extended_sf-17]
+	_ = x[DW_CFA_def_cfa_sf-18]
+	_ = x[DW_CFA_def_cfa_offset_sf-19]
+	_ = x[DW_CFA_val_offset-20]
+	_ = x[DW_CFA_val_offset_sf-21]
+	_ = x[DW_CFA_val_expression-22]
+	_ = x[DW_CFA_GNU_window_save-45]
+	_ = x[DW_CFA_GNU_args_size-46]
+	_ = x[DW_CFA_GNU_negative_offset_extended-47]
+}
+
+const (
+	_DW_CFA_name_0 = "DW_CFA_nopDW_CFA_set_locDW_CFA_advance_loc1DW_CFA_advance_loc2DW_CFA_advance_loc4DW_CFA_offset_extendedDW_CFA_restore_extendedDW_CFA_undefinedDW_CFA_same_valueDW_CFA_registerDW_CFA_remember_stateDW_CFA_restore_stateDW_CFA_def_cfaDW_CFA_def_cfa_registerDW_CFA_def_cfa_offsetDW_CFA_def_cfa_expressionDW_CFA_expressionDW_CFA_offset_extended_sfDW_CFA_def_cfa_sfDW_CFA_def_cfa_offset_sfDW_CFA_val_offsetDW_CFA_val_offset_sfDW_CFA_val_expression"
+	_DW_CFA_name_1 = "DW_CFA_GNU_window_saveDW_CFA_GNU_args_sizeDW_CFA_GNU_negative_offset_extended"
+	_DW_CFA_name_2 = "DW_CFA_advance_loc"
+	_DW_CFA_name_3 = "DW_CFA_offset"
+	_DW_CFA_name_4 = "DW_CFA_restore"
+)
+
+var (
+	_DW_CFA_index_0 = [...]uint16{0, 10, 24, 43, 62, 81, 103, 126, 142, 159, 174, 195, 215, 229, 252, 273, 298, 315, 340, 357, 381, 398, 418, 439}
+	_DW_CFA_index_1 = [...]uint8{0, 22, 42, 77}
+)
+
+func (i DW_CFA) String() string {
+	switch {
+	case i <= 22:
+		return _DW_CFA_name_0[_DW_CFA_index_0[i]:_DW_CFA_index_0[i+1]]
+	case 45 <= i && i <= 47:
+		i -= 45
+		return _DW_CFA_name_1[_DW_CFA_index_1[i]:_DW_CFA_index_1[i+1]]
+	case i == 64:
+		return _DW_CFA_name_2
+	case i == 128:
+		return _DW_CFA_name_3
+	case i == 192:
+		return _DW_CFA_name_4
+	default:
+		return "DW_CFA(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+}
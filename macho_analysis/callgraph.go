@@ -0,0 +1,304 @@
+package macho_analysis
+
+import (
+	"bufio"
+	"debug/macho"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// callGraphDepth is the depth limit of call trees and exported call graphs.
+const callGraphDepth = 16
+
+// maxCallSites is the number of call sites shown in a row of call trees.
+const maxCallSites = 4
+
+var callTreeHeader = []string{"Function", "Call Sites"}
+
+// funcKey is the defined function at addr, or the undefined symbol symnum.
+type funcKey struct {
+	addr   uint64
+	symnum int
+}
+
+type callSite struct {
+	from uint64  // the address of the call instruction
+	fn   funcKey // the callee or the caller
+}
+
+type callGraph struct {
+	callees map[funcKey][]callSite
+	callers map[funcKey][]callSite
+	funcs   []uint64 // sorted addresses of defined symbols
+}
+
+// callGraph builds the call graph from the calls and the tail calls in the xref index.
+// Calls through symbol stubs are resolved to the bound symbols.
+func (f *File) callGraph() *callGraph {
+	if f.calls != nil {
+		return f.calls
+	}
+
+	g := &callGraph{
+		callees: make(map[funcKey][]callSite),
+		callers: make(map[funcKey][]callSite),
+	}
+
+	seen := make(map[uint64]bool)
+	for i := range f.Syms {
+		sym := &f.Syms[i]
+		if sym.Type&N_STAB == 0 && SymbolType(sym.Type&N_TYPE) == N_SECT && !seen[sym.Value] {
+			seen[sym.Value] = true
+			g.funcs = append(g.funcs, sym.Value)
+		}
+	}
+	sort.Slice(g.funcs, func(i, j int) bool {
+		return g.funcs[i] < g.funcs[j]
+	})
+
+	for _, x := range f.xrefTable().from {
+		if x.Kind != XrefCall && x.Kind != XrefJump {
+			continue
+		}
+		caller, ok := f.funcAt(g, x.From)
+		if !ok {
+			continue
+		}
+		callee := f.calleeOf(g, x)
+		if x.Kind == XrefJump {
+			// tail calls
+			if callee == caller || callee.symnum == -1 && callee.addr != x.To {
+				continue
+			}
+		}
+		g.callees[caller] = append(g.callees[caller], callSite{from: x.From, fn: callee})
+		g.callers[callee] = append(g.callers[callee], callSite{from: x.From, fn: caller})
+	}
+
+	f.calls = g
+
+	return f.calls
+}
+
+// funcAt returns the defined function containing addr.
+func (f *File) funcAt(g *callGraph, addr uint64) (funcKey, bool) {
+	i := sort.Search(len(g.funcs), func(i int) bool {
+		return addr < g.funcs[i]
+	})
+	if i == 0 {
+		return funcKey{}, false
+	}
+	base := g.funcs[i-1]
+	if info := f.SymInfos[base]; info == nil || base+info.Size <= addr {
+		return funcKey{}, false
+	}
+	return funcKey{addr: base, symnum: -1}, true
+}
+
+func (f *File) calleeOf(g *callGraph, x Xref) funcKey {
+	if x.Symnum != -1 {
+		return funcKey{symnum: x.Symnum}
+	}
+	if i := f.indirectSymnum(x.To); i != -1 {
+		return funcKey{symnum: i}
+	}
+	if k, ok := f.funcAt(g, x.To); ok {
+		return k
+	}
+	return funcKey{addr: x.To, symnum: -1}
+}
+
+// symKey returns the function of sym.
+func (f *File) symKey(sym *macho.Symbol) funcKey {
+	if sym.Type&N_STAB == 0 && SymbolType(sym.Type&N_TYPE) == N_SECT {
+		return funcKey{addr: sym.Value, symnum: -1}
+	}
+	for i := range f.Syms {
+		if &f.Syms[i] == sym {
+			return funcKey{symnum: i}
+		}
+	}
+	return funcKey{addr: sym.Value, symnum: -1}
+}
+
+// funcValue returns the names of k, which link to the symbols.
+func (f *File) funcValue(k funcKey) Value {
+	if k.symnum != -1 {
+		return Value{}.appendLink(f.symName(f.Syms[k.symnum].Name), symbolLink(k.symnum, 0, 0))
+	}
+	var v Value
+	if info := f.SymInfos[k.addr]; info != nil {
+		for i, si := range info.SymbolIndices {
+			if i > 0 {
+				v = v.appendText("|")
+			}
+			v = v.appendLink(f.symName(f.Syms[si].Name), symbolLink(si, 0, 0))
+		}
+	}
+	if v == nil {
+		v = Value{}.appendLink(fmt.Sprintf("%#x", k.addr), addressLink(k.addr, 0))
+	}
+	return v
+}
+
+// newCallTree returns the tree of callees or callers of sym.
+// Recursive calls aren't expanded, and the tree is cut at callGraphDepth.
+func (f *File) newCallTree(sym *macho.Symbol, callers bool) *DataTree {
+	t := &DataTree{Header: callTreeHeader}
+
+	g := f.callGraph()
+
+	root := f.symKey(sym)
+	n := t.appendRow(f.funcValue(root), nil)
+	n.childrenFunc = f.callNodes(g, root, callers, []funcKey{root})
+
+	return t
+}
+
+func (f *File) callNodes(g *callGraph, k funcKey, callers bool, path []funcKey) func() []*DataNode {
+	return func() []*DataNode {
+		sites := g.callees[k]
+		if callers {
+			sites = g.callers[k]
+		}
+
+		var fns []funcKey
+		froms := make(map[funcKey][]uint64)
+		for _, s := range sites {
+			if _, ok := froms[s.fn]; !ok {
+				fns = append(fns, s.fn)
+			}
+			froms[s.fn] = append(froms[s.fn], s.from)
+		}
+
+		nodes := make([]*DataNode, len(fns))
+		for i, fn := range fns {
+			name := f.funcValue(fn)
+
+			var calls Value
+			for j, from := range froms[fn] {
+				if j == maxCallSites {
+					calls = calls.appendText(fmt.Sprintf(", ... (%d)", len(froms[fn])))
+					break
+				}
+				if j > 0 {
+					calls = calls.appendText(", ")
+				}
+				calls = calls.appendLink(fmt.Sprintf("%#x", from), addressLink(from, 0))
+			}
+
+			n := &DataNode{Values: []Value{name, calls}}
+
+			switch {
+			case inFuncPath(path, fn):
+				n.Values[0] = name.appendText(" (recursive)")
+			case len(path) == callGraphDepth:
+				n.Values[0] = name.appendText(" (depth limit)")
+			default:
+				n.childrenFunc = f.callNodes(g, fn, callers, append(path[:len(path):len(path)], fn))
+			}
+
+			nodes[i] = n
+		}
+		return nodes
+	}
+}
+
+func inFuncPath(path []funcKey, k funcKey) bool {
+	for _, p := range path {
+		if p == k {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteCallGraphDOT writes the call graph in the DOT language.
+// If sym isn't nil, only functions reachable from sym within the depth limit are written,
+// following callers if callers is true, or callees otherwise.
+func (f *File) WriteCallGraphDOT(w io.Writer, sym *macho.Symbol, callers bool) error {
+	g := f.callGraph()
+
+	ids := make(map[funcKey]int)
+	var keys []funcKey
+	id := func(k funcKey) int {
+		i, ok := ids[k]
+		if !ok {
+			i = len(keys)
+			ids[k] = i
+			keys = append(keys, k)
+		}
+		return i
+	}
+
+	type edge struct{ from, to int }
+	var edges []edge
+	seen := make(map[edge]bool)
+	addEdge := func(caller, callee funcKey) {
+		e := edge{id(caller), id(callee)}
+		if !seen[e] {
+			seen[e] = true
+			edges = append(edges, e)
+		}
+	}
+
+	if sym == nil {
+		var callers []funcKey
+		for k := range g.callees {
+			callers = append(callers, k)
+		}
+		sort.Slice(callers, func(i, j int) bool {
+			return callers[i].addr < callers[j].addr || callers[i].addr == callers[j].addr && callers[i].symnum < callers[j].symnum
+		})
+		for _, k := range callers {
+			for _, s := range g.callees[k] {
+				addEdge(k, s.fn)
+			}
+		}
+	} else {
+		root := f.symKey(sym)
+		id(root)
+		depth := map[funcKey]int{root: 0}
+		queue := []funcKey{root}
+		for len(queue) > 0 {
+			k := queue[0]
+			queue = queue[1:]
+			if depth[k] == callGraphDepth {
+				continue
+			}
+			sites := g.callees[k]
+			if callers {
+				sites = g.callers[k]
+			}
+			for _, s := range sites {
+				if callers {
+					addEdge(s.fn, k)
+				} else {
+					addEdge(k, s.fn)
+				}
+				if _, ok := depth[s.fn]; !ok {
+					depth[s.fn] = depth[k] + 1
+					queue = append(queue, s.fn)
+				}
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph calls {")
+	fmt.Fprintln(bw, "\tnode [shape=box];")
+	for i, k := range keys {
+		attrs := ""
+		if k.symnum != -1 {
+			attrs = ", style=dashed"
+		}
+		fmt.Fprintf(bw, "\tn%d [label=%s%s];\n", i, strconv.Quote(f.funcValue(k).String()), attrs)
+	}
+	for _, e := range edges {
+		fmt.Fprintf(bw, "\tn%d -> n%d;\n", e.from, e.to)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
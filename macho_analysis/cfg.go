@@ -0,0 +1,314 @@
+package macho_analysis
+
+import (
+	"debug/macho"
+	"fmt"
+	"sort"
+
+	"golang.org/x/arch/arm64/arm64asm"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// BasicBlock is a sequence of instructions, which is entered at the first one and left at the last one.
+type BasicBlock struct {
+	Start uint64 // the address of the first instruction
+	End   uint64 // the address after the last instruction
+	Lines []string
+
+	Succs []int  // indices of the successors
+	Back  []bool // whether the edge to Succs[i] is a back-edge
+	Loop  bool   // whether the block is in a loop
+}
+
+type branchKind uint8
+
+const (
+	branchNone branchKind = iota
+	branchJump            // unconditional jumps
+	branchCond            // conditional jumps
+	branchExit            // returns, indirect jumps and traps
+)
+
+type branch struct {
+	kind   branchKind
+	target uint64
+	known  bool // whether target is known
+}
+
+// ControlFlowGraph splits sym into basic blocks. The first block is the entry.
+// It returns nil if sym isn't code or the CPU isn't supported.
+func (f *File) ControlFlowGraph(sym *macho.Symbol) []*BasicBlock {
+	if f.GuessSymType(sym) != "Code" {
+		return nil
+	}
+
+	disasm := f.disasmFunc()
+	decode := f.branchFunc()
+	if disasm == nil || decode == nil {
+		// TODO warning
+		return nil
+	}
+
+	addr := sym.Value
+	sect := f.Sections[sym.Sect-1]
+	info := f.SymInfos[addr]
+
+	sdata, err := sect.Data()
+	if err != nil {
+		// TODO warning
+		return nil
+	}
+	if addr < sect.Addr || addr+info.Size < addr || addr+info.Size > sect.Addr+uint64(len(sdata)) {
+		// e.g. __mh_execute_header, which is before __text
+		return nil
+	}
+	relocs := f.relocTargets(sect, sdata)
+	data := sdata[addr-sect.Addr : addr-sect.Addr+info.Size]
+
+	inside := func(a uint64) bool {
+		return addr <= a && a < addr+info.Size
+	}
+
+	type inst struct {
+		pc   uint64
+		size int
+		br   branch
+	}
+
+	var insts []inst
+
+	leaders := map[uint64]bool{addr: true}
+
+	for off := 0; off < len(data); {
+		pc := addr + uint64(off)
+		br, size := decode(data[off:], pc)
+		if off+size > len(data) {
+			size = len(data) - off
+		}
+
+		// the target of a relocated branch is out of sym
+		for i := 0; i < size; i++ {
+			if _, ok := relocs[pc-sect.Addr+uint64(i)]; ok {
+				br.known = false
+			}
+		}
+		if br.known && !inside(br.target) {
+			br.known = false
+		}
+
+		insts = append(insts, inst{pc: pc, size: size, br: br})
+
+		if br.kind != branchNone {
+			if br.known {
+				leaders[br.target] = true
+			}
+			leaders[pc+uint64(size)] = true
+		}
+
+		off += size
+	}
+
+	var blocks []*BasicBlock
+	index := make(map[uint64]int) // start address -> block index
+	var last []branch             // the branch at the end of blocks
+
+	for _, in := range insts {
+		if leaders[in.pc] || len(blocks) == 0 {
+			index[in.pc] = len(blocks)
+			blocks = append(blocks, &BasicBlock{Start: in.pc})
+			last = append(last, branch{})
+		}
+		b := blocks[len(blocks)-1]
+		b.End = in.pc + uint64(in.size)
+		text, _ := disasm(data[in.pc-addr:], in.pc)
+		b.Lines = append(b.Lines, fmt.Sprintf("%#x: %s", in.pc, text))
+		last[len(last)-1] = in.br
+	}
+
+	for i, b := range blocks {
+		br := last[i]
+		if br.known {
+			// a target in the middle of an instruction isn't a leader
+			if j, ok := index[br.target]; ok {
+				b.Succs = append(b.Succs, j)
+			}
+		}
+		if br.kind == branchNone || br.kind == branchCond {
+			if i+1 < len(blocks) && (len(b.Succs) == 0 || b.Succs[0] != i+1) {
+				b.Succs = append(b.Succs, i+1)
+			}
+		}
+		b.Back = make([]bool, len(b.Succs))
+	}
+
+	markLoops(blocks)
+
+	return blocks
+}
+
+// markLoops marks back-edges found by DFS from the entry, and blocks in their natural loops.
+func markLoops(blocks []*BasicBlock) {
+	if len(blocks) == 0 {
+		return
+	}
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+
+	state := make([]int, len(blocks))
+
+	preds := make([][]int, len(blocks))
+	for i, b := range blocks {
+		for _, j := range b.Succs {
+			preds[j] = append(preds[j], i)
+		}
+	}
+
+	type frame struct {
+		block int
+		next  int // the next successor to visit
+	}
+
+	var backs [][2]int
+
+	stack := []frame{{block: 0}}
+	state[0] = onStack
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		b := blocks[top.block]
+		if top.next == len(b.Succs) {
+			state[top.block] = done
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		i := top.next
+		top.next++
+		switch j := b.Succs[i]; state[j] {
+		case unvisited:
+			state[j] = onStack
+			stack = append(stack, frame{block: j})
+		case onStack:
+			b.Back[i] = true
+			backs = append(backs, [2]int{top.block, j})
+		}
+	}
+
+	for _, e := range backs {
+		tail, head := e[0], e[1]
+		blocks[head].Loop = true
+		seen := map[int]bool{head: true}
+		work := []int{tail}
+		for len(work) > 0 {
+			i := work[len(work)-1]
+			work = work[:len(work)-1]
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			blocks[i].Loop = true
+			work = append(work, preds[i]...)
+		}
+	}
+}
+
+// BlockAt returns the index of the block containing addr, or -1.
+func BlockAt(blocks []*BasicBlock, addr uint64) int {
+	i := sort.Search(len(blocks), func(i int) bool {
+		return addr < blocks[i].End
+	})
+	if i < len(blocks) && blocks[i].Start <= addr {
+		return i
+	}
+	return -1
+}
+
+// branchFunc returns a function decoding the branch at pc, or nil if the CPU isn't supported.
+// TODO support arm and ppc64
+func (f *File) branchFunc() func(code []byte, pc uint64) (branch, int) {
+	switch f.Cpu {
+	case macho.Cpu386:
+		return func(code []byte, pc uint64) (branch, int) {
+			inst, err := x86asm.Decode(code, 32)
+			if err != nil {
+				return branch{}, 1
+			}
+			return x86Branch(inst, pc), inst.Len
+		}
+	case macho.CpuAmd64:
+		return func(code []byte, pc uint64) (branch, int) {
+			inst, err := x86asm.Decode(code, 64)
+			if err != nil {
+				return branch{}, 1
+			}
+			return x86Branch(inst, pc), inst.Len
+		}
+	case macho.CpuArm | 0x01000000:
+		return func(code []byte, pc uint64) (branch, int) {
+			inst, err := arm64asm.Decode(code)
+			if err != nil {
+				return branch{}, 4
+			}
+			return arm64Branch(inst, pc), 4
+		}
+	}
+
+	return nil
+}
+
+func x86Branch(inst x86asm.Inst, pc uint64) branch {
+	var br branch
+
+	switch inst.Op {
+	case x86asm.JMP:
+		br.kind = branchJump
+	case x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JCXZ, x86asm.JE, x86asm.JECXZ, x86asm.JG, x86asm.JGE,
+		x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP, x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JRCXZ, x86asm.JS,
+		x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		br.kind = branchCond
+	case x86asm.RET, x86asm.LRET, x86asm.IRET, x86asm.IRETD, x86asm.IRETQ, x86asm.HLT, x86asm.UD0, x86asm.UD1, x86asm.UD2:
+		return branch{kind: branchExit}
+	default:
+		return br
+	}
+
+	if rel, ok := inst.Args[0].(x86asm.Rel); ok {
+		br.target = addAddend(pc+uint64(inst.Len), int64(rel))
+		br.known = true
+	} else if br.kind == branchJump {
+		// indirect jumps, e.g. switch tables
+		br.kind = branchExit
+	}
+
+	return br
+}
+
+func arm64Branch(inst arm64asm.Inst, pc uint64) branch {
+	var br branch
+
+	switch inst.Op {
+	case arm64asm.B:
+		if inst.Args[1] == nil {
+			br.kind = branchJump
+		} else {
+			br.kind = branchCond
+		}
+	case arm64asm.CBZ, arm64asm.CBNZ, arm64asm.TBZ, arm64asm.TBNZ:
+		br.kind = branchCond
+	case arm64asm.RET, arm64asm.BR, arm64asm.ERET, arm64asm.BRK, arm64asm.HLT:
+		return branch{kind: branchExit}
+	default:
+		return br
+	}
+
+	for _, arg := range inst.Args {
+		if rel, ok := arg.(arm64asm.PCRel); ok {
+			br.target = addAddend(pc, int64(rel))
+			br.known = true
+		}
+	}
+
+	return br
+}
@@ -0,0 +1,70 @@
+package macho_analysis
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"testing"
+)
+
+// newTestExec returns an x86-64 executable of code, where __mh_execute_header is before __text as usual.
+func newTestExec(t *testing.T, code []byte) *File {
+	const (
+		base    = 0x100000000
+		textOff = 0x100
+	)
+	le := binary.LittleEndian
+	strtab := []byte("\x00__mh_execute_header\x00_main\x00")
+	symOff := uint32(textOff + len(code))
+	strOff := symOff + 2*16
+
+	var b bytes.Buffer
+	write := func(v interface{}) {
+		if err := binary.Write(&b, le, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmdsz := uint32(72 + 80 + 24)
+	write(macho.FileHeader{Magic: macho.Magic64, Cpu: macho.CpuAmd64, SubCpu: 3, Type: macho.TypeExec, Ncmd: 2, Cmdsz: cmdsz})
+	write(uint32(0)) // reserved
+	seg := macho.Segment64{Cmd: macho.LoadCmdSegment64, Len: 72 + 80, Addr: base, Memsz: 0x1000, Filesz: uint64(strOff) + uint64(len(strtab)), Maxprot: 5, Prot: 5, Nsect: 1}
+	copy(seg.Name[:], "__TEXT")
+	write(seg)
+	sect := macho.Section64{Addr: base + textOff, Size: uint64(len(code)), Offset: textOff, Flags: 0x80000400}
+	copy(sect.Name[:], "__text")
+	copy(sect.Seg[:], "__TEXT")
+	write(sect)
+	write(macho.SymtabCmd{Cmd: macho.LoadCmdSymtab, Len: 24, Symoff: symOff, Nsyms: 2, Stroff: strOff, Strsize: uint32(len(strtab))})
+	b.Write(make([]byte, textOff-b.Len()))
+	b.Write(code)
+	write(macho.Nlist64{Name: 1, Type: 0x0f, Sect: 1, Desc: 0x10, Value: base})
+	write(macho.Nlist64{Name: 21, Type: 0x0f, Sect: 1, Value: base + textOff})
+	b.Write(strtab)
+
+	mf, err := macho.NewFile(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewFile(mf)
+}
+
+func TestControlFlowGraph(t *testing.T) {
+	// push %rbp; mov %rsp,%rbp; pop %rbp; ret
+	f := newTestExec(t, []byte{0x55, 0x48, 0x89, 0xe5, 0x5d, 0xc3})
+
+	for i := range f.Syms {
+		sym := &f.Syms[i]
+		blocks := f.ControlFlowGraph(sym)
+		switch sym.Name {
+		case "__mh_execute_header":
+			// before __text, which has its bytes
+			if blocks != nil {
+				t.Errorf("ControlFlowGraph(%s) = %d blocks; want nil", sym.Name, len(blocks))
+			}
+		case "_main":
+			if len(blocks) != 1 || blocks[0].Start != sym.Value || blocks[0].End != sym.Value+6 {
+				t.Errorf("ControlFlowGraph(%s) = %+v; want a block of 6 bytes", sym.Name, blocks)
+			}
+		}
+	}
+}
@@ -0,0 +1,543 @@
+//go:generate stringer -type=ChainedPtrFormat,ChainedImportFormat -output chained_fixups_string.go
+
+package macho_analysis
+
+// reference:
+// <mach-o/fixup-chains.h>
+// https://opensource.apple.com/source/dyld/dyld-852.2/common/MachOLoaded.cpp
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ChainedPtrFormat uint16
+
+const (
+	DYLD_CHAINED_PTR_ARM64E              ChainedPtrFormat = 1
+	DYLD_CHAINED_PTR_64                  ChainedPtrFormat = 2
+	DYLD_CHAINED_PTR_32                  ChainedPtrFormat = 3
+	DYLD_CHAINED_PTR_32_CACHE            ChainedPtrFormat = 4
+	DYLD_CHAINED_PTR_32_FIRMWARE         ChainedPtrFormat = 5
+	DYLD_CHAINED_PTR_64_OFFSET           ChainedPtrFormat = 6
+	DYLD_CHAINED_PTR_ARM64E_KERNEL       ChainedPtrFormat = 7
+	DYLD_CHAINED_PTR_64_KERNEL_CACHE     ChainedPtrFormat = 8
+	DYLD_CHAINED_PTR_ARM64E_USERLAND     ChainedPtrFormat = 9
+	DYLD_CHAINED_PTR_ARM64E_FIRMWARE     ChainedPtrFormat = 10
+	DYLD_CHAINED_PTR_X86_64_KERNEL_CACHE ChainedPtrFormat = 11
+	DYLD_CHAINED_PTR_ARM64E_USERLAND24   ChainedPtrFormat = 12
+)
+
+type ChainedImportFormat uint32
+
+const (
+	DYLD_CHAINED_IMPORT          ChainedImportFormat = 1
+	DYLD_CHAINED_IMPORT_ADDEND   ChainedImportFormat = 2
+	DYLD_CHAINED_IMPORT_ADDEND64 ChainedImportFormat = 3
+)
+
+const (
+	DYLD_CHAINED_PTR_START_NONE  = 0xffff
+	DYLD_CHAINED_PTR_START_MULTI = 0x8000
+	DYLD_CHAINED_PTR_START_LAST  = 0x8000
+)
+
+// ChainedFixupsHeader is a struct dyld_chained_fixups_header.
+type ChainedFixupsHeader struct {
+	FixupsVersion uint32
+	StartsOffset  uint32
+	ImportsOffset uint32
+	SymbolsOffset uint32
+	ImportsCount  uint32
+	ImportsFormat ChainedImportFormat
+	SymbolsFormat uint32
+}
+
+// ChainedStarts is a struct dyld_chained_starts_in_segment.
+type ChainedStarts struct {
+	SegIndex        int
+	Seg             *macho.Segment
+	Size            uint32
+	PageSize        uint16
+	PointerFormat   ChainedPtrFormat
+	SegmentOffset   uint64
+	MaxValidPointer uint32
+	PageCount       uint16
+	PageStarts      []uint16 // including overflow starts
+}
+
+type ChainedImport struct {
+	LibOrdinal int
+	Weak       bool
+	Name       string
+	Addend     int64
+}
+
+type ChainedFixup struct {
+	Seg    *macho.Segment
+	Addr   uint64
+	Raw    uint64
+	Size   int
+	Format ChainedPtrFormat
+
+	Bind   bool
+	Target uint64 // exist if it is a rebase
+	Import int    // exist if it is a bind
+	Addend int64  // exist if it is a bind
+
+	Auth      bool
+	Key       uint8
+	Diversity uint16
+	AddrDiv   bool
+}
+
+type ChainedFixups struct {
+	Header  ChainedFixupsHeader
+	Starts  []ChainedStarts
+	Imports []ChainedImport
+	Fixups  []ChainedFixup // sorted by address
+}
+
+// Lookup returns the fixup located at addr, or nil.
+func (c *ChainedFixups) Lookup(addr uint64) *ChainedFixup {
+	if c == nil {
+		return nil
+	}
+	i := sort.Search(len(c.Fixups), func(i int) bool {
+		return addr <= c.Fixups[i].Addr
+	})
+	if i < len(c.Fixups) && c.Fixups[i].Addr == addr {
+		return &c.Fixups[i]
+	}
+	return nil
+}
+
+func (f *File) chainedFixupsData() ([]byte, error) {
+	cmd := f.linkeditDataCmd(LC_DYLD_CHAINED_FIXUPS)
+	if cmd == nil {
+		return nil, nil
+	}
+	return f.readFileData(uint64(cmd.Dataoff), uint64(cmd.Datasize))
+}
+
+// decodeChainedFixups parses the payload of LC_DYLD_CHAINED_FIXUPS and walks all chains.
+// On error, it returns fixups decoded so far.
+func (f *File) decodeChainedFixups(data []byte) (*ChainedFixups, error) {
+	c := new(ChainedFixups)
+
+	bo := f.ByteOrder
+
+	if err := binary.Read(bytes.NewReader(data), bo, &c.Header); err != nil {
+		return c, err
+	}
+
+	imports, err := f.decodeChainedImports(data, &c.Header)
+	c.Imports = imports
+	if err != nil {
+		return c, err
+	}
+
+	starts, err := f.decodeChainedStarts(data, &c.Header)
+	c.Starts = starts
+	if err != nil {
+		return c, err
+	}
+
+	base := f.baseAddr()
+
+	for i := range c.Starts {
+		s := &c.Starts[i]
+
+		sdata, err := s.Seg.Data()
+		if err != nil {
+			return c, err
+		}
+
+		for p, start := range s.PageStarts[:s.PageCount] {
+			if start == DYLD_CHAINED_PTR_START_NONE {
+				continue
+			}
+
+			var offs []uint16
+			if start&DYLD_CHAINED_PTR_START_MULTI != 0 {
+				// overflow starts for 32-bit formats
+				for j := int(start &^ DYLD_CHAINED_PTR_START_MULTI); j < len(s.PageStarts); j++ {
+					offs = append(offs, s.PageStarts[j]&^DYLD_CHAINED_PTR_START_LAST)
+					if s.PageStarts[j]&DYLD_CHAINED_PTR_START_LAST != 0 {
+						break
+					}
+				}
+			} else {
+				offs = []uint16{start}
+			}
+
+			for _, off := range offs {
+				addr := base + s.SegmentOffset + uint64(p)*uint64(s.PageSize) + uint64(off)
+
+				fixups, err := f.walkChain(s, sdata, addr, base)
+				c.Fixups = append(c.Fixups, fixups...)
+				if err != nil {
+					sort.Slice(c.Fixups, func(i, j int) bool { return c.Fixups[i].Addr < c.Fixups[j].Addr })
+					return c, err
+				}
+			}
+		}
+	}
+
+	sort.Slice(c.Fixups, func(i, j int) bool { return c.Fixups[i].Addr < c.Fixups[j].Addr })
+
+	return c, nil
+}
+
+func (f *File) decodeChainedImports(data []byte, h *ChainedFixupsHeader) ([]ChainedImport, error) {
+	bo := f.ByteOrder
+
+	if h.SymbolsFormat != 0 {
+		return nil, fmt.Errorf("unsupported symbols format %d", h.SymbolsFormat)
+	}
+	if uint64(h.SymbolsOffset) > uint64(len(data)) {
+		return nil, errors.New("symbols offset is out of range")
+	}
+
+	symbols := data[h.SymbolsOffset:]
+
+	symbolName := func(off uint64) string {
+		if off >= uint64(len(symbols)) {
+			// TODO warning
+			return ""
+		}
+		name := symbols[off:]
+		if i := bytes.IndexByte(name, 0); i != -1 {
+			name = name[:i]
+		}
+		return string(name)
+	}
+
+	var esize uint64
+	switch h.ImportsFormat {
+	case DYLD_CHAINED_IMPORT:
+		esize = 4
+	case DYLD_CHAINED_IMPORT_ADDEND:
+		esize = 8
+	case DYLD_CHAINED_IMPORT_ADDEND64:
+		esize = 16
+	default:
+		return nil, fmt.Errorf("unknown imports format %d", h.ImportsFormat)
+	}
+
+	off := uint64(h.ImportsOffset)
+
+	if off+esize*uint64(h.ImportsCount) > uint64(len(data)) {
+		return nil, errors.New("imports table is out of range")
+	}
+
+	imports := make([]ChainedImport, h.ImportsCount)
+	for i := range imports {
+		p := data[off+esize*uint64(i):]
+
+		imp := &imports[i]
+
+		switch h.ImportsFormat {
+		case DYLD_CHAINED_IMPORT, DYLD_CHAINED_IMPORT_ADDEND:
+			v := bo.Uint32(p)
+			imp.LibOrdinal = int(v & 0xff)
+			if imp.LibOrdinal > 0xf0 {
+				// small negative ordinals are special
+				imp.LibOrdinal -= 0x100
+			}
+			imp.Weak = (v>>8)&1 != 0
+			imp.Name = symbolName(uint64(v >> 9))
+			if h.ImportsFormat == DYLD_CHAINED_IMPORT_ADDEND {
+				imp.Addend = int64(int32(bo.Uint32(p[4:])))
+			}
+		case DYLD_CHAINED_IMPORT_ADDEND64:
+			v := bo.Uint64(p)
+			imp.LibOrdinal = int(v & 0xffff)
+			if imp.LibOrdinal > 0xfff0 {
+				imp.LibOrdinal -= 0x10000
+			}
+			imp.Weak = (v>>16)&1 != 0
+			imp.Name = symbolName(v >> 32)
+			imp.Addend = int64(bo.Uint64(p[8:]))
+		}
+	}
+
+	return imports, nil
+}
+
+func (f *File) decodeChainedStarts(data []byte, h *ChainedFixupsHeader) ([]ChainedStarts, error) {
+	bo := f.ByteOrder
+
+	off := uint64(h.StartsOffset)
+	if off+4 > uint64(len(data)) {
+		return nil, errors.New("starts offset is out of range")
+	}
+
+	segCount := uint64(bo.Uint32(data[off:]))
+	if off+4+4*segCount > uint64(len(data)) {
+		return nil, errors.New("truncated starts in image")
+	}
+
+	segs := f.segments()
+
+	var starts []ChainedStarts
+
+	for i := uint64(0); i < segCount; i++ {
+		soff := uint64(bo.Uint32(data[off+4+4*i:]))
+		if soff == 0 {
+			continue
+		}
+		if int(i) >= len(segs) {
+			return starts, fmt.Errorf("segment index %d is out of range", i)
+		}
+
+		p := off + soff
+		if p+22 > uint64(len(data)) {
+			return starts, errors.New("truncated starts in segment")
+		}
+
+		s := ChainedStarts{
+			SegIndex:        int(i),
+			Seg:             segs[i],
+			Size:            bo.Uint32(data[p:]),
+			PageSize:        bo.Uint16(data[p+4:]),
+			PointerFormat:   ChainedPtrFormat(bo.Uint16(data[p+6:])),
+			SegmentOffset:   bo.Uint64(data[p+8:]),
+			MaxValidPointer: bo.Uint32(data[p+16:]),
+		}
+
+		s.PageCount = bo.Uint16(data[p+20:])
+
+		pageCount := uint64(s.PageCount)
+
+		// page_start is followed by overflow starts (up to size)
+		n := pageCount
+		if m := (uint64(s.Size) - 22) / 2; uint64(s.Size) >= 22 && m > n {
+			n = m
+		}
+		if p+22+2*n > uint64(len(data)) {
+			return starts, errors.New("truncated page starts")
+		}
+
+		s.PageStarts = make([]uint16, n)
+		for j := range s.PageStarts {
+			s.PageStarts[j] = bo.Uint16(data[p+22+2*uint64(j):])
+		}
+
+		starts = append(starts, s)
+	}
+
+	return starts, nil
+}
+
+// walkChain follows the chain which starts at addr.
+// sdata is the content of the segment.
+func (f *File) walkChain(s *ChainedStarts, sdata []byte, addr, base uint64) ([]ChainedFixup, error) {
+	var fixups []ChainedFixup
+
+	bo := f.ByteOrder
+
+	var stride uint64
+	var size int
+
+	switch s.PointerFormat {
+	case DYLD_CHAINED_PTR_ARM64E, DYLD_CHAINED_PTR_ARM64E_USERLAND, DYLD_CHAINED_PTR_ARM64E_USERLAND24:
+		stride, size = 8, 8
+	case DYLD_CHAINED_PTR_ARM64E_KERNEL, DYLD_CHAINED_PTR_ARM64E_FIRMWARE:
+		stride, size = 4, 8
+	case DYLD_CHAINED_PTR_64, DYLD_CHAINED_PTR_64_OFFSET, DYLD_CHAINED_PTR_64_KERNEL_CACHE:
+		stride, size = 4, 8
+	case DYLD_CHAINED_PTR_X86_64_KERNEL_CACHE:
+		stride, size = 1, 8
+	case DYLD_CHAINED_PTR_32, DYLD_CHAINED_PTR_32_CACHE, DYLD_CHAINED_PTR_32_FIRMWARE:
+		stride, size = 4, 4
+	default:
+		return nil, fmt.Errorf("unknown pointer format %d", s.PointerFormat)
+	}
+
+	for {
+		if addr < s.Seg.Addr || addr-s.Seg.Addr+uint64(size) > uint64(len(sdata)) {
+			return fixups, fmt.Errorf("chain address %#x is out of segment", addr)
+		}
+
+		p := sdata[addr-s.Seg.Addr:]
+
+		var raw uint64
+		if size == 8 {
+			raw = bo.Uint64(p)
+		} else {
+			raw = uint64(bo.Uint32(p))
+		}
+
+		fx := ChainedFixup{
+			Seg:    s.Seg,
+			Addr:   addr,
+			Raw:    raw,
+			Size:   size,
+			Format: s.PointerFormat,
+		}
+
+		next := f.decodeChainedPtr(&fx, s, base)
+
+		fixups = append(fixups, fx)
+
+		if next == 0 {
+			break
+		}
+
+		addr += next * stride
+	}
+
+	return fixups, nil
+}
+
+// decodeChainedPtr fills fx from fx.Raw, and returns the next field.
+func (f *File) decodeChainedPtr(fx *ChainedFixup, s *ChainedStarts, base uint64) uint64 {
+	raw := fx.Raw
+
+	bits := func(off, n uint) uint64 {
+		return (raw >> off) & (1<<n - 1)
+	}
+
+	switch s.PointerFormat {
+	case DYLD_CHAINED_PTR_ARM64E, DYLD_CHAINED_PTR_ARM64E_KERNEL, DYLD_CHAINED_PTR_ARM64E_USERLAND, DYLD_CHAINED_PTR_ARM64E_FIRMWARE, DYLD_CHAINED_PTR_ARM64E_USERLAND24:
+		fx.Bind = bits(62, 1) != 0
+		fx.Auth = bits(63, 1) != 0
+		next := bits(51, 11)
+
+		if fx.Auth {
+			fx.Diversity = uint16(bits(32, 16))
+			fx.AddrDiv = bits(48, 1) != 0
+			fx.Key = uint8(bits(49, 2))
+		}
+
+		switch {
+		case fx.Bind && s.PointerFormat == DYLD_CHAINED_PTR_ARM64E_USERLAND24:
+			fx.Import = int(bits(0, 24))
+			if !fx.Auth {
+				fx.Addend = signExtend(bits(32, 19), 19)
+			}
+		case fx.Bind:
+			fx.Import = int(bits(0, 16))
+			if !fx.Auth {
+				fx.Addend = signExtend(bits(32, 19), 19)
+			}
+		case fx.Auth:
+			fx.Target = base + bits(0, 32)
+		default:
+			target := bits(0, 43) | bits(43, 8)<<56
+			switch s.PointerFormat {
+			case DYLD_CHAINED_PTR_ARM64E, DYLD_CHAINED_PTR_ARM64E_FIRMWARE:
+				fx.Target = target
+			default:
+				fx.Target = base + target
+			}
+		}
+
+		return next
+	case DYLD_CHAINED_PTR_64, DYLD_CHAINED_PTR_64_OFFSET:
+		fx.Bind = bits(63, 1) != 0
+		next := bits(51, 12)
+
+		if fx.Bind {
+			fx.Import = int(bits(0, 24))
+			fx.Addend = int64(bits(24, 8))
+		} else {
+			target := bits(0, 36)
+			if s.PointerFormat == DYLD_CHAINED_PTR_64_OFFSET {
+				target += base
+			}
+			fx.Target = target | bits(36, 8)<<56
+		}
+
+		return next
+	case DYLD_CHAINED_PTR_64_KERNEL_CACHE, DYLD_CHAINED_PTR_X86_64_KERNEL_CACHE:
+		fx.Auth = bits(63, 1) != 0
+		if fx.Auth {
+			fx.Diversity = uint16(bits(32, 16))
+			fx.AddrDiv = bits(48, 1) != 0
+			fx.Key = uint8(bits(49, 2))
+		}
+		fx.Target = base + bits(0, 30)
+
+		return bits(51, 12)
+	case DYLD_CHAINED_PTR_32:
+		fx.Bind = bits(31, 1) != 0
+		next := bits(26, 5)
+
+		if fx.Bind {
+			fx.Import = int(bits(0, 20))
+			fx.Addend = int64(bits(20, 6))
+		} else {
+			target := bits(0, 26)
+			if target > uint64(s.MaxValidPointer) {
+				// non-pointer, stolen by the chain
+				bias := (0x04000000 + uint64(s.MaxValidPointer)) / 2
+				target -= bias
+			}
+			fx.Target = target
+		}
+
+		return next
+	case DYLD_CHAINED_PTR_32_CACHE:
+		fx.Target = base + bits(0, 30)
+
+		return bits(30, 2)
+	case DYLD_CHAINED_PTR_32_FIRMWARE:
+		fx.Target = bits(0, 26)
+
+		return bits(26, 6)
+	}
+
+	return 0
+}
+
+func (f *File) chainedImportString(c *ChainedFixups, i int) string {
+	if i < 0 || len(c.Imports) <= i {
+		// TODO warning
+		return fmt.Sprintf("%d (?)", i)
+	}
+	return f.symName(c.Imports[i].Name)
+}
+
+func (f *File) chainedAuthString(fx *ChainedFixup) string {
+	if !fx.Auth {
+		return ""
+	}
+	keys := []string{"IA", "IB", "DA", "DB"}
+	return fmt.Sprintf("key=%s diversity=%#04x addrDiv=%t", keys[fx.Key&3], fx.Diversity, fx.AddrDiv)
+}
+
+// fixupString describes the resolved value of the pointer.
+func (f *File) fixupString(c *ChainedFixups, fx *ChainedFixup) string {
+	var vals []string
+
+	if fx.Bind {
+		name := f.chainedImportString(c, fx.Import)
+		addend := fx.Addend
+		if 0 <= fx.Import && fx.Import < len(c.Imports) {
+			addend += c.Imports[fx.Import].Addend
+		}
+		if addend != 0 {
+			name = fmt.Sprintf("%s%+d", name, addend)
+		}
+		vals = append(vals, "bind "+name)
+	} else {
+		suffix := ""
+		if s := f.symAddrString(fx.Target, false); s != "" {
+			suffix = fmt.Sprintf(" (%s)", s)
+		}
+		vals = append(vals, fmt.Sprintf("rebase %#016x%s", fx.Target, suffix))
+	}
+
+	if fx.Auth {
+		vals = append(vals, f.chainedAuthString(fx))
+	}
+
+	return strings.Join(vals, " ")
+}
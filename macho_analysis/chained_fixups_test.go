@@ -0,0 +1,47 @@
+package macho_analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeChainedPtr(t *testing.T) {
+	const base = 0x100000000
+
+	for _, tt := range []struct {
+		format ChainedPtrFormat
+		raw    uint64
+		fx     ChainedFixup
+		next   uint64
+	}{
+		// rebases
+		{DYLD_CHAINED_PTR_64, 0x1234 | 0x80<<36 | 3<<51, ChainedFixup{Target: 0x8000000000001234}, 3},
+		{DYLD_CHAINED_PTR_64_OFFSET, 0x4000 | 1<<51, ChainedFixup{Target: base + 0x4000}, 1},
+		{DYLD_CHAINED_PTR_ARM64E, 0x100004000 | 0x12<<43 | 1<<51, ChainedFixup{Target: 0x1200000100004000}, 1},
+		{DYLD_CHAINED_PTR_ARM64E_USERLAND, 0x4000, ChainedFixup{Target: base + 0x4000}, 0},
+		{
+			DYLD_CHAINED_PTR_ARM64E, 0x4000 | 0x1234<<32 | 1<<48 | 2<<49 | 1<<51 | 1<<63,
+			ChainedFixup{Target: base + 0x4000, Auth: true, Key: 2, Diversity: 0x1234, AddrDiv: true}, 1,
+		},
+		{DYLD_CHAINED_PTR_32, 0x1000 | 2<<26, ChainedFixup{Target: 0x1000}, 2},
+		{DYLD_CHAINED_PTR_32, 0x3000000, ChainedFixup{Target: 0xf80000}, 0}, // non-pointer
+
+		// binds
+		{DYLD_CHAINED_PTR_64, 5 | 7<<24 | 2<<51 | 1<<63, ChainedFixup{Bind: true, Import: 5, Addend: 7}, 2},
+		{DYLD_CHAINED_PTR_ARM64E, 3 | 0x7fffc<<32 | 1<<62, ChainedFixup{Bind: true, Import: 3, Addend: -4}, 0},
+		{DYLD_CHAINED_PTR_ARM64E_USERLAND24, 0x123456 | 1<<62, ChainedFixup{Bind: true, Import: 0x123456}, 0},
+		{
+			DYLD_CHAINED_PTR_ARM64E, 3 | 0x1234<<32 | 1<<49 | 1<<62 | 1<<63,
+			ChainedFixup{Bind: true, Import: 3, Auth: true, Key: 1, Diversity: 0x1234}, 0,
+		},
+		{DYLD_CHAINED_PTR_32, 9 | 3<<20 | 1<<31, ChainedFixup{Bind: true, Import: 9, Addend: 3}, 0},
+	} {
+		s := &ChainedStarts{PointerFormat: tt.format, MaxValidPointer: 0x100000}
+		fx := ChainedFixup{Raw: tt.raw}
+		next := new(File).decodeChainedPtr(&fx, s, base)
+		tt.fx.Raw = tt.raw
+		if !reflect.DeepEqual(fx, tt.fx) || next != tt.next {
+			t.Errorf("decodeChainedPtr(%#x) of %v = %+v, %d; want %+v, %d", tt.raw, tt.format, fx, next, tt.fx, tt.next)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package macho_analysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	chainedStartsHeader = []string{"Segment", "size", "page_size", "pointer_format", "segment_offset", "max_valid_pointer", "page_count", "page_start"}
+	chainedImportHeader = []string{"Index", "Dylib", "Weak", "Name", "Addend"}
+	chainedFixupHeader  = []string{"Segment", "Address", "Raw", "Kind", "Target", "Addend", "Auth"}
+)
+
+func (f *File) newChainedFixupsNode() *StructNode {
+	cmd := f.linkeditDataCmd(LC_DYLD_CHAINED_FIXUPS)
+	if cmd == nil {
+		// TODO warning
+		return &StructNode{Name: "LC_DYLD_CHAINED_FIXUPS (?)"}
+	}
+
+	node := newStructNode(cmd.Cmd.String(), [][]string{
+		{"cmd", fmt.Sprintf("%#08x (%s)", uint32(cmd.Cmd), cmd.Cmd)},
+		{"cmdsize", fmt.Sprintf("%#08x", cmd.Len)},
+		{"dataoff", fmt.Sprintf("%#08x", cmd.Dataoff)},
+		{"datasize", fmt.Sprint(cmd.Datasize)},
+	})
+
+	c := f.Fixups
+	if c == nil {
+		// TODO warning
+		return node
+	}
+
+	h := &c.Header
+
+	node.appendChild(newStructNode("Header", [][]string{
+		{"fixups_version", fmt.Sprint(h.FixupsVersion)},
+		{"starts_offset", fmt.Sprintf("%#08x", h.StartsOffset)},
+		{"imports_offset", fmt.Sprintf("%#08x", h.ImportsOffset)},
+		{"symbols_offset", fmt.Sprintf("%#08x", h.SymbolsOffset)},
+		{"imports_count", fmt.Sprint(h.ImportsCount)},
+		{"imports_format", fmt.Sprintf("%d (%s)", h.ImportsFormat, h.ImportsFormat)},
+		{"symbols_format", fmt.Sprint(h.SymbolsFormat)},
+	}))
+
+	node.appendChild(newTableNode("Starts", chainedStartsHeader, func() [][]Value {
+		rows := make([][]Value, len(c.Starts))
+		for i, s := range c.Starts {
+			pageStarts := make([]string, len(s.PageStarts))
+			for j, start := range s.PageStarts {
+				if start == DYLD_CHAINED_PTR_START_NONE {
+					pageStarts[j] = "none"
+				} else {
+					pageStarts[j] = fmt.Sprintf("%#04x", start)
+				}
+			}
+			rows[i] = []Value{
+				Text(fmt.Sprintf("%d (%s)", s.SegIndex, s.Seg.Name)),
+				Text(fmt.Sprint(s.Size)),
+				Text(fmt.Sprintf("%#x", s.PageSize)),
+				Text(fmt.Sprintf("%d (%s)", s.PointerFormat, s.PointerFormat)),
+				Text(fmt.Sprintf("%#x", s.SegmentOffset)),
+				Text(fmt.Sprintf("%#x", s.MaxValidPointer)),
+				Text(fmt.Sprint(s.PageCount)),
+				Text(strings.Join(pageStarts, " ")),
+			}
+		}
+		return rows
+	}))
+
+	node.appendChild(newTableNode("Imports", chainedImportHeader, func() [][]Value {
+		rows := make([][]Value, len(c.Imports))
+		for i, imp := range c.Imports {
+			rows[i] = []Value{
+				Text(fmt.Sprint(i)),
+				Text(f.dylibOrdinalString(imp.LibOrdinal)),
+				Text(fmt.Sprintf("%t", imp.Weak)),
+				Text(imp.Name),
+				Text(fmt.Sprintf("%+d", imp.Addend)),
+			}
+		}
+		return rows
+	}))
+
+	node.appendChild(newTableNode("Fixups", chainedFixupHeader, func() [][]Value {
+		rows := make([][]Value, len(c.Fixups))
+		for i := range c.Fixups {
+			fx := &c.Fixups[i]
+
+			var kind, addend string
+			var target Value
+			if fx.Bind {
+				kind = "bind"
+				target = Text(f.chainedImportString(c, fx.Import))
+				addend = fmt.Sprintf("%+d", fx.Addend)
+			} else {
+				kind = "rebase"
+				target = f.addrValue(fx.Target, 0)
+			}
+			if fx.Auth {
+				kind = "auth " + kind
+			}
+
+			rows[i] = []Value{
+				Text(fx.Seg.Name),
+				f.addrValue(fx.Addr, uint64(fx.Size)),
+				Text(fmt.Sprintf("%#016x", fx.Raw)),
+				Text(kind),
+				target,
+				Text(addend),
+				Text(f.chainedAuthString(fx)),
+			}
+		}
+		return rows
+	}))
+
+	return node
+}
@@ -0,0 +1,1040 @@
+//go:generate stringer -type=CSMagic,CSSlot,CSHashType -output code_sign_string.go
+
+package macho_analysis
+
+// reference:
+// <kern/cs_blobs.h>
+// https://opensource.apple.com/source/Security/Security-59306.61.1/OSX/libsecurity_codesigning/lib/requirement.h
+// https://opensource.apple.com/source/Security/Security-59306.61.1/OSX/libsecurity_codesigning/lib/reqdumper.cpp
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CSMagic uint32
+
+const (
+	CSMAGIC_REQUIREMENT                CSMagic = 0xfade0c00
+	CSMAGIC_REQUIREMENTS               CSMagic = 0xfade0c01
+	CSMAGIC_CODEDIRECTORY              CSMagic = 0xfade0c02
+	CSMAGIC_EMBEDDED_SIGNATURE         CSMagic = 0xfade0cc0
+	CSMAGIC_EMBEDDED_SIGNATURE_OLD     CSMagic = 0xfade0b02
+	CSMAGIC_EMBEDDED_ENTITLEMENTS      CSMagic = 0xfade7171
+	CSMAGIC_EMBEDDED_DER_ENTITLEMENTS  CSMagic = 0xfade7172
+	CSMAGIC_DETACHED_SIGNATURE         CSMagic = 0xfade0cc1
+	CSMAGIC_BLOBWRAPPER                CSMagic = 0xfade0b01
+	CSMAGIC_EMBEDDED_LAUNCH_CONSTRAINT CSMagic = 0xfade8181
+)
+
+type CSSlot uint32
+
+const (
+	CSSLOT_CODEDIRECTORY                 CSSlot = 0
+	CSSLOT_INFOSLOT                      CSSlot = 1
+	CSSLOT_REQUIREMENTS                  CSSlot = 2
+	CSSLOT_RESOURCEDIR                   CSSlot = 3
+	CSSLOT_APPLICATION                   CSSlot = 4
+	CSSLOT_ENTITLEMENTS                  CSSlot = 5
+	CSSLOT_REP_SPECIFIC                  CSSlot = 6
+	CSSLOT_DER_ENTITLEMENTS              CSSlot = 7
+	CSSLOT_LAUNCH_CONSTRAINT_SELF        CSSlot = 8
+	CSSLOT_LAUNCH_CONSTRAINT_PARENT      CSSlot = 9
+	CSSLOT_LAUNCH_CONSTRAINT_RESPONSIBLE CSSlot = 10
+	CSSLOT_LIBRARY_CONSTRAINT            CSSlot = 11
+	CSSLOT_ALTERNATE_CODEDIRECTORIES     CSSlot = 0x1000
+	CSSLOT_SIGNATURESLOT                 CSSlot = 0x10000
+	CSSLOT_IDENTIFICATIONSLOT            CSSlot = 0x10001
+	CSSLOT_TICKETSLOT                    CSSlot = 0x10002
+)
+
+// CSSLOT_ALTERNATE_CODEDIRECTORIES+i (i < CSSLOT_ALTERNATE_CODEDIRECTORY_MAX) have alternate code directories.
+const CSSLOT_ALTERNATE_CODEDIRECTORY_MAX = 5
+
+// CS_CDHASH_LEN is the size of cdhashes, to which the hashes of all types are truncated.
+const CS_CDHASH_LEN = 20
+
+type CSHashType uint8
+
+const (
+	CS_HASHTYPE_SHA1             CSHashType = 1
+	CS_HASHTYPE_SHA256           CSHashType = 2
+	CS_HASHTYPE_SHA256_TRUNCATED CSHashType = 3
+	CS_HASHTYPE_SHA384           CSHashType = 4
+)
+
+const (
+	CS_SUPPORTSSCATTER     = 0x20100
+	CS_SUPPORTSTEAMID      = 0x20200
+	CS_SUPPORTSCODELIMIT64 = 0x20300
+	CS_SUPPORTSEXECSEG     = 0x20400
+	CS_SUPPORTSRUNTIME     = 0x20500
+)
+
+var csFlagStrings = [...]string{
+	"CS_VALID",
+	"CS_ADHOC",
+	"CS_GET_TASK_ALLOW",
+	"CS_INSTALLER",
+	"CS_FORCED_LV",
+	"CS_INVALID_ALLOWED",
+	"?",
+	"?",
+	"CS_HARD",
+	"CS_KILL",
+	"CS_CHECK_EXPIRATION",
+	"CS_RESTRICT",
+	"CS_ENFORCEMENT",
+	"CS_REQUIRE_LV",
+	"CS_ENTITLEMENTS_VALIDATED",
+	"CS_NVRAM_UNRESTRICTED",
+	"CS_RUNTIME",
+	"CS_LINKER_SIGNED",
+}
+
+var csExecSegFlagStrings = [...]string{
+	"CS_EXECSEG_MAIN_BINARY",
+	"?",
+	"?",
+	"?",
+	"CS_EXECSEG_ALLOW_UNSIGNED",
+	"CS_EXECSEG_DEBUGGER",
+	"CS_EXECSEG_JIT",
+	"CS_EXECSEG_SKIP_LV",
+	"CS_EXECSEG_CAN_LOAD_CDHASH",
+	"CS_EXECSEG_CAN_EXEC_CDHASH",
+}
+
+// CodeSignature is the embedded signature at LC_CODE_SIGNATURE, which is a SuperBlob indexing blobs by slots.
+type CodeSignature struct {
+	Magic  CSMagic
+	Length uint32
+	Blobs  []CSBlob
+}
+
+// CSBlob is a blob in the SuperBlob.
+type CSBlob struct {
+	Slot   CSSlot
+	Offset uint32
+	Magic  CSMagic
+	Length uint32
+	Data   []byte // including magic and length
+}
+
+// Lookup returns the blob at slot, or nil.
+func (cs *CodeSignature) Lookup(slot CSSlot) *CSBlob {
+	for i := range cs.Blobs {
+		if cs.Blobs[i].Slot == slot {
+			return &cs.Blobs[i]
+		}
+	}
+	return nil
+}
+
+// CodeDirectory is a struct CS_CodeDirectory.
+type CodeDirectory struct {
+	Version          uint32
+	Flags            uint32
+	HashOffset       uint32
+	IdentOffset      uint32
+	NSpecialSlots    uint32
+	NCodeSlots       uint32
+	CodeLimit        uint64 // codeLimit64 if it exists
+	HashSize         uint8
+	HashType         CSHashType
+	Platform         uint8
+	PageSize         uint8  // log2 of the page size, or 0 for infinity
+	ScatterOffset    uint32 // exist if Version >= CS_SUPPORTSSCATTER
+	TeamOffset       uint32 // exist if Version >= CS_SUPPORTSTEAMID
+	ExecSegBase      uint64 // exist if Version >= CS_SUPPORTSEXECSEG
+	ExecSegLimit     uint64 // exist if Version >= CS_SUPPORTSEXECSEG
+	ExecSegFlags     uint64 // exist if Version >= CS_SUPPORTSEXECSEG
+	Runtime          uint32 // exist if Version >= CS_SUPPORTSRUNTIME
+	PreEncryptOffset uint32 // exist if Version >= CS_SUPPORTSRUNTIME
+
+	Identifier   string
+	TeamID       string
+	SpecialSlots [][]byte // SpecialSlots[i] is the hash of the slot -(i+1)
+	CodeSlots    [][]byte // CodeSlots[i] is the hash of the i-th page
+}
+
+// CSRequirement is a requirement in the requirement set, which is decompiled into the requirement language.
+type CSRequirement struct {
+	Type uint32
+	Expr string
+}
+
+var csRequirementTypeStrings = [...]string{
+	1: "host",
+	2: "guest",
+	3: "designated",
+	4: "library",
+	5: "plugin",
+}
+
+// CMSSignature is the summary of the CMS signature in CSSLOT_SIGNATURESLOT.
+type CMSSignature struct {
+	Certificates []*x509.Certificate
+	Signers      []CMSSigner
+}
+
+type CMSSigner struct {
+	Cert            *x509.Certificate // nil if the certificate isn't embedded
+	Issuer          string
+	Serial          *big.Int
+	DigestAlgorithm asn1.ObjectIdentifier
+	SigningTime     time.Time // zero if there is no signing time
+}
+
+func (f *File) codeSignatureData() ([]byte, error) {
+	cmd := f.linkeditDataCmd(LC_CODE_SIGNATURE)
+	if cmd == nil {
+		return nil, nil
+	}
+	return f.readFileData(uint64(cmd.Dataoff), uint64(cmd.Datasize))
+}
+
+// CodeSignature returns the embedded signature, or nil if there is no LC_CODE_SIGNATURE.
+func (f *File) CodeSignature() (*CodeSignature, error) {
+	data, err := f.codeSignatureData()
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return decodeCodeSignature(data)
+}
+
+// decodeCodeSignature parses the SuperBlob. Integers in code signatures are big endian.
+func decodeCodeSignature(data []byte) (*CodeSignature, error) {
+	if len(data) < 12 {
+		return nil, errors.New("short code signature")
+	}
+
+	be := binary.BigEndian
+
+	cs := &CodeSignature{
+		Magic:  CSMagic(be.Uint32(data)),
+		Length: be.Uint32(data[4:]),
+	}
+	if cs.Magic != CSMAGIC_EMBEDDED_SIGNATURE && cs.Magic != CSMAGIC_EMBEDDED_SIGNATURE_OLD && cs.Magic != CSMAGIC_DETACHED_SIGNATURE {
+		return nil, fmt.Errorf("unknown code signature magic %#08x", uint32(cs.Magic))
+	}
+	if cs.Length < 12 || uint64(cs.Length) > uint64(len(data)) {
+		return nil, errors.New("code signature is out of range")
+	}
+	data = data[:cs.Length]
+
+	count := be.Uint32(data[8:])
+	if uint64(count)*8 > uint64(len(data)-12) {
+		return nil, errors.New("code signature index is out of range")
+	}
+
+	for i := uint32(0); i < count; i++ {
+		idx := data[12+i*8:]
+		b := CSBlob{
+			Slot:   CSSlot(be.Uint32(idx)),
+			Offset: be.Uint32(idx[4:]),
+		}
+		if uint64(b.Offset)+8 > uint64(len(data)) {
+			return nil, fmt.Errorf("blob %d is out of range", i)
+		}
+		b.Magic = CSMagic(be.Uint32(data[b.Offset:]))
+		b.Length = be.Uint32(data[b.Offset+4:])
+		if b.Length < 8 || uint64(b.Offset)+uint64(b.Length) > uint64(len(data)) {
+			return nil, fmt.Errorf("blob %d is out of range", i)
+		}
+		b.Data = data[b.Offset : b.Offset+b.Length]
+		cs.Blobs = append(cs.Blobs, b)
+	}
+
+	return cs, nil
+}
+
+func decodeCodeDirectory(data []byte) (*CodeDirectory, error) {
+	be := binary.BigEndian
+
+	if len(data) < 44 {
+		return nil, errors.New("short code directory")
+	}
+
+	cd := &CodeDirectory{
+		Version:       be.Uint32(data[8:]),
+		Flags:         be.Uint32(data[12:]),
+		HashOffset:    be.Uint32(data[16:]),
+		IdentOffset:   be.Uint32(data[20:]),
+		NSpecialSlots: be.Uint32(data[24:]),
+		NCodeSlots:    be.Uint32(data[28:]),
+		CodeLimit:     uint64(be.Uint32(data[32:])),
+		HashSize:      data[36],
+		HashType:      CSHashType(data[37]),
+		Platform:      data[38],
+		PageSize:      data[39],
+	}
+
+	// each version appends fields
+	if cd.Version >= CS_SUPPORTSSCATTER && len(data) >= 48 {
+		cd.ScatterOffset = be.Uint32(data[44:])
+	}
+	if cd.Version >= CS_SUPPORTSTEAMID && len(data) >= 52 {
+		cd.TeamOffset = be.Uint32(data[48:])
+	}
+	if cd.Version >= CS_SUPPORTSCODELIMIT64 && len(data) >= 64 {
+		if limit := be.Uint64(data[56:]); limit != 0 {
+			cd.CodeLimit = limit
+		}
+	}
+	if cd.Version >= CS_SUPPORTSEXECSEG && len(data) >= 88 {
+		cd.ExecSegBase = be.Uint64(data[64:])
+		cd.ExecSegLimit = be.Uint64(data[72:])
+		cd.ExecSegFlags = be.Uint64(data[80:])
+	}
+	if cd.Version >= CS_SUPPORTSRUNTIME && len(data) >= 96 {
+		cd.Runtime = be.Uint32(data[88:])
+		cd.PreEncryptOffset = be.Uint32(data[92:])
+	}
+
+	cd.Identifier = cstring(data, cd.IdentOffset)
+	if cd.TeamOffset != 0 {
+		cd.TeamID = cstring(data, cd.TeamOffset)
+	}
+
+	size := uint64(cd.HashSize)
+	hashOff := uint64(cd.HashOffset)
+	if size*uint64(cd.NSpecialSlots) > hashOff || hashOff+size*uint64(cd.NCodeSlots) > uint64(len(data)) {
+		return nil, errors.New("code directory hashes are out of range")
+	}
+	for i := uint64(1); i <= uint64(cd.NSpecialSlots); i++ {
+		cd.SpecialSlots = append(cd.SpecialSlots, data[hashOff-i*size:hashOff-(i-1)*size])
+	}
+	for i := uint64(0); i < uint64(cd.NCodeSlots); i++ {
+		cd.CodeSlots = append(cd.CodeSlots, data[hashOff+i*size:hashOff+(i+1)*size])
+	}
+
+	return cd, nil
+}
+
+// cstring returns the null-terminated string at off in data.
+func cstring(data []byte, off uint32) string {
+	if uint64(off) >= uint64(len(data)) {
+		return ""
+	}
+	s := data[off:]
+	if i := bytes.IndexByte(s, 0); i != -1 {
+		s = s[:i]
+	}
+	return string(s)
+}
+
+// csHash returns the hash of data by typ, which is truncated to size.
+func csHash(typ CSHashType, data []byte, size int) []byte {
+	var sum []byte
+	switch typ {
+	case CS_HASHTYPE_SHA1:
+		s := sha1.Sum(data)
+		sum = s[:]
+	case CS_HASHTYPE_SHA256, CS_HASHTYPE_SHA256_TRUNCATED:
+		s := sha256.Sum256(data)
+		sum = s[:]
+	case CS_HASHTYPE_SHA384:
+		s := sha512.Sum384(data)
+		sum = s[:]
+	default:
+		return nil
+	}
+	if size < len(sum) {
+		sum = sum[:size]
+	}
+	return sum
+}
+
+// pageSize returns the size of pages hashed in code slots.
+func (cd *CodeDirectory) pageSize() uint64 {
+	if cd.PageSize == 0 {
+		return cd.CodeLimit
+	}
+	return 1 << cd.PageSize
+}
+
+// codeSlotStatus checks the hash of the i-th page against the file.
+func (f *File) codeSlotStatus(cd *CodeDirectory, i int) string {
+	off := uint64(i) * cd.pageSize()
+	if off >= cd.CodeLimit {
+		return "?"
+	}
+	size := cd.pageSize()
+	if off+size > cd.CodeLimit {
+		size = cd.CodeLimit - off
+	}
+	data, err := f.readFileData(off, size)
+	if err != nil {
+		// TODO warning
+		return "?"
+	}
+	return csHashStatus(cd, cd.CodeSlots[i], data)
+}
+
+// specialSlotStatus checks the hash of the slot -(i+1) against the blob in the signature.
+func specialSlotStatus(cs *CodeSignature, cd *CodeDirectory, i int) string {
+	hash := cd.SpecialSlots[i]
+	if isZero(hash) {
+		return "absent"
+	}
+	b := cs.Lookup(CSSlot(i + 1))
+	if b == nil {
+		return "not embedded"
+	}
+	return csHashStatus(cd, hash, b.Data)
+}
+
+func csHashStatus(cd *CodeDirectory, hash, data []byte) string {
+	sum := csHash(cd.HashType, data, len(hash))
+	if sum == nil {
+		return "?"
+	}
+	if bytes.Equal(sum, hash) {
+		return "ok"
+	}
+	return "mismatch"
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// cdhash returns the hash of the code directory, which identifies the signed code.
+func cdhash(cd *CodeDirectory, data []byte) string {
+	sum := csHash(cd.HashType, data, CS_CDHASH_LEN)
+	if sum == nil {
+		return "?"
+	}
+	return hex.EncodeToString(sum)
+}
+
+// decodeRequirements parses the requirement set, i.e. CSMAGIC_REQUIREMENTS.
+func decodeRequirements(data []byte) ([]CSRequirement, error) {
+	be := binary.BigEndian
+
+	if len(data) < 12 {
+		return nil, errors.New("short requirement set")
+	}
+	count := be.Uint32(data[8:])
+	if uint64(count)*8 > uint64(len(data)-12) {
+		return nil, errors.New("requirement set index is out of range")
+	}
+
+	var reqs []CSRequirement
+	for i := uint32(0); i < count; i++ {
+		idx := data[12+i*8:]
+		typ := be.Uint32(idx)
+		off := be.Uint32(idx[4:])
+		if uint64(off)+12 > uint64(len(data)) {
+			return nil, fmt.Errorf("requirement %d is out of range", i)
+		}
+		length := be.Uint32(data[off+4:])
+		if uint64(off)+uint64(length) > uint64(len(data)) || length < 12 {
+			return nil, fmt.Errorf("requirement %d is out of range", i)
+		}
+		expr, err := decompileRequirement(data[off : off+length])
+		if err != nil {
+			expr = fmt.Sprintf("%s (%v)", expr, err)
+		}
+		reqs = append(reqs, CSRequirement{Type: typ, Expr: expr})
+	}
+
+	return reqs, nil
+}
+
+func csRequirementTypeString(typ uint32) string {
+	if typ < uint32(len(csRequirementTypeStrings)) && csRequirementTypeStrings[typ] != "" {
+		return csRequirementTypeStrings[typ]
+	}
+	return "?"
+}
+
+// opcodes of requirement expressions
+const (
+	reqOpFalse = iota
+	reqOpTrue
+	reqOpIdent
+	reqOpAppleAnchor
+	reqOpAnchorHash
+	reqOpInfoKeyValue
+	reqOpAnd
+	reqOpOr
+	reqOpCDHash
+	reqOpNot
+	reqOpInfoKeyField
+	reqOpCertField
+	reqOpTrustedCert
+	reqOpTrustedCerts
+	reqOpCertGeneric
+	reqOpAppleGenericAnchor
+	reqOpEntitlementField
+	reqOpCertPolicy
+	reqOpNamedAnchor
+	reqOpNamedCode
+	reqOpPlatform
+	reqOpNotarized
+	reqOpCertFieldDate
+	reqOpLegacyDevID
+
+	reqOpFlagMask = 0xff000000
+)
+
+// match operations of requirement expressions
+const (
+	reqMatchExists = iota
+	reqMatchEqual
+	reqMatchContains
+	reqMatchBeginsWith
+	reqMatchEndsWith
+	reqMatchLessThan
+	reqMatchGreaterThan
+	reqMatchLessEqual
+	reqMatchGreaterEqual
+	reqMatchOn
+	reqMatchBefore
+	reqMatchAfter
+	reqMatchOnOrBefore
+	reqMatchOnOrAfter
+	reqMatchAbsent
+)
+
+// syntax levels of requirement expressions, which decide parentheses
+const (
+	reqLevelOr = iota
+	reqLevelAnd
+	reqLevelPrimary
+)
+
+// decompileRequirement returns the requirement blob, i.e. CSMAGIC_REQUIREMENT, in the requirement language.
+func decompileRequirement(data []byte) (string, error) {
+	be := binary.BigEndian
+	if kind := be.Uint32(data[8:]); kind != 1 { // exprForm
+		return "?", fmt.Errorf("unknown requirement kind %d", kind)
+	}
+	r := &reqReader{data: data, off: 12}
+	s := r.expr(reqLevelOr, 0)
+	return s, r.err
+}
+
+type reqReader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (r *reqReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *reqReader) uint32() uint32 {
+	if r.off+4 > len(r.data) {
+		r.fail(errors.New("short requirement"))
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.data[r.off:])
+	r.off += 4
+	return v
+}
+
+// bytes returns the length-prefixed data, which is padded to 4 bytes.
+func (r *reqReader) bytes() []byte {
+	n := uint64(r.uint32())
+	if r.err != nil {
+		return nil
+	}
+	if uint64(r.off)+n > uint64(len(r.data)) {
+		r.fail(errors.New("short requirement"))
+		return nil
+	}
+	b := r.data[r.off : r.off+int(n)]
+	r.off += int((n + 3) &^ 3)
+	return b
+}
+
+func (r *reqReader) quoted() string {
+	return strconv.Quote(string(r.bytes()))
+}
+
+func (r *reqReader) certSlot() string {
+	switch slot := int32(r.uint32()); slot {
+	case 0:
+		return "leaf"
+	case -1:
+		return "root"
+	default:
+		return fmt.Sprint(slot)
+	}
+}
+
+func (r *reqReader) oid() string {
+	b := r.bytes()
+	var oid asn1.ObjectIdentifier
+	if len(b) < 0x80 {
+		if _, err := asn1.Unmarshal(append([]byte{asn1.TagOID, byte(len(b))}, b...), &oid); err == nil {
+			return oid.String()
+		}
+	}
+	return hex.EncodeToString(b)
+}
+
+func (r *reqReader) expr(level, depth int) string {
+	if depth > 64 {
+		r.fail(errors.New("too deep requirement"))
+		return "?"
+	}
+	op := r.uint32()
+	if r.err != nil {
+		return "?"
+	}
+
+	switch op &^ reqOpFlagMask {
+	case reqOpFalse:
+		return "never"
+	case reqOpTrue:
+		return "always"
+	case reqOpIdent:
+		return "identifier " + r.quoted()
+	case reqOpAppleAnchor:
+		return "anchor apple"
+	case reqOpAppleGenericAnchor:
+		return "anchor apple generic"
+	case reqOpAnchorHash:
+		slot := r.certSlot()
+		return fmt.Sprintf("certificate %s = H\"%x\"", slot, r.bytes())
+	case reqOpInfoKeyValue:
+		key := r.quoted()
+		return fmt.Sprintf("info[%s] = %s", key, r.quoted())
+	case reqOpAnd:
+		a := r.expr(reqLevelAnd, depth+1)
+		b := r.expr(reqLevelAnd, depth+1)
+		if level > reqLevelAnd {
+			return "(" + a + " and " + b + ")"
+		}
+		return a + " and " + b
+	case reqOpOr:
+		a := r.expr(reqLevelOr, depth+1)
+		b := r.expr(reqLevelOr, depth+1)
+		if level > reqLevelOr {
+			return "(" + a + " or " + b + ")"
+		}
+		return a + " or " + b
+	case reqOpNot:
+		return "! " + r.expr(reqLevelPrimary, depth+1)
+	case reqOpCDHash:
+		return fmt.Sprintf("cdhash H\"%x\"", r.bytes())
+	case reqOpInfoKeyField:
+		key := r.quoted()
+		return fmt.Sprintf("info[%s]%s", key, r.match())
+	case reqOpEntitlementField:
+		key := r.quoted()
+		return fmt.Sprintf("entitlement[%s]%s", key, r.match())
+	case reqOpCertField:
+		slot := r.certSlot()
+		field := string(r.bytes())
+		return fmt.Sprintf("certificate %s[%s]%s", slot, field, r.match())
+	case reqOpCertGeneric:
+		slot := r.certSlot()
+		oid := r.oid()
+		return fmt.Sprintf("certificate %s[field.%s]%s", slot, oid, r.match())
+	case reqOpCertPolicy:
+		slot := r.certSlot()
+		oid := r.oid()
+		return fmt.Sprintf("certificate %s[policy.%s]%s", slot, oid, r.match())
+	case reqOpCertFieldDate:
+		slot := r.certSlot()
+		oid := r.oid()
+		return fmt.Sprintf("certificate %s[timestamp.%s]%s", slot, oid, r.match())
+	case reqOpTrustedCert:
+		return fmt.Sprintf("certificate %s trusted", r.certSlot())
+	case reqOpTrustedCerts:
+		return "anchor trusted"
+	case reqOpNamedAnchor:
+		return "anchor apple " + string(r.bytes())
+	case reqOpNamedCode:
+		return "(" + string(r.bytes()) + ")"
+	case reqOpPlatform:
+		return fmt.Sprintf("platform = %d", r.uint32())
+	case reqOpNotarized:
+		return "notarized"
+	case reqOpLegacyDevID:
+		return "legacy"
+	}
+
+	r.fail(fmt.Errorf("unknown requirement opcode %#x", op))
+	return "?"
+}
+
+func (r *reqReader) match() string {
+	switch op := r.uint32(); op {
+	case reqMatchExists:
+		return " /* exists */"
+	case reqMatchAbsent:
+		return " absent"
+	case reqMatchEqual:
+		return " = " + r.quoted()
+	case reqMatchContains:
+		return " ~ " + r.quoted()
+	case reqMatchBeginsWith:
+		s := string(r.bytes())
+		return " = " + strconv.Quote(s+"*")
+	case reqMatchEndsWith:
+		s := string(r.bytes())
+		return " = " + strconv.Quote("*"+s)
+	case reqMatchLessThan:
+		return " < " + r.quoted()
+	case reqMatchGreaterThan:
+		return " > " + r.quoted()
+	case reqMatchLessEqual:
+		return " <= " + r.quoted()
+	case reqMatchGreaterEqual:
+		return " >= " + r.quoted()
+	case reqMatchOn:
+		return " = " + r.timestamp()
+	case reqMatchBefore:
+		return " < " + r.timestamp()
+	case reqMatchAfter:
+		return " > " + r.timestamp()
+	case reqMatchOnOrBefore:
+		return " <= " + r.timestamp()
+	case reqMatchOnOrAfter:
+		return " >= " + r.timestamp()
+	default:
+		r.fail(fmt.Errorf("unknown requirement match %d", op))
+		return " ?"
+	}
+}
+
+// timestamp returns the CFAbsoluteTime, i.e. seconds since 2001.
+func (r *reqReader) timestamp() string {
+	hi := uint64(r.uint32())
+	lo := uint64(r.uint32())
+	t := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(int64(hi<<32|lo)) * time.Second)
+	return fmt.Sprintf("timestamp %q", t.Format(time.RFC3339))
+}
+
+// derEntitlementsLines returns the DER entitlements in lines, where dictionaries are indented.
+func derEntitlementsLines(data []byte) ([]string, error) {
+	return derLines(data, 0, nil)
+}
+
+func derLines(data []byte, depth int, lines []string) ([]string, error) {
+	for len(data) > 0 {
+		var v asn1.RawValue
+		rest, err := asn1.Unmarshal(data, &v)
+		if err != nil {
+			return lines, err
+		}
+		lines, err = derValueLines(v, depth, lines)
+		if err != nil {
+			return lines, err
+		}
+		data = rest
+	}
+	return lines, nil
+}
+
+func derValueLines(v asn1.RawValue, depth int, lines []string) ([]string, error) {
+	indent := strings.Repeat("  ", depth)
+
+	if !v.IsCompound {
+		return append(lines, indent+derPrimitiveString(v)), nil
+	}
+
+	// dictionaries consist of sequences of a key and a value
+	if v.Class == asn1.ClassUniversal && v.Tag == asn1.TagSequence {
+		var key, val asn1.RawValue
+		if rest, err := asn1.Unmarshal(v.Bytes, &key); err == nil && key.Class == asn1.ClassUniversal && key.Tag == asn1.TagUTF8String {
+			if rest, err := asn1.Unmarshal(rest, &val); err == nil && len(rest) == 0 {
+				if !val.IsCompound {
+					return append(lines, fmt.Sprintf("%s%q = %s", indent, key.Bytes, derPrimitiveString(val))), nil
+				}
+				lines = append(lines, fmt.Sprintf("%s%q = %s", indent, key.Bytes, derTagString(val)))
+				return derLines(val.Bytes, depth+1, lines)
+			}
+		}
+	}
+
+	lines = append(lines, indent+derTagString(v))
+	return derLines(v.Bytes, depth+1, lines)
+}
+
+func derTagString(v asn1.RawValue) string {
+	switch {
+	case v.Class == asn1.ClassUniversal && v.Tag == asn1.TagSet:
+		return "dict"
+	case v.Class == asn1.ClassUniversal && v.Tag == asn1.TagSequence:
+		return "array"
+	case v.Class == asn1.ClassContextSpecific && v.Tag == 16:
+		return "dict"
+	case v.Class == asn1.ClassApplication && v.Tag == 16:
+		return "entitlements"
+	}
+	return fmt.Sprintf("[%d %d]", v.Class, v.Tag)
+}
+
+func derPrimitiveString(v asn1.RawValue) string {
+	if v.Class == asn1.ClassUniversal {
+		switch v.Tag {
+		case asn1.TagBoolean:
+			var b bool
+			if _, err := asn1.Unmarshal(v.FullBytes, &b); err == nil {
+				return fmt.Sprint(b)
+			}
+		case asn1.TagInteger:
+			var n *big.Int
+			if _, err := asn1.Unmarshal(v.FullBytes, &n); err == nil {
+				return n.String()
+			}
+		case asn1.TagUTF8String, asn1.TagPrintableString, asn1.TagIA5String:
+			return strconv.Quote(string(v.Bytes))
+		}
+	}
+	return fmt.Sprintf("[%d %d] %x", v.Class, v.Tag, v.Bytes)
+}
+
+var (
+	oidSignedData  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidSigningTime = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+)
+
+var digestAlgorithmStrings = map[string]string{
+	"1.3.14.3.2.26":          "SHA-1",
+	"2.16.840.1.101.3.4.2.1": "SHA-256",
+	"2.16.840.1.101.3.4.2.2": "SHA-384",
+	"2.16.840.1.101.3.4.2.3": "SHA-512",
+}
+
+func digestAlgorithmString(oid asn1.ObjectIdentifier) string {
+	if s, ok := digestAlgorithmStrings[oid.String()]; ok {
+		return s
+	}
+	return oid.String()
+}
+
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type cmsIssuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// decodeCMSSignature parses the CMS signature, i.e. the payload of CSMAGIC_BLOBWRAPPER.
+func decodeCMSSignature(data []byte) (*CMSSignature, error) {
+	// codesign writes BER with indefinite lengths
+	der, err := berToDER(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var ci cmsContentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, err
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unknown content type %s", ci.ContentType)
+	}
+
+	var sd cmsSignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, err
+	}
+
+	sig := new(CMSSignature)
+
+	for rest := sd.Certificates.Bytes; len(rest) > 0; {
+		var raw asn1.RawValue
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &raw); err != nil {
+			return nil, err
+		}
+		cert, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			// TODO warning
+			continue
+		}
+		sig.Certificates = append(sig.Certificates, cert)
+	}
+
+	for _, si := range sd.SignerInfos {
+		signer := CMSSigner{DigestAlgorithm: si.DigestAlgorithm.Algorithm}
+
+		var is cmsIssuerAndSerial
+		if _, err := asn1.Unmarshal(si.SID.FullBytes, &is); err == nil {
+			signer.Serial = is.Serial
+			var issuer pkix.RDNSequence
+			if _, err := asn1.Unmarshal(is.Issuer.FullBytes, &issuer); err == nil {
+				signer.Issuer = issuer.String()
+			}
+			for _, cert := range sig.Certificates {
+				if bytes.Equal(cert.RawIssuer, is.Issuer.FullBytes) && cert.SerialNumber.Cmp(is.Serial) == 0 {
+					signer.Cert = cert
+					break
+				}
+			}
+		}
+
+		for rest := si.SignedAttrs.Bytes; len(rest) > 0; {
+			var attr cmsAttribute
+			var err error
+			if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+				break
+			}
+			if attr.Type.Equal(oidSigningTime) {
+				var t time.Time
+				if _, err := asn1.Unmarshal(attr.Values.Bytes, &t); err == nil {
+					signer.SigningTime = t
+				}
+			}
+		}
+
+		sig.Signers = append(sig.Signers, signer)
+	}
+
+	return sig, nil
+}
+
+// berToDER converts indefinite lengths in data to definite lengths, which encoding/asn1 requires.
+func berToDER(data []byte) ([]byte, error) {
+	der, _, err := berElement(data, 0)
+	return der, err
+}
+
+func berElement(data []byte, depth int) ([]byte, []byte, error) {
+	if depth > 64 {
+		return nil, nil, errors.New("too deep BER")
+	}
+
+	i := 1
+	if len(data) > 0 && data[0]&0x1f == 0x1f { // high tag number
+		for i < len(data) && data[i]&0x80 != 0 {
+			i++
+		}
+		i++
+	}
+	if i >= len(data) {
+		return nil, nil, errors.New("short BER")
+	}
+	tag := data[:i]
+	compound := data[0]&0x20 != 0
+
+	l := data[i]
+	i++
+
+	var content, rest []byte
+	switch {
+	case l == 0x80:
+		// the content ends with end-of-contents
+		if !compound {
+			return nil, nil, errors.New("indefinite length of primitive BER")
+		}
+		rest = data[i:]
+		for {
+			if len(rest) < 2 {
+				return nil, nil, errors.New("short BER")
+			}
+			if rest[0] == 0 && rest[1] == 0 {
+				rest = rest[2:]
+				break
+			}
+			var child []byte
+			var err error
+			if child, rest, err = berElement(rest, depth+1); err != nil {
+				return nil, nil, err
+			}
+			content = append(content, child...)
+		}
+	default:
+		n := uint64(l)
+		if l&0x80 != 0 {
+			k := int(l & 0x7f)
+			if k > 8 || i+k > len(data) {
+				return nil, nil, errors.New("bad BER length")
+			}
+			n = 0
+			for _, b := range data[i : i+k] {
+				n = n<<8 | uint64(b)
+			}
+			i += k
+		}
+		if n > uint64(len(data)-i) {
+			return nil, nil, errors.New("short BER")
+		}
+		content = data[i : i+int(n)]
+		rest = data[i+int(n):]
+		if compound {
+			var children []byte
+			for c := content; len(c) > 0; {
+				var child []byte
+				var err error
+				if child, c, err = berElement(c, depth+1); err != nil {
+					return nil, nil, err
+				}
+				children = append(children, child...)
+			}
+			content = children
+		}
+	}
+
+	der := append([]byte(nil), tag...)
+	der = append(der, derLength(len(content))...)
+	return append(der, content...), rest, nil
+}
+
+func derLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
@@ -0,0 +1,153 @@
+package macho_analysis
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+// reqBlob returns the requirement blob of the expression, where ints are opcodes and strings are data.
+func reqBlob(expr ...interface{}) []byte {
+	be := binary.BigEndian
+	b := make([]byte, 12)
+	be.PutUint32(b, uint32(CSMAGIC_REQUIREMENT))
+	be.PutUint32(b[8:], 1) // exprForm
+	for _, x := range expr {
+		switch x := x.(type) {
+		case int:
+			b = append(b, 0, 0, 0, 0)
+			be.PutUint32(b[len(b)-4:], uint32(x))
+		case string:
+			b = append(b, 0, 0, 0, 0)
+			be.PutUint32(b[len(b)-4:], uint32(len(x)))
+			b = append(b, x...)
+			for len(b)%4 != 0 {
+				b = append(b, 0)
+			}
+		}
+	}
+	be.PutUint32(b[4:], uint32(len(b)))
+	return b
+}
+
+func TestDecompileRequirement(t *testing.T) {
+	for _, tt := range []struct {
+		in  []byte
+		out string
+	}{
+		{reqBlob(reqOpTrue), "always"},
+		{reqBlob(reqOpIdent, "com.example.hello"), `identifier "com.example.hello"`},
+		{
+			reqBlob(reqOpAnd, reqOpIdent, "a", reqOpOr, reqOpAppleAnchor, reqOpCDHash, "\x01\x02"),
+			`identifier "a" and (anchor apple or cdhash H"0102")`,
+		},
+		{
+			reqBlob(reqOpOr, reqOpAnd, reqOpIdent, "a", reqOpAppleGenericAnchor, reqOpTrustedCerts),
+			`identifier "a" and anchor apple generic or anchor trusted`,
+		},
+		{
+			reqBlob(reqOpCertField, 0, "subject.OU", reqMatchEqual, "ABCDE12345"),
+			`certificate leaf[subject.OU] = "ABCDE12345"`,
+		},
+		{
+			reqBlob(reqOpCertGeneric, 1, "\x2a\x86\x48\x86\xf7\x63\x64\x06\x02\x06", reqMatchExists),
+			"certificate 1[field.1.2.840.113635.100.6.2.6] /* exists */",
+		},
+		{
+			reqBlob(reqOpNot, reqOpInfoKeyValue, "CFBundleVersion", "1.0"),
+			`! info["CFBundleVersion"] = "1.0"`,
+		},
+		{reqBlob(reqOpEntitlementField, "a", reqMatchBeginsWith, "b"), `entitlement["a"] = "b*"`},
+	} {
+		out, err := decompileRequirement(tt.in)
+		if err != nil || out != tt.out {
+			t.Errorf("decompileRequirement(%x) = %q, %v; want %q", tt.in, out, err, tt.out)
+		}
+	}
+
+	for _, in := range [][]byte{
+		reqBlob(0xff),
+		reqBlob(reqOpAnd, reqOpTrue),
+		reqBlob(reqOpIdent),
+		reqBlob(reqOpCertField, 0, "subject.OU", 0xff),
+	} {
+		if out, err := decompileRequirement(in); err == nil {
+			t.Errorf("decompileRequirement(%x) = %q; want error", in, out)
+		}
+	}
+}
+
+func TestCDHash(t *testing.T) {
+	data := []byte("code directory")
+	sha1Sum := sha1.Sum(data)
+	sha256Sum := sha256.Sum256(data)
+	sha384Sum := sha512.Sum384(data)
+	for _, tt := range []struct {
+		typ CSHashType
+		sum []byte
+	}{
+		{CS_HASHTYPE_SHA1, sha1Sum[:]},
+		{CS_HASHTYPE_SHA256, sha256Sum[:CS_CDHASH_LEN]},
+		{CS_HASHTYPE_SHA384, sha384Sum[:CS_CDHASH_LEN]},
+		{0xff, nil},
+	} {
+		want := hex.EncodeToString(tt.sum)
+		if tt.sum == nil {
+			want = "?"
+		}
+		if got := cdhash(&CodeDirectory{HashType: tt.typ}, data); got != want {
+			t.Errorf("cdhash of hash type %d = %s; want %s", tt.typ, got, want)
+		}
+	}
+}
+
+func TestDEREntitlementsLines(t *testing.T) {
+	// <key>a</key><true/><key>b</key><array><string>x</string></array>
+	data, _ := hex.DecodeString("70" + "1a" +
+		"020101" +
+		"b015" +
+		"3006" + "0c0161" + "0101ff" +
+		"300b" + "0c0162" + "3006" + "0c0178" + "020102")
+	lines, err := derEntitlementsLines(data)
+	want := []string{
+		"entitlements",
+		"  1",
+		"  dict",
+		`    "a" = true`,
+		`    "b" = array`,
+		`      "x"`,
+		"      2",
+	}
+	if err != nil || !reflect.DeepEqual(lines, want) {
+		t.Errorf("derEntitlementsLines(%x) = %q, %v; want %q", data, lines, err, want)
+	}
+}
+
+func TestBERToDER(t *testing.T) {
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{"020101", "020101"},
+		{"3080020101" + "0000", "3003020101"},
+		{"3080" + "3080" + "0401aa" + "0000" + "0000", "3005" + "3003" + "0401aa"},
+		{"a080" + "0401aa" + "0000", "a003" + "0401aa"},
+	} {
+		in, _ := hex.DecodeString(tt.in)
+		out, err := berToDER(in)
+		if want, _ := hex.DecodeString(tt.out); err != nil || !bytes.Equal(out, want) {
+			t.Errorf("berToDER(%s) = %x, %v; want %s", tt.in, out, err, tt.out)
+		}
+	}
+
+	for _, in := range []string{"3080020101", "3005020101"} {
+		b, _ := hex.DecodeString(in)
+		if out, err := berToDER(b); err == nil {
+			t.Errorf("berToDER(%s) = %x; want error", in, out)
+		}
+	}
+}
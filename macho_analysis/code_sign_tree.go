@@ -0,0 +1,282 @@
+package macho_analysis
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	csIndexHeader        = []string{"Slot", "Offset", "Magic", "Length"}
+	csSpecialSlotHeader  = []string{"Slot", "Hash", "Status"}
+	csCodeSlotHeader     = []string{"Page", "Offset", "Hash", "Status"}
+	csRequirementHeader  = []string{"Type", "Requirement"}
+	csEntitlementsHeader = []string{"Entitlements"}
+	cmsCertificateHeader = []string{"Subject", "Issuer", "Serial", "Not Before", "Not After"}
+	cmsSignerHeader      = []string{"Subject", "Issuer", "Serial", "Digest", "Signing Time"}
+)
+
+func (f *File) newCodeSignatureNode() *StructNode {
+	cmd := f.linkeditDataCmd(LC_CODE_SIGNATURE)
+	if cmd == nil {
+		// TODO warning
+		return &StructNode{Name: "LC_CODE_SIGNATURE (?)"}
+	}
+
+	node := newStructNode(cmd.Cmd.String(), [][]string{
+		{"cmd", fmt.Sprintf("%#08x (%s)", uint32(cmd.Cmd), cmd.Cmd)},
+		{"cmdsize", fmt.Sprintf("%#08x", cmd.Len)},
+		{"dataoff", fmt.Sprintf("%#08x", cmd.Dataoff)},
+		{"datasize", fmt.Sprint(cmd.Datasize)},
+	})
+
+	cs, err := f.CodeSignature()
+	if err != nil || cs == nil {
+		// TODO warning
+		return node
+	}
+
+	node.appendChild(newStructNode("SuperBlob", [][]string{
+		{"magic", fmt.Sprintf("%#08x (%s)", uint32(cs.Magic), cs.Magic)},
+		{"length", fmt.Sprint(cs.Length)},
+		{"count", fmt.Sprint(len(cs.Blobs))},
+	}))
+
+	node.appendChild(newTableNode("Index", csIndexHeader, func() [][]Value {
+		rows := make([][]Value, len(cs.Blobs))
+		for i, b := range cs.Blobs {
+			rows[i] = []Value{
+				Text(csSlotString(b.Slot)),
+				Text(fmt.Sprintf("%#08x", b.Offset)),
+				Text(fmt.Sprintf("%#08x (%s)", uint32(b.Magic), b.Magic)),
+				Text(fmt.Sprint(b.Length)),
+			}
+		}
+		return rows
+	}))
+
+	for i := range cs.Blobs {
+		node.appendChild(f.newCSBlobNode(cs, &cs.Blobs[i]))
+	}
+
+	return node
+}
+
+func csSlotString(slot CSSlot) string {
+	if CSSLOT_ALTERNATE_CODEDIRECTORIES < slot && slot < CSSLOT_ALTERNATE_CODEDIRECTORIES+CSSLOT_ALTERNATE_CODEDIRECTORY_MAX {
+		return fmt.Sprintf("%#x (CSSLOT_ALTERNATE_CODEDIRECTORIES+%d)", uint32(slot), slot-CSSLOT_ALTERNATE_CODEDIRECTORIES)
+	}
+	return fmt.Sprintf("%#x (%s)", uint32(slot), slot)
+}
+
+func (f *File) newCSBlobNode(cs *CodeSignature, b *CSBlob) *StructNode {
+	fields := [][]string{
+		{"magic", fmt.Sprintf("%#08x (%s)", uint32(b.Magic), b.Magic)},
+		{"length", fmt.Sprint(b.Length)},
+	}
+
+	switch b.Magic {
+	case CSMAGIC_CODEDIRECTORY:
+		return f.newCodeDirectoryNode(cs, b)
+	case CSMAGIC_REQUIREMENTS:
+		node := newStructNode("Requirements", fields)
+		reqs, err := decodeRequirements(b.Data)
+		if err != nil {
+			// TODO warning
+			return node
+		}
+		node.appendChild(newTableNode("Requirement Set", csRequirementHeader, func() [][]Value {
+			rows := make([][]Value, len(reqs))
+			for i, req := range reqs {
+				rows[i] = []Value{
+					Text(fmt.Sprintf("%d (%s)", req.Type, csRequirementTypeString(req.Type))),
+					Text(req.Expr),
+				}
+			}
+			return rows
+		}))
+		return node
+	case CSMAGIC_EMBEDDED_ENTITLEMENTS:
+		node := newStructNode("Entitlements", fields)
+		xml := strings.TrimRight(string(b.Data[8:]), "\x00\n")
+		node.appendChild(newTableNode("XML", csEntitlementsHeader, func() [][]Value {
+			lines := strings.Split(xml, "\n")
+			rows := make([][]Value, len(lines))
+			for i, line := range lines {
+				rows[i] = []Value{Text(line)}
+			}
+			return rows
+		}))
+		return node
+	case CSMAGIC_EMBEDDED_DER_ENTITLEMENTS:
+		node := newStructNode("Entitlements (DER)", fields)
+		lines, err := derEntitlementsLines(b.Data[8:])
+		if err != nil {
+			// TODO warning
+			lines = append(lines, fmt.Sprintf("? (%v)", err))
+		}
+		node.appendChild(newTableNode("DER", csEntitlementsHeader, func() [][]Value {
+			rows := make([][]Value, len(lines))
+			for i, line := range lines {
+				rows[i] = []Value{Text(line)}
+			}
+			return rows
+		}))
+		return node
+	case CSMAGIC_BLOBWRAPPER:
+		return f.newCMSSignatureNode(b, fields)
+	}
+
+	return newStructNode(fmt.Sprintf("%s (?)", b.Magic), fields)
+}
+
+func (f *File) newCodeDirectoryNode(cs *CodeSignature, b *CSBlob) *StructNode {
+	name := "CodeDirectory"
+	if b.Slot != CSSLOT_CODEDIRECTORY {
+		name = fmt.Sprintf("CodeDirectory (alternate %d)", b.Slot-CSSLOT_ALTERNATE_CODEDIRECTORIES)
+	}
+
+	cd, err := decodeCodeDirectory(b.Data)
+	if err != nil {
+		// TODO warning
+		return newStructNode(name+" (?)", [][]string{
+			{"magic", fmt.Sprintf("%#08x (%s)", uint32(b.Magic), b.Magic)},
+			{"length", fmt.Sprint(b.Length)},
+		})
+	}
+
+	fields := [][]string{
+		{"magic", fmt.Sprintf("%#08x (%s)", uint32(b.Magic), b.Magic)},
+		{"length", fmt.Sprint(b.Length)},
+		{"version", fmt.Sprintf("%#x", cd.Version)},
+		{"flags", f.flagsString(cd.Flags, csFlagStrings[:])},
+		{"hashOffset", fmt.Sprintf("%#x", cd.HashOffset)},
+		{"identOffset", fmt.Sprintf("%#x", cd.IdentOffset)},
+		{"nSpecialSlots", fmt.Sprint(cd.NSpecialSlots)},
+		{"nCodeSlots", fmt.Sprint(cd.NCodeSlots)},
+		{"codeLimit", fmt.Sprintf("%#x", cd.CodeLimit)},
+		{"hashSize", fmt.Sprint(cd.HashSize)},
+		{"hashType", fmt.Sprintf("%d (%s)", cd.HashType, cd.HashType)},
+		{"platform", fmt.Sprint(cd.Platform)},
+		{"pageSize", fmt.Sprintf("%d (%#x)", cd.PageSize, cd.pageSize())},
+	}
+	if cd.Version >= CS_SUPPORTSSCATTER {
+		fields = append(fields, []string{"scatterOffset", fmt.Sprintf("%#x", cd.ScatterOffset)})
+	}
+	if cd.Version >= CS_SUPPORTSTEAMID {
+		fields = append(fields, []string{"teamOffset", fmt.Sprintf("%#x", cd.TeamOffset)})
+	}
+	if cd.Version >= CS_SUPPORTSEXECSEG {
+		fields = append(fields,
+			[]string{"execSegBase", fmt.Sprintf("%#x", cd.ExecSegBase)},
+			[]string{"execSegLimit", fmt.Sprintf("%#x", cd.ExecSegLimit)},
+			[]string{"execSegFlags", f.flagsString(uint32(cd.ExecSegFlags), csExecSegFlagStrings[:])},
+		)
+	}
+	if cd.Version >= CS_SUPPORTSRUNTIME {
+		fields = append(fields,
+			[]string{"runtime", f.versionString(cd.Runtime)},
+			[]string{"preEncryptOffset", fmt.Sprintf("%#x", cd.PreEncryptOffset)},
+		)
+	}
+	fields = append(fields,
+		[]string{"identifier", cd.Identifier},
+		[]string{"teamID", cd.TeamID},
+		[]string{"cdhash", cdhash(cd, b.Data)},
+	)
+
+	node := newStructNode(name, fields)
+
+	node.appendChild(newTableNode("Special Slots", csSpecialSlotHeader, func() [][]Value {
+		rows := make([][]Value, len(cd.SpecialSlots))
+		for i, hash := range cd.SpecialSlots {
+			rows[i] = []Value{
+				Text(fmt.Sprintf("-%d (%s)", i+1, CSSlot(i+1))),
+				Text(hex.EncodeToString(hash)),
+				Text(specialSlotStatus(cs, cd, i)),
+			}
+		}
+		return rows
+	}))
+
+	node.appendChild(newTableNode("Code Slots", csCodeSlotHeader, func() [][]Value {
+		rows := make([][]Value, len(cd.CodeSlots))
+		for i, hash := range cd.CodeSlots {
+			rows[i] = []Value{
+				Text(fmt.Sprint(i)),
+				Text(fmt.Sprintf("%#08x", uint64(i)*cd.pageSize())),
+				Text(hex.EncodeToString(hash)),
+				Text(f.codeSlotStatus(cd, i)),
+			}
+		}
+		return rows
+	}))
+
+	return node
+}
+
+func (f *File) newCMSSignatureNode(b *CSBlob, fields [][]string) *StructNode {
+	if len(b.Data) == 8 {
+		// ad-hoc signatures have no CMS signature
+		return newStructNode("CMS Signature (empty)", fields)
+	}
+
+	sig, err := decodeCMSSignature(b.Data[8:])
+	if err != nil {
+		// TODO warning
+		return newStructNode("CMS Signature (?)", fields)
+	}
+
+	var signers []string
+	for _, s := range sig.Signers {
+		if s.Cert != nil {
+			signers = append(signers, s.Cert.Subject.String())
+		} else {
+			signers = append(signers, "?")
+		}
+	}
+	fields = append(fields, []string{"signers", strings.Join(signers, "\n")})
+
+	node := newStructNode("CMS Signature", fields)
+
+	node.appendChild(newTableNode("Signers", cmsSignerHeader, func() [][]Value {
+		rows := make([][]Value, len(sig.Signers))
+		for i, s := range sig.Signers {
+			var subject, serial, signingTime string
+			if s.Cert != nil {
+				subject = s.Cert.Subject.String()
+			}
+			if s.Serial != nil {
+				serial = fmt.Sprintf("%x", s.Serial)
+			}
+			if !s.SigningTime.IsZero() {
+				signingTime = s.SigningTime.Format(time.RFC3339)
+			}
+			rows[i] = []Value{
+				Text(subject),
+				Text(s.Issuer),
+				Text(serial),
+				Text(digestAlgorithmString(s.DigestAlgorithm)),
+				Text(signingTime),
+			}
+		}
+		return rows
+	}))
+
+	node.appendChild(newTableNode("Certificates", cmsCertificateHeader, func() [][]Value {
+		rows := make([][]Value, len(sig.Certificates))
+		for i, cert := range sig.Certificates {
+			rows[i] = []Value{
+				Text(cert.Subject.String()),
+				Text(cert.Issuer.String()),
+				Text(fmt.Sprintf("%x", cert.SerialNumber)),
+				Text(cert.NotBefore.Format(time.RFC3339)),
+				Text(cert.NotAfter.Format(time.RFC3339)),
+			}
+		}
+		return rows
+	}))
+
+	return node
+}
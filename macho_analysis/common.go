@@ -0,0 +1,674 @@
+package macho_analysis
+
+import (
+	"bytes"
+	"debug/macho"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/arch/arm/armasm"
+	"golang.org/x/arch/arm64/arm64asm"
+	"golang.org/x/arch/ppc64/ppc64asm"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// #include <stdio.h>
+//
+// int sprintf_3(char * restrict s, const char * restrict format, void * val1) {
+//   return sprintf(s, format, *(long double *)val1);
+// }
+import "C"
+
+type File struct {
+	*macho.File
+	Syms      []macho.Symbol
+	SymInfos  map[uint64]*SymInfo
+	SymLookup SymLookup
+	Fixups    *ChainedFixups
+
+	// SourceRoot is the directory where SourceLine looks up source files.
+	SourceRoot string
+
+	// DSYM is the dSYM loaded by LoadDSYM, which provides DWARF and symbols.
+	DSYM       *File
+	dsymCloser io.Closer
+
+	lines    *lineTable
+	sources  map[string][]string
+	lsdas    map[uint64]uint64 // LSDA address -> function address
+	bindSyms map[uint64]string
+	relocs   map[*macho.Section]map[uint64]*RelocTarget
+	xrefs    *xrefTable
+	calls    *callGraph
+	linked   *linkImage // the image last linked by link
+}
+
+type SymInfo struct {
+	Size          uint64
+	Relocs        []macho.Reloc
+	RelocSections []*macho.Section
+	RelocIndices  []int // the indices of Relocs in RelocSections
+	SymbolIndices []int
+}
+
+type SymLookup func(addr uint64) (string, uint64)
+
+func NewFile(f *macho.File) *File {
+	var syms []macho.Symbol
+	if f.Symtab != nil {
+		syms = f.Symtab.Syms
+	}
+	ssyms := makeSortedSymbols(f)
+	symInfos := makeSymInfos(f, ssyms)
+	var ff *File
+	symLookup := func(addr uint64) (string, uint64) {
+		j := sort.Search(len(ssyms), func(i int) bool {
+			return addr < ssyms[i].Value
+		})
+		if j > 0 {
+			sym := ssyms[j-1]
+			info := symInfos[sym.Value]
+			if sym.Value != 0 && sym.Value <= addr && addr < sym.Value+info.Size {
+				ss := make([]string, len(info.SymbolIndices))
+				for i, si := range info.SymbolIndices {
+					sym := &syms[si]
+					if sym.Value == addr {
+						ss[i] = ff.symName(sym.Name)
+					} else {
+						ss[i] = fmt.Sprintf("%s%+x", ff.symName(sym.Name), addr-sym.Value)
+					}
+				}
+				return strings.Join(ss, "|"), sym.Value
+			}
+		}
+		return "", 0
+	}
+	ff = &File{
+		File:      f,
+		Syms:      syms,
+		SymInfos:  symInfos,
+		SymLookup: symLookup,
+	}
+	if data, err := ff.chainedFixupsData(); err != nil {
+		// TODO warning
+	} else if data != nil {
+		ff.Fixups, err = ff.decodeChainedFixups(data)
+		if err != nil {
+			// TODO warning
+		}
+	}
+	return ff
+}
+
+type SortedSymbols []struct {
+	*macho.Symbol
+
+	Index int
+}
+
+func (v SortedSymbols) Len() int {
+	return len(v)
+}
+
+func (v SortedSymbols) Less(i, j int) bool {
+	return v[i].Value < v[j].Value
+}
+
+func (v SortedSymbols) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+func makeSortedSymbols(f *macho.File) SortedSymbols {
+	var syms []macho.Symbol
+	if f.Symtab != nil {
+		syms = f.Symtab.Syms
+	}
+
+	ssyms := make(SortedSymbols, 0, len(syms))
+
+	for i := range syms {
+		sym := &syms[i]
+		if sym.Type&N_STAB == 0 && SymbolType(sym.Type&N_TYPE) == N_SECT {
+			ssyms = append(ssyms, struct {
+				*macho.Symbol
+				Index int
+			}{
+				Symbol: sym,
+				Index:  i,
+			})
+		}
+	}
+
+	sort.Sort(ssyms)
+
+	return ssyms
+}
+
+func makeSymInfos(f *macho.File, ssyms SortedSymbols) map[uint64]*SymInfo {
+	symInfos := make(map[uint64]*SymInfo)
+
+	if len(ssyms) != 0 {
+		for i := 0; i < len(ssyms); i++ {
+			sym := ssyms[i]
+			info := new(SymInfo)
+			info.SymbolIndices = append(info.SymbolIndices, sym.Index)
+			if i == len(ssyms)-1 {
+				if 0 < int(sym.Sect) && int(sym.Sect) <= len(f.Sections) {
+					sect := f.Sections[sym.Sect-1]
+					info.Size = sect.Addr + sect.Size - sym.Value
+				}
+			} else {
+				for j := i + 1; j < len(ssyms); j++ {
+					nsym := ssyms[j]
+					if sym.Value != nsym.Value {
+						if sym.Sect == nsym.Sect {
+							info.Size = nsym.Value - sym.Value
+						} else {
+							if 0 < int(sym.Sect) && int(sym.Sect) <= len(f.Sections) {
+								sect := f.Sections[sym.Sect-1]
+								info.Size = sect.Addr + sect.Size - sym.Value
+							}
+						}
+						i = j - 1
+						break
+					}
+					info.SymbolIndices = append(info.SymbolIndices, nsym.Index)
+				}
+			}
+			symInfos[sym.Value] = info
+		}
+
+		for _, sect := range f.Sections {
+			for i, r := range sect.Relocs {
+				if i > 0 && isRelocPair(f.Cpu, sect.Relocs[i-1], r) {
+					// the pair is shown with the first
+					continue
+				}
+				k := sort.Search(len(ssyms), func(j int) bool {
+					sym := ssyms[j]
+					return sym.Value > sect.Addr+uint64(r.Addr)
+				})
+				if k == 0 {
+					continue
+				}
+				sym := ssyms[k-1]
+				info := symInfos[sym.Value]
+				if sym.Value <= sect.Addr+uint64(r.Addr) && sect.Addr+uint64(r.Addr)+relocSize(f.Cpu, r) <= sym.Value+info.Size {
+					info.Relocs = append(info.Relocs, r)
+					info.RelocSections = append(info.RelocSections, sect)
+					info.RelocIndices = append(info.RelocIndices, i)
+				}
+			}
+		}
+	}
+
+	return symInfos
+}
+
+func (f *File) symAddrString(addr uint64, force bool) string {
+	if s, base := f.SymLookup(addr); s != "" {
+		info := f.SymInfos[base]
+		ss := make([]string, len(info.SymbolIndices))
+		for i, si := range info.SymbolIndices {
+			sym := &f.Syms[si]
+			if base == addr {
+				ss[i] = f.symName(sym.Name)
+			} else {
+				ss[i] = fmt.Sprintf("%s%+x", f.symName(sym.Name), addr-base)
+			}
+		}
+		return strings.Join(ss, "|")
+	}
+	if s, base := f.stubLookup(addr); s != "" {
+		if base == addr {
+			return s
+		}
+		return fmt.Sprintf("%s%+x", s, addr-base)
+	}
+	if force {
+		return fmt.Sprintf("%#x", addr)
+	}
+	return ""
+}
+
+// addrValue returns addr and the symbol name at addr. addr links to the address.
+func (f *File) addrValue(addr uint64, size uint64) Value {
+	v := Value{}.appendLink(fmt.Sprintf("%#016x", addr), addressLink(addr, size))
+	if s := f.symAddrString(addr, false); s != "" {
+		v = v.appendText(fmt.Sprintf(" (%s)", s))
+	}
+	return v
+}
+
+// addrSymValue is like addrValue, but the symbol names link to the symbols.
+func (f *File) addrSymValue(addr uint64, size uint64) Value {
+	v := Value{}.appendLink(fmt.Sprintf("%#016x", addr), addressLink(addr, size))
+	if s := f.symValue(addr, size); s != nil {
+		v = append(v.appendText(" ("), s...).appendText(")")
+	} else if f.DSYM != nil {
+		// symbols of the dSYM can't be linked
+		if s := f.DSYM.symAddrString(addr, false); s != "" {
+			v = v.appendText(fmt.Sprintf(" (%s)", s))
+		}
+	}
+	return v
+}
+
+// symValue returns the symbols containing addr, which link to the symbols, or nil if there is no symbol.
+func (f *File) symValue(addr uint64, size uint64) Value {
+	s, base := f.SymLookup(addr)
+	if s == "" {
+		// stubs link to the bound symbols
+		i, base, styp := f.indirectSlot(addr)
+		if i == -1 {
+			return nil
+		}
+		name := f.symName(f.Syms[i].Name) + stubSuffix(styp)
+		if base == addr {
+			return Value{}.appendLink(name, symbolLink(i, 0, size))
+		}
+		return Value{}.appendLink(fmt.Sprintf("%s%+x", name, addr-base), symbolLink(i, int64(addr-base), size))
+	}
+	info := f.SymInfos[base]
+	var v Value
+	for i, si := range info.SymbolIndices {
+		if i > 0 {
+			v = v.appendText("|")
+		}
+		sym := &f.Syms[si]
+		if base == addr {
+			v = v.appendLink(f.symName(sym.Name), symbolLink(si, 0, size))
+		} else {
+			v = v.appendLink(fmt.Sprintf("%s%+x", f.symName(sym.Name), addr-base), symbolLink(si, int64(addr-base), size))
+		}
+	}
+	return v
+}
+
+// sectionReserved returns reserved1 and reserved2 of sect, which aren't decoded by debug/macho.
+func (f *File) sectionReserved(sect *macho.Section) (reserved1, reserved2 uint32, ok bool) {
+	for _, l := range f.Loads {
+		// sections of object files are in the unnamed segment
+		seg, ok := l.(*macho.Segment)
+		if !ok {
+			continue
+		}
+
+		raw := seg.Raw()
+
+		var off, size, roff int
+		switch LoadCommand(seg.Cmd) {
+		case LC_SEGMENT:
+			off, size, roff = 56, 68, 60
+		case LC_SEGMENT_64:
+			off, size, roff = 72, 80, 68
+		default:
+			continue
+		}
+
+		for i := 0; i < int(seg.Nsect) && off+(i+1)*size <= len(raw); i++ {
+			sh := raw[off+i*size : off+(i+1)*size]
+			if string(bytes.TrimRight(sh[0:16], "\x00")) == sect.Name && string(bytes.TrimRight(sh[16:32], "\x00")) == sect.Seg {
+				return f.ByteOrder.Uint32(sh[roff:]), f.ByteOrder.Uint32(sh[roff+4:]), true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// indirectSymbol returns the symbol bound to the symbol pointer or the symbol stub at addr, or nil.
+func (f *File) indirectSymbol(addr uint64) *macho.Symbol {
+	if i := f.indirectSymnum(addr); i != -1 {
+		return &f.Syms[i]
+	}
+	return nil
+}
+
+// indirectSymnum returns the index of the symbol bound to the symbol pointer or the symbol stub at addr, or -1.
+func (f *File) indirectSymnum(addr uint64) int {
+	i, _, _ := f.indirectSlot(addr)
+	return i
+}
+
+// indirectSlot returns the index of the symbol bound to the symbol pointer or the symbol stub containing addr,
+// the address of the pointer or the stub, and the section type. The index is -1 if there is no such symbol.
+// The index of the symbol is looked up in the indirect symbol table from reserved1 of the section.
+func (f *File) indirectSlot(addr uint64) (int, uint64, SectionType) {
+	if f.Dysymtab == nil {
+		return -1, 0, 0
+	}
+	for _, sect := range f.Sections {
+		if addr < sect.Addr || sect.Addr+sect.Size <= addr {
+			continue
+		}
+
+		styp := SectionType(sect.Flags & SECTION_TYPE)
+
+		var stride uint64
+		switch styp {
+		case S_NON_LAZY_SYMBOL_POINTERS, S_LAZY_SYMBOL_POINTERS, S_LAZY_DYLIB_SYMBOL_POINTERS, S_THREAD_LOCAL_VARIABLE_POINTERS:
+			stride = f.pointerSize()
+		case S_SYMBOL_STUBS:
+		default:
+			return -1, 0, 0
+		}
+
+		reserved1, reserved2, ok := f.sectionReserved(sect)
+		if !ok {
+			return -1, 0, 0
+		}
+		if styp == S_SYMBOL_STUBS {
+			stride = uint64(reserved2)
+		}
+		if stride == 0 {
+			return -1, 0, 0
+		}
+
+		n := (addr - sect.Addr) / stride
+		i := uint64(reserved1) + n
+		if i < uint64(len(f.Dysymtab.IndirectSyms)) && f.symIndex(f.Dysymtab.IndirectSyms[i]) != nil {
+			return int(f.Dysymtab.IndirectSyms[i]), sect.Addr + n*stride, styp
+		}
+		return -1, 0, 0
+	}
+	return -1, 0, 0
+}
+
+// stubLookup returns the name of the symbol stub or the symbol pointer containing addr,
+// e.g. "_malloc$stub" or "_malloc$lazy_ptr", and the address of it.
+func (f *File) stubLookup(addr uint64) (string, uint64) {
+	i, base, styp := f.indirectSlot(addr)
+	if i == -1 {
+		return "", 0
+	}
+	return f.symName(f.Syms[i].Name) + stubSuffix(styp), base
+}
+
+func stubSuffix(styp SectionType) string {
+	switch styp {
+	case S_SYMBOL_STUBS:
+		return "$stub"
+	case S_LAZY_SYMBOL_POINTERS, S_LAZY_DYLIB_SYMBOL_POINTERS:
+		return "$lazy_ptr"
+	case S_THREAD_LOCAL_VARIABLE_POINTERS:
+		return "$tlv_ptr"
+	default:
+		return "$non_lazy_ptr"
+	}
+}
+
+// disasmLookup is SymLookup, which falls back to stubLookup.
+func (f *File) disasmLookup(addr uint64) (string, uint64) {
+	if s, base := f.SymLookup(addr); s != "" {
+		return s, base
+	}
+	return f.stubLookup(addr)
+}
+
+// Demangle reports whether symbol names are shown demangled.
+var Demangle = true
+
+// symName returns the displayed name of the symbol, which is demangled if Demangle is set.
+func (f *File) symName(name string) string {
+	if !Demangle {
+		return name
+	}
+	if s, ok := SwiftDemangle(name); ok {
+		return s
+	}
+	// C symbols have the leading underscore
+	if s, ok := ItaniumDemangle(strings.TrimPrefix(name, "_")); ok {
+		return s
+	}
+	return name
+}
+
+func (f *File) symIndexString(i uint32) string {
+	if sym := f.symIndex(i); sym != nil {
+		return f.symName(sym.Name)
+	}
+	return ""
+}
+
+func (f *File) symIndex(i uint32) *macho.Symbol {
+	if len(f.Syms) < math.MaxUint32 && 0 <= i && i < uint32(len(f.Syms)) {
+		return &f.Syms[i]
+	}
+	return nil
+}
+
+func (f *File) sectNumString(num uint32) string {
+	if len(f.Sections) < math.MaxUint32 && 0 <= num-1 && num-1 < uint32(len(f.Sections)) {
+		sect := f.Sections[num-1]
+		return fmt.Sprintf("%s,%s", sect.Seg, sect.Name)
+	}
+	return ""
+}
+
+func (f *File) disasmFunc() func(code []byte, pc uint64) (string, int) {
+	return f.disasmFuncWith(f.disasmLookup)
+}
+
+// disasmFuncWith is like disasmFunc, but symbols are looked up by lookup.
+func (f *File) disasmFuncWith(lookup func(addr uint64) (string, uint64)) func(code []byte, pc uint64) (string, int) {
+	switch f.Cpu {
+	case macho.Cpu386:
+		return func(code []byte, pc uint64) (string, int) {
+			inst, err := x86asm.Decode(code, 32)
+			if err != nil {
+				return "?", 1
+			}
+			syntax := x86asm.GNUSyntax(inst, pc, x86asm.SymLookup(lookup))
+			return syntax, inst.Len
+		}
+	case macho.CpuAmd64:
+		return func(code []byte, pc uint64) (string, int) {
+			inst, err := x86asm.Decode(code, 64)
+			if err != nil {
+				return "?", 1
+			}
+			syntax := x86asm.GNUSyntax(inst, pc, x86asm.SymLookup(lookup))
+			return syntax, inst.Len
+		}
+	case macho.CpuArm:
+		return func(code []byte, pc uint64) (string, int) {
+			inst, err := armasm.Decode(code, armasm.ModeARM)
+			if err != nil {
+				return "?", 1
+			}
+			syntax := armasm.GNUSyntax(inst)
+			return syntax, inst.Len
+		}
+	case macho.CpuArm | 0x01000000:
+		return func(code []byte, pc uint64) (string, int) {
+			inst, err := arm64asm.Decode(code)
+			if err != nil {
+				return "?", 4
+			}
+			syntax := arm64asm.GNUSyntax(inst)
+			if inst.Op != arm64asm.ADRP {
+				// GNUSyntax doesn't look up symbols
+				for _, arg := range inst.Args {
+					if rel, ok := arg.(arm64asm.PCRel); ok {
+						target := pc + uint64(rel)
+						if s, base := lookup(target); s != "" {
+							if base != target {
+								s += fmt.Sprintf("%+#x", target-base)
+							}
+							syntax += fmt.Sprintf(" <%s>", s)
+						}
+					}
+				}
+			}
+			return syntax, 4
+		}
+	case macho.CpuPpc64:
+		return func(code []byte, pc uint64) (string, int) {
+			inst, err := ppc64asm.Decode(code, f.ByteOrder)
+			if err != nil {
+				return "?", 1
+			}
+			syntax := ppc64asm.GNUSyntax(inst)
+			return syntax, inst.Len
+		}
+	}
+
+	return nil
+}
+
+func (f *File) toASCII(data []byte) string {
+	ret := make([]byte, len(data))
+	for i, c := range data {
+		if 32 <= c && c < 127 {
+			ret[i] = c
+		} else {
+			ret[i] = '.'
+		}
+	}
+	return string(ret)
+}
+
+func (f *File) toFloat32(data []byte) string {
+	if len(data) != 4 {
+		return ""
+	}
+	return fmt.Sprintf("%g", math.Float32frombits(f.ByteOrder.Uint32(data)))
+}
+
+func (f *File) toFloat64(data []byte) string {
+	if len(data) != 8 {
+		return ""
+	}
+	return fmt.Sprintf("%g", math.Float64frombits(f.ByteOrder.Uint64(data)))
+}
+
+func (f *File) toFloat128(data []byte) string {
+	if len(data) != 16 {
+		return ""
+	}
+
+	// TODO encoding of `long double` is platform dependent, so this is not precisely correct.
+
+	switch f.Cpu {
+	case macho.Cpu386:
+		if runtime.GOARCH != "386" {
+			return ""
+		}
+	case macho.CpuAmd64:
+		if runtime.GOARCH != "amd64" {
+			return ""
+		}
+	case macho.CpuArm:
+		if runtime.GOARCH != "arm" {
+			return ""
+		}
+	case macho.CpuArm | 0x01000000:
+		if runtime.GOARCH != "arm64" {
+			return ""
+		}
+	case macho.CpuPpc:
+		if runtime.GOARCH != "ppc" {
+			return ""
+		}
+	case macho.CpuPpc64:
+		if runtime.GOARCH != "ppc64" {
+			return ""
+		}
+	}
+
+	format := []byte("%Lg")
+	bs := make([]byte, 64)
+	for {
+		i := int(C.sprintf_3((*C.char)(unsafe.Pointer(&bs[0])), (*C.char)(unsafe.Pointer(&format[0])), unsafe.Pointer(&data[0])))
+		if i == -1 {
+			return ""
+		}
+		if i < len(bs) {
+			break
+		}
+		bs = make([]byte, len(bs)*2)
+	}
+	i := bytes.IndexByte(bs, 0)
+	if i == -1 {
+		return ""
+	}
+	return string(bs[:i])
+}
+
+func (f *File) toPointer32(data []byte, addr uint64) string {
+	if len(data) != 4 {
+		return ""
+	}
+	return f.toPointer(uint64(f.ByteOrder.Uint32(data)), addr)
+}
+
+func (f *File) toPointer64(data []byte, addr uint64) string {
+	if len(data) != 8 {
+		return ""
+	}
+	return f.toPointer(f.ByteOrder.Uint64(data), addr)
+}
+
+// toPointer returns paddr, which is the pointer at addr, and the symbol at paddr.
+// If the pointer is fixed up, bound, relocated or in the indirect symbol table, the target is shown instead.
+func (f *File) toPointer(paddr uint64, addr uint64) string {
+	if fx := f.Fixups.Lookup(addr); fx != nil {
+		return f.fixupString(f.Fixups, fx)
+	}
+	suffix := ""
+	if s := f.symAddrString(paddr, false); s != "" {
+		suffix = fmt.Sprintf(` (%s)`, s)
+	}
+	if s := f.pointerTargetString(addr); s != "" {
+		suffix += " -> " + s
+	}
+	return fmt.Sprintf("%#016x%s", paddr, suffix)
+}
+
+// pointerTargetString returns the target bound to the pointer at addr by binds, relocations or
+// the indirect symbol table, or "" if there is no such target.
+func (f *File) pointerTargetString(addr uint64) string {
+	if s, ok := f.bindSymbols()[addr]; ok {
+		return f.symName(s)
+	}
+
+	for _, sect := range f.Sections {
+		if addr < sect.Addr || sect.Addr+sect.Size <= addr {
+			continue
+		}
+		if t := f.sectRelocTargets(sect)[addr-sect.Addr]; t != nil {
+			return f.relocTargetValue(t).String()
+		}
+		break
+	}
+
+	// symbol pointers are bound to the symbols in the indirect symbol table
+	if i, _, styp := f.indirectSlot(addr); i != -1 && styp != S_SYMBOL_STUBS {
+		return f.symName(f.Syms[i].Name)
+	}
+
+	return ""
+}
+
+func (f *File) isZeroSym(sym *macho.Symbol) bool {
+	if sym.Type&N_STAB != 0 || SymbolType(sym.Type&N_TYPE) != N_SECT {
+		return false
+	}
+
+	if 0 < int(sym.Sect) && int(sym.Sect) <= len(f.Sections) {
+		return f.isZeroSect(f.Sections[sym.Sect-1])
+	}
+
+	return false
+}
+
+func (f *File) isZeroSect(sect *macho.Section) bool {
+	styp := SectionType(sect.Flags & SECTION_TYPE)
+
+	return styp == S_ZEROFILL || styp == S_GB_ZEROFILL
+}
@@ -0,0 +1,289 @@
+package macho_analysis
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DataTree is a decoded section or symbol, which is shown by DataView.
+//
+// Rows of sections and symbols are decoded lazily.
+// Len reports rows indexed so far, and Fetch indexes more rows while More is true.
+type DataTree struct {
+	Header []string
+
+	rows []*DataNode
+	idx  *dataIndex
+}
+
+// DataNode is a row of DataTree.
+type DataNode struct {
+	Values   []Value
+	Children []*DataNode
+
+	childrenFunc func() []*DataNode // decode Children on demand
+}
+
+// Nodes returns Children. Children of a lazy node are decoded by the first call.
+func (n *DataNode) Nodes() []*DataNode {
+	if n.childrenFunc != nil {
+		n.Children = n.childrenFunc()
+		n.childrenFunc = nil
+	}
+	return n.Children
+}
+
+func (t *DataTree) appendRow(vals ...Value) *DataNode {
+	n := &DataNode{Values: vals}
+	t.rows = append(t.rows, n)
+	return n
+}
+
+func (n *DataNode) appendRow(vals ...Value) *DataNode {
+	c := &DataNode{Values: vals}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// Len returns the number of rows indexed so far.
+func (t *DataTree) Len() int {
+	if t.idx != nil {
+		return t.idx.nrows
+	}
+	return len(t.rows)
+}
+
+// More reports whether there are rows which aren't indexed yet.
+func (t *DataTree) More() bool {
+	if t.idx != nil {
+		return t.idx.next.off < len(t.idx.data)
+	}
+	return false
+}
+
+// Fetch indexes at most n rows, and returns the number of indexed rows.
+func (t *DataTree) Fetch(n int) int {
+	if t.idx != nil {
+		return t.idx.fetch(n)
+	}
+	return 0
+}
+
+// Row returns i-th row. i must be less than Len.
+func (t *DataTree) Row(i int) *DataNode {
+	if t.idx != nil {
+		return t.idx.row(i)
+	}
+	return t.rows[i]
+}
+
+// RowAt returns the index of the fetched top-level row at addr, or -1 if there is no such row.
+func (t *DataTree) RowAt(addr uint64) int {
+	if t.idx != nil {
+		return t.idx.rowAt(addr)
+	}
+	return -1
+}
+
+// dataBlockSize is the number of rows between marks.
+// Rows are decoded by blocks, so that Row doesn't decode from the top.
+const dataBlockSize = 64
+
+type dataMark struct {
+	off  int
+	info *SymInfo
+	line *LineEntry // the last source line
+}
+
+// dataIndex records the offset of every dataBlockSize-th row.
+type dataIndex struct {
+	f         *File
+	data      []byte
+	addr      uint64
+	valueFunc func(data []byte, addr uint64) (string, int)
+	hasRel    bool
+	track     bool // update info by symbols in data
+
+	slide   uint64 // added to the addresses of rows, e.g. of linked object files
+	patched []bool // marks the bytes in data patched by relocations
+
+	lines      *lineTable // show source lines in the last column
+	interleave bool       // insert a row of the source when the line changes
+
+	marks []dataMark
+	nrows int
+	next  dataMark // the mark of nrows-th row
+
+	block int
+	cache []*DataNode
+}
+
+func (x *dataIndex) fetch(n int) int {
+	i := 0
+	for ; i < n && x.next.off < len(x.data); i++ {
+		if x.nrows%dataBlockSize == 0 {
+			x.marks = append(x.marks, x.next)
+		}
+		x.step(&x.next, false)
+		x.nrows++
+	}
+	return i
+}
+
+func (x *dataIndex) row(i int) *DataNode {
+	if block := i / dataBlockSize; x.cache == nil || x.block != block {
+		x.block = block
+		x.cache = x.decodeBlock(block)
+	}
+	return x.cache[i%dataBlockSize]
+}
+
+func (x *dataIndex) rowAt(addr uint64) int {
+	if addr < x.addr || len(x.marks) == 0 {
+		return -1
+	}
+
+	block := sort.Search(len(x.marks), func(i int) bool {
+		return addr < x.addr+uint64(x.marks[i].off)
+	}) - 1
+	if block < 0 {
+		return -1
+	}
+
+	m := x.marks[block]
+	for i := block * dataBlockSize; i < x.nrows; i++ {
+		off := m.off
+		x.step(&m, false)
+		if m.off == off {
+			// a row of the source
+			continue
+		}
+		if a := x.addr + uint64(off); a == addr {
+			return i
+		} else if a > addr {
+			break
+		}
+	}
+	return -1
+}
+
+func (x *dataIndex) decodeBlock(block int) []*DataNode {
+	n := x.nrows - block*dataBlockSize
+	if n > dataBlockSize {
+		n = dataBlockSize
+	}
+
+	rows := make([]*DataNode, n)
+
+	m := x.marks[block]
+	for i := range rows {
+		rows[i] = x.step(&m, true)
+	}
+
+	return rows
+}
+
+// step advances m by a row. The row is decoded if decode is true.
+func (x *dataIndex) step(m *dataMark, decode bool) *DataNode {
+	addr := x.addr + uint64(m.off)
+	if x.track {
+		if info := x.f.SymInfos[addr]; info != nil {
+			// TODO make tree
+			m.info = info
+		}
+	}
+
+	var line *LineEntry
+	if x.lines != nil {
+		line = x.lines.lookup(addr)
+		if line != nil && (m.line == nil || m.line.File != line.File || m.line.Line != line.Line) {
+			m.line = line
+			if x.interleave {
+				if !decode {
+					return nil
+				}
+				text, _ := x.f.SourceLine(line.File, line.Line)
+				return x.newRow(nil, nil, Text(text), Text(fmt.Sprintf("%s:%d", line.File, line.Line)))
+			}
+		}
+	}
+
+	off := m.off
+	data := x.data[off:]
+
+	value, size := x.valueFunc(data, addr+x.slide)
+
+	m.off += size
+
+	if !decode {
+		return nil
+	}
+
+	var source Value
+	if line != nil {
+		source = Text(line.String())
+	}
+
+	n := x.newRow(
+		Text(fmt.Sprintf("%#016x", addr+x.slide)),
+		x.dataValue(off, size),
+		Text(value),
+		source,
+	)
+
+	if x.hasRel && m.info != nil {
+		x.f.appendRelocRows(n, m.info, data[:size], addr)
+	}
+
+	return n
+}
+
+// dataValue returns the bytes of size at off. The patched bytes are marked.
+func (x *dataIndex) dataValue(off, size int) Value {
+	if x.patched == nil {
+		return Text(fmt.Sprintf("% x", x.data[off:off+size]))
+	}
+	var v Value
+	for i := off; i < off+size; {
+		j := i + 1
+		for j < off+size && x.patched[j] == x.patched[i] {
+			j++
+		}
+		if i > off {
+			v = v.appendText(" ")
+		}
+		v = append(v, Span{Text: fmt.Sprintf("% x", x.data[i:j]), Mark: x.patched[i]})
+		i = j
+	}
+	return v
+}
+
+// newRow returns a row of address, data and value. source is put in the last column.
+func (x *dataIndex) newRow(addr, data, value, source Value) *DataNode {
+	vals := []Value{addr, data, value}
+	if x.lines != nil {
+		ncols := len(dataHeader)
+		if x.hasRel {
+			ncols = len(relocDataHeader)
+		}
+		vals = append(vals, make([]Value, ncols-len(vals))...)
+		vals = append(vals, source)
+	}
+	return &DataNode{Values: vals}
+}
+
+// withLines adds the source column to t, which is a data of code.
+// If interleave is true, source lines are inserted as rows too.
+func (t *DataTree) withLines(interleave bool) *DataTree {
+	if t == nil || t.idx == nil {
+		return t
+	}
+	lines := t.idx.f.lineTable()
+	if len(lines.entries) == 0 {
+		return t
+	}
+	t.Header = append(t.Header[:len(t.Header):len(t.Header)], "Source")
+	t.idx.lines = lines
+	t.idx.interleave = interleave
+	return t
+}
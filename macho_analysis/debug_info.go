@@ -0,0 +1,496 @@
+//go:generate stringer -type=DW_OP,DW_ATE,DW_LANG -output debug_info_string.go
+
+package macho_analysis
+
+// reference:
+// http://www.dwarfstd.org/doc/DWARF5.pdf
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"strings"
+)
+
+type DW_OP uint8
+
+type DW_ATE uint8
+
+type DW_LANG uint16
+
+const (
+	DW_OP_addr                 DW_OP = 0x03
+	DW_OP_deref                DW_OP = 0x06
+	DW_OP_const1u              DW_OP = 0x08
+	DW_OP_const1s              DW_OP = 0x09
+	DW_OP_const2u              DW_OP = 0x0a
+	DW_OP_const2s              DW_OP = 0x0b
+	DW_OP_const4u              DW_OP = 0x0c
+	DW_OP_const4s              DW_OP = 0x0d
+	DW_OP_const8u              DW_OP = 0x0e
+	DW_OP_const8s              DW_OP = 0x0f
+	DW_OP_constu               DW_OP = 0x10
+	DW_OP_consts               DW_OP = 0x11
+	DW_OP_dup                  DW_OP = 0x12
+	DW_OP_drop                 DW_OP = 0x13
+	DW_OP_over                 DW_OP = 0x14
+	DW_OP_pick                 DW_OP = 0x15
+	DW_OP_swap                 DW_OP = 0x16
+	DW_OP_rot                  DW_OP = 0x17
+	DW_OP_xderef               DW_OP = 0x18
+	DW_OP_abs                  DW_OP = 0x19
+	DW_OP_and                  DW_OP = 0x1a
+	DW_OP_div                  DW_OP = 0x1b
+	DW_OP_minus                DW_OP = 0x1c
+	DW_OP_mod                  DW_OP = 0x1d
+	DW_OP_mul                  DW_OP = 0x1e
+	DW_OP_neg                  DW_OP = 0x1f
+	DW_OP_not                  DW_OP = 0x20
+	DW_OP_or                   DW_OP = 0x21
+	DW_OP_plus                 DW_OP = 0x22
+	DW_OP_plus_uconst          DW_OP = 0x23
+	DW_OP_shl                  DW_OP = 0x24
+	DW_OP_shr                  DW_OP = 0x25
+	DW_OP_shra                 DW_OP = 0x26
+	DW_OP_xor                  DW_OP = 0x27
+	DW_OP_bra                  DW_OP = 0x28
+	DW_OP_eq                   DW_OP = 0x29
+	DW_OP_ge                   DW_OP = 0x2a
+	DW_OP_gt                   DW_OP = 0x2b
+	DW_OP_le                   DW_OP = 0x2c
+	DW_OP_lt                   DW_OP = 0x2d
+	DW_OP_ne                   DW_OP = 0x2e
+	DW_OP_skip                 DW_OP = 0x2f
+	DW_OP_lit0                 DW_OP = 0x30 // ... DW_OP_lit31 (0x4f)
+	DW_OP_reg0                 DW_OP = 0x50 // ... DW_OP_reg31 (0x6f)
+	DW_OP_breg0                DW_OP = 0x70 // ... DW_OP_breg31 (0x8f)
+	DW_OP_regx                 DW_OP = 0x90
+	DW_OP_fbreg                DW_OP = 0x91
+	DW_OP_bregx                DW_OP = 0x92
+	DW_OP_piece                DW_OP = 0x93
+	DW_OP_deref_size           DW_OP = 0x94
+	DW_OP_xderef_size          DW_OP = 0x95
+	DW_OP_nop                  DW_OP = 0x96
+	DW_OP_push_object_address  DW_OP = 0x97
+	DW_OP_call2                DW_OP = 0x98
+	DW_OP_call4                DW_OP = 0x99
+	DW_OP_call_ref             DW_OP = 0x9a
+	DW_OP_form_tls_address     DW_OP = 0x9b
+	DW_OP_call_frame_cfa       DW_OP = 0x9c
+	DW_OP_bit_piece            DW_OP = 0x9d
+	DW_OP_implicit_value       DW_OP = 0x9e
+	DW_OP_stack_value          DW_OP = 0x9f
+	DW_OP_implicit_pointer     DW_OP = 0xa0
+	DW_OP_addrx                DW_OP = 0xa1
+	DW_OP_constx               DW_OP = 0xa2
+	DW_OP_entry_value          DW_OP = 0xa3
+	DW_OP_const_type           DW_OP = 0xa4
+	DW_OP_regval_type          DW_OP = 0xa5
+	DW_OP_deref_type           DW_OP = 0xa6
+	DW_OP_xderef_type          DW_OP = 0xa7
+	DW_OP_convert              DW_OP = 0xa8
+	DW_OP_reinterpret          DW_OP = 0xa9
+	DW_OP_GNU_push_tls_address DW_OP = 0xe0
+	DW_OP_GNU_entry_value      DW_OP = 0xf3
+)
+
+const (
+	DW_ATE_address         DW_ATE = 0x01
+	DW_ATE_boolean         DW_ATE = 0x02
+	DW_ATE_complex_float   DW_ATE = 0x03
+	DW_ATE_float           DW_ATE = 0x04
+	DW_ATE_signed          DW_ATE = 0x05
+	DW_ATE_signed_char     DW_ATE = 0x06
+	DW_ATE_unsigned        DW_ATE = 0x07
+	DW_ATE_unsigned_char   DW_ATE = 0x08
+	DW_ATE_imaginary_float DW_ATE = 0x09
+	DW_ATE_packed_decimal  DW_ATE = 0x0a
+	DW_ATE_numeric_string  DW_ATE = 0x0b
+	DW_ATE_edited          DW_ATE = 0x0c
+	DW_ATE_signed_fixed    DW_ATE = 0x0d
+	DW_ATE_unsigned_fixed  DW_ATE = 0x0e
+	DW_ATE_decimal_float   DW_ATE = 0x0f
+	DW_ATE_UTF             DW_ATE = 0x10
+	DW_ATE_UCS             DW_ATE = 0x11
+	DW_ATE_ASCII           DW_ATE = 0x12
+)
+
+const (
+	DW_LANG_C89            DW_LANG = 0x0001
+	DW_LANG_C              DW_LANG = 0x0002
+	DW_LANG_Ada83          DW_LANG = 0x0003
+	DW_LANG_C_plus_plus    DW_LANG = 0x0004
+	DW_LANG_Cobol74        DW_LANG = 0x0005
+	DW_LANG_Cobol85        DW_LANG = 0x0006
+	DW_LANG_Fortran77      DW_LANG = 0x0007
+	DW_LANG_Fortran90      DW_LANG = 0x0008
+	DW_LANG_Pascal83       DW_LANG = 0x0009
+	DW_LANG_Modula2        DW_LANG = 0x000a
+	DW_LANG_Java           DW_LANG = 0x000b
+	DW_LANG_C99            DW_LANG = 0x000c
+	DW_LANG_Ada95          DW_LANG = 0x000d
+	DW_LANG_Fortran95      DW_LANG = 0x000e
+	DW_LANG_PLI            DW_LANG = 0x000f
+	DW_LANG_ObjC           DW_LANG = 0x0010
+	DW_LANG_ObjC_plus_plus DW_LANG = 0x0011
+	DW_LANG_UPC            DW_LANG = 0x0012
+	DW_LANG_D              DW_LANG = 0x0013
+	DW_LANG_Python         DW_LANG = 0x0014
+	DW_LANG_OpenCL         DW_LANG = 0x0015
+	DW_LANG_Go             DW_LANG = 0x0016
+	DW_LANG_Modula3        DW_LANG = 0x0017
+	DW_LANG_Haskell        DW_LANG = 0x0018
+	DW_LANG_C_plus_plus_03 DW_LANG = 0x0019
+	DW_LANG_C_plus_plus_11 DW_LANG = 0x001a
+	DW_LANG_OCaml          DW_LANG = 0x001b
+	DW_LANG_Rust           DW_LANG = 0x001c
+	DW_LANG_C11            DW_LANG = 0x001d
+	DW_LANG_Swift          DW_LANG = 0x001e
+	DW_LANG_Julia          DW_LANG = 0x001f
+	DW_LANG_Dylan          DW_LANG = 0x0020
+	DW_LANG_C_plus_plus_14 DW_LANG = 0x0021
+	DW_LANG_Fortran03      DW_LANG = 0x0022
+	DW_LANG_Fortran08      DW_LANG = 0x0023
+	DW_LANG_RenderScript   DW_LANG = 0x0024
+	DW_LANG_BLISS          DW_LANG = 0x0025
+	DW_LANG_Mips_Assembler DW_LANG = 0x8001
+)
+
+var debugInfoHeader = []string{"Offset", "Tag / Attribute", "Class", "Value"}
+
+// HasDebugInfo reports whether f or the dSYM has the __debug_info section.
+func (f *File) HasDebugInfo() bool {
+	if f.DSYM != nil {
+		return f.DSYM.HasDebugInfo()
+	}
+	return f.Section("__debug_info") != nil || f.Section("__zdebug_info") != nil
+}
+
+// DebugInfo returns the DIE tree. Compile units are decoded when their children are requested.
+func (f *File) DebugInfo() *DataTree {
+	d, err := f.DWARF()
+	if err != nil {
+		// TODO warning
+		return nil
+	}
+
+	t := &DataTree{Header: debugInfoHeader}
+
+	r := d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			// TODO warning
+			break
+		}
+		if e == nil {
+			break
+		}
+
+		u := &dwarfUnit{f: f, d: d, cu: e, addrSize: r.AddressSize()}
+
+		n := t.appendRow(u.entryValues(e)...)
+		n.childrenFunc = u.nodes
+
+		r.SkipChildren()
+	}
+
+	return t
+}
+
+// dwarfUnit decodes DIEs of a compile unit.
+type dwarfUnit struct {
+	f        *File
+	d        *dwarf.Data
+	cu       *dwarf.Entry
+	addrSize int
+	files    []*dwarf.LineFile
+}
+
+func (u *dwarfUnit) nodes() []*DataNode {
+	if lr, err := u.d.LineReader(u.cu); err != nil {
+		// TODO warning
+	} else if lr != nil {
+		u.files = lr.Files()
+	}
+
+	r := u.d.Reader()
+	r.Seek(u.cu.Offset)
+
+	e, err := r.Next()
+	if err != nil || e == nil {
+		// TODO warning
+		return nil
+	}
+
+	return u.childNodes(r, e)
+}
+
+// childNodes returns the attributes of e, followed by the children of e.
+func (u *dwarfUnit) childNodes(r *dwarf.Reader, e *dwarf.Entry) []*DataNode {
+	var nodes []*DataNode
+
+	for _, field := range e.Field {
+		nodes = append(nodes, &DataNode{Values: []Value{
+			nil,
+			Text(attrString(field.Attr)),
+			Text(strings.TrimPrefix(field.Class.String(), "Class")),
+			u.fieldValue(e, field),
+		}})
+	}
+
+	if e.Children {
+		for {
+			c, err := r.Next()
+			if err != nil {
+				// TODO warning
+				break
+			}
+			if c == nil || c.Tag == 0 {
+				break
+			}
+			nodes = append(nodes, &DataNode{
+				Values:   u.entryValues(c),
+				Children: u.childNodes(r, c),
+			})
+		}
+	}
+
+	return nodes
+}
+
+func (u *dwarfUnit) entryValues(e *dwarf.Entry) []Value {
+	name, _ := e.Val(dwarf.AttrName).(string)
+	return []Value{
+		Text(fmt.Sprintf("%#08x", e.Offset)),
+		Text(e.Tag.String()),
+		nil,
+		Text(name),
+	}
+}
+
+func (u *dwarfUnit) fieldValue(e *dwarf.Entry, field dwarf.Field) Value {
+	switch field.Class {
+	case dwarf.ClassAddress:
+		addr, _ := field.Val.(uint64)
+		var size uint64
+		if field.Attr == dwarf.AttrLowpc {
+			if high, ok := highpc(e, addr); ok && high > addr {
+				size = high - addr
+			}
+		}
+		return u.f.addrSymValue(addr, size)
+	case dwarf.ClassConstant:
+		val, _ := field.Val.(int64)
+		switch field.Attr {
+		case dwarf.AttrHighpc:
+			if low, ok := e.Val(dwarf.AttrLowpc).(uint64); ok {
+				high := low + uint64(val)
+				return Text(fmt.Sprintf("%#x (", val)).appendLink(fmt.Sprintf("%#016x", high), addressLink(high, 0)).appendText(")")
+			}
+		case dwarf.AttrDeclFile, dwarf.AttrCallFile:
+			if 0 <= val && val < int64(len(u.files)) && u.files[val] != nil {
+				return Text(fmt.Sprintf("%d (%s)", val, u.files[val].Name))
+			}
+		case dwarf.AttrLanguage:
+			return Text(fmt.Sprintf("%#04x (%s)", val, DW_LANG(val)))
+		case dwarf.AttrEncoding:
+			return Text(fmt.Sprintf("%#02x (%s)", val, DW_ATE(val)))
+		}
+		return Text(fmt.Sprint(field.Val))
+	case dwarf.ClassString, dwarf.ClassFlag:
+		return Text(fmt.Sprint(field.Val))
+	case dwarf.ClassReference:
+		off, _ := field.Val.(dwarf.Offset)
+		v := Text(fmt.Sprintf("<%#08x>", off))
+		if field.Attr == dwarf.AttrType {
+			if typ, err := u.d.Type(off); err == nil {
+				v = v.appendText(fmt.Sprintf(" (%s)", typ))
+			}
+		}
+		return v
+	case dwarf.ClassExprLoc:
+		b, _ := field.Val.([]byte)
+		return u.f.exprValue(b, u.addrSize)
+	case dwarf.ClassRangeListPtr:
+		ranges, err := u.d.Ranges(e)
+		if err != nil {
+			// TODO warning
+			break
+		}
+		var v Value
+		for i, rg := range ranges {
+			if i > 0 {
+				v = v.appendText(", ")
+			}
+			v = v.appendText("[").
+				appendLink(fmt.Sprintf("%#x", rg[0]), addressLink(rg[0], rg[1]-rg[0])).
+				appendText(", ").
+				appendLink(fmt.Sprintf("%#x", rg[1]), addressLink(rg[1], 0)).
+				appendText(")")
+		}
+		return v
+	}
+	return Text(fmt.Sprintf("%#x", field.Val))
+}
+
+func attrString(attr dwarf.Attr) string {
+	s := attr.String()
+	if strings.HasPrefix(s, "Attr(") {
+		return fmt.Sprintf("Attr(%#x)", uint32(attr))
+	}
+	return s
+}
+
+func highpc(e *dwarf.Entry, low uint64) (uint64, bool) {
+	switch high := e.Val(dwarf.AttrHighpc).(type) {
+	case uint64:
+		return high, true
+	case int64:
+		return low + uint64(high), true
+	}
+	return 0, false
+}
+
+// exprValue decodes a DWARF expression, such as DW_AT_location.
+func (f *File) exprValue(b []byte, addrSize int) Value {
+	var v Value
+
+	ok := true
+
+	fixed := func(n int) uint64 {
+		if len(b) < n {
+			ok = false
+			return 0
+		}
+		var x uint64
+		switch n {
+		case 1:
+			x = uint64(b[0])
+		case 2:
+			x = uint64(f.ByteOrder.Uint16(b))
+		case 4:
+			x = uint64(f.ByteOrder.Uint32(b))
+		case 8:
+			x = f.ByteOrder.Uint64(b)
+		}
+		b = b[n:]
+		return x
+	}
+	uleb := func() uint64 {
+		x, n, err := readUleb128(b)
+		if err != nil {
+			ok = false
+		}
+		b = b[n:]
+		return x
+	}
+	sleb := func() int64 {
+		x, n, err := readSleb128(b)
+		if err != nil {
+			ok = false
+		}
+		b = b[n:]
+		return x
+	}
+	block := func() []byte {
+		n := uleb()
+		if uint64(len(b)) < n {
+			ok = false
+			return nil
+		}
+		blk := b[:n]
+		b = b[n:]
+		return blk
+	}
+
+	for ok && len(b) > 0 {
+		if v != nil {
+			v = v.appendText("; ")
+		}
+
+		op := DW_OP(b[0])
+		b = b[1:]
+
+		switch {
+		case DW_OP_lit0 <= op && op < DW_OP_lit0+32:
+			v = v.appendText(fmt.Sprintf("DW_OP_lit%d", op-DW_OP_lit0))
+			continue
+		case DW_OP_reg0 <= op && op < DW_OP_reg0+32:
+			v = v.appendText(fmt.Sprintf("DW_OP_reg%s", f.registerString(uint64(op-DW_OP_reg0))))
+			continue
+		case DW_OP_breg0 <= op && op < DW_OP_breg0+32:
+			v = v.appendText(fmt.Sprintf("DW_OP_breg%s %+d", f.registerString(uint64(op-DW_OP_breg0)), sleb()))
+			continue
+		}
+
+		switch op {
+		case DW_OP_addr:
+			addr := fixed(addrSize)
+			v = append(v.appendText(op.String()+" "), f.addrSymValue(addr, 0)...)
+		case DW_OP_const1u, DW_OP_pick, DW_OP_deref_size, DW_OP_xderef_size:
+			v = v.appendText(fmt.Sprintf("%s %d", op, fixed(1)))
+		case DW_OP_const1s:
+			v = v.appendText(fmt.Sprintf("%s %d", op, int8(fixed(1))))
+		case DW_OP_const2u, DW_OP_call2:
+			v = v.appendText(fmt.Sprintf("%s %d", op, fixed(2)))
+		case DW_OP_const2s, DW_OP_skip, DW_OP_bra:
+			v = v.appendText(fmt.Sprintf("%s %d", op, int16(fixed(2))))
+		case DW_OP_const4u, DW_OP_call4, DW_OP_call_ref:
+			v = v.appendText(fmt.Sprintf("%s %d", op, fixed(4)))
+		case DW_OP_const4s:
+			v = v.appendText(fmt.Sprintf("%s %d", op, int32(fixed(4))))
+		case DW_OP_const8u:
+			v = v.appendText(fmt.Sprintf("%s %d", op, fixed(8)))
+		case DW_OP_const8s:
+			v = v.appendText(fmt.Sprintf("%s %d", op, int64(fixed(8))))
+		case DW_OP_constu, DW_OP_plus_uconst, DW_OP_piece, DW_OP_addrx, DW_OP_constx, DW_OP_convert, DW_OP_reinterpret:
+			v = v.appendText(fmt.Sprintf("%s %d", op, uleb()))
+		case DW_OP_consts, DW_OP_fbreg:
+			v = v.appendText(fmt.Sprintf("%s %d", op, sleb()))
+		case DW_OP_regx:
+			v = v.appendText(fmt.Sprintf("%s %s", op, f.registerString(uleb())))
+		case DW_OP_bregx:
+			reg := uleb()
+			v = v.appendText(fmt.Sprintf("%s %s %+d", op, f.registerString(reg), sleb()))
+		case DW_OP_bit_piece, DW_OP_regval_type:
+			x := uleb()
+			v = v.appendText(fmt.Sprintf("%s %d %d", op, x, uleb()))
+		case DW_OP_deref_type, DW_OP_xderef_type:
+			x := fixed(1)
+			v = v.appendText(fmt.Sprintf("%s %d %d", op, x, uleb()))
+		case DW_OP_implicit_pointer:
+			x := fixed(4)
+			v = v.appendText(fmt.Sprintf("%s <%#08x> %+d", op, x, sleb()))
+		case DW_OP_implicit_value:
+			v = v.appendText(fmt.Sprintf("%s [% x]", op, block()))
+		case DW_OP_const_type:
+			x := uleb()
+			n := fixed(1)
+			if uint64(len(b)) < n {
+				ok = false
+				break
+			}
+			v = v.appendText(fmt.Sprintf("%s %d [% x]", op, x, b[:n]))
+			b = b[n:]
+		case DW_OP_entry_value, DW_OP_GNU_entry_value:
+			v = append(v.appendText(op.String()+" ("), f.exprValue(block(), addrSize)...).appendText(")")
+		case DW_OP_deref, DW_OP_dup, DW_OP_drop, DW_OP_over, DW_OP_swap, DW_OP_rot, DW_OP_xderef,
+			DW_OP_abs, DW_OP_and, DW_OP_div, DW_OP_minus, DW_OP_mod, DW_OP_mul, DW_OP_neg, DW_OP_not,
+			DW_OP_or, DW_OP_plus, DW_OP_shl, DW_OP_shr, DW_OP_shra, DW_OP_xor,
+			DW_OP_eq, DW_OP_ge, DW_OP_gt, DW_OP_le, DW_OP_lt, DW_OP_ne,
+			DW_OP_nop, DW_OP_push_object_address, DW_OP_form_tls_address, DW_OP_call_frame_cfa,
+			DW_OP_stack_value, DW_OP_GNU_push_tls_address:
+			v = v.appendText(op.String())
+		default:
+			v = v.appendText(fmt.Sprintf("%s [% x]", op, b))
+			b = nil
+		}
+	}
+
+	if !ok {
+		// TODO warning
+		v = v.appendText(" (truncated)")
+	}
+
+	return v
+}
@@ -0,0 +1,128 @@
+package macho_analysis
+
+import (
+	"debug/dwarf"
+	"debug/macho"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UUID returns the uuid of LC_UUID.
+func (f *File) UUID() ([16]byte, bool) {
+	return fileUUID(f.File)
+}
+
+func fileUUID(f *macho.File) (uuid [16]byte, ok bool) {
+	for _, lc := range f.Loads {
+		raw := lc.Raw()
+		if len(raw) < 8+len(uuid) || LoadCommand(f.ByteOrder.Uint32(raw[0:4])) != LC_UUID {
+			continue
+		}
+		copy(uuid[:], raw[8:])
+		return uuid, true
+	}
+	return uuid, false
+}
+
+func uuidString(uuid [16]byte) string {
+	return fmt.Sprintf("%X-%X-%X-%X-%X", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+}
+
+// FindDSYM returns the dSYM bundle next to the binary at path, or "" if there is nothing.
+func FindDSYM(path string) string {
+	bundle := path + ".dSYM"
+	if fi, err := os.Stat(bundle); err == nil && fi.IsDir() {
+		return bundle
+	}
+	return ""
+}
+
+// dsymFile returns the file in the bundle, which contains DWARF.
+func dsymFile(bundle string) (string, error) {
+	dir := filepath.Join(bundle, "Contents", "Resources", "DWARF")
+
+	name := filepath.Join(dir, strings.TrimSuffix(filepath.Base(bundle), ".dSYM"))
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+
+	// the bundle may be renamed, so fall back to the only file
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(fis) != 1 {
+		return "", fmt.Errorf("%s: no DWARF file", bundle)
+	}
+	return filepath.Join(dir, fis[0].Name()), nil
+}
+
+// LoadDSYM uses DWARF and symbols of the dSYM at path, which is a .dSYM bundle or the file in it.
+// The dSYM must have the same LC_UUID as f. If the dSYM is a universal binary, the matching architecture is used.
+func (f *File) LoadDSYM(path string) error {
+	uuid, ok := f.UUID()
+	if !ok {
+		return errors.New("no LC_UUID")
+	}
+
+	if fi, err := os.Stat(path); err != nil {
+		return err
+	} else if fi.IsDir() {
+		path, err = dsymFile(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+	}
+
+	var files []*macho.File
+	var closer io.Closer
+
+	ff, err := OpenFat(path)
+	switch err {
+	case nil:
+		for _, arch := range ff.Arches {
+			if arch.File != nil {
+				files = append(files, arch.File)
+			}
+		}
+		closer = ff
+	case macho.ErrNotFat:
+		mf, err := macho.Open(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, mf)
+		closer = mf
+	default:
+		return err
+	}
+
+	for _, mf := range files {
+		if u, ok := fileUUID(mf); ok && u == uuid {
+			if f.dsymCloser != nil {
+				f.dsymCloser.Close()
+			}
+			f.DSYM = NewFile(mf)
+			f.dsymCloser = closer
+			f.lines = nil
+			return nil
+		}
+	}
+
+	closer.Close()
+
+	return fmt.Errorf("%s: no matching UUID (%s)", path, uuidString(uuid))
+}
+
+// DWARF returns the DWARF data of the dSYM if it's loaded.
+func (f *File) DWARF() (*dwarf.Data, error) {
+	if f.DSYM != nil {
+		return f.DSYM.DWARF()
+	}
+	return f.File.DWARF()
+}
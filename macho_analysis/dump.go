@@ -0,0 +1,212 @@
+package macho_analysis
+
+import (
+	"debug/macho"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Dump writes what the Structure, Symbols and Relocations tabs show, without Qt.
+// format is "text" or "json".
+func Dump(w io.Writer, mf *macho.File, format string) error {
+	d := NewFile(mf).newDumpFile()
+
+	switch format {
+	case "text":
+		return d.writeText(w)
+	case "json":
+		return writeJSON(w, d)
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// DumpFat is like Dump, but writes the fat header and all architectures.
+func DumpFat(w io.Writer, ff *FatFile, format string) error {
+	fat := newDumpNode(FatStructTree(ff))
+
+	arches := make([]*dumpFile, len(ff.Arches))
+	for i, arch := range ff.Arches {
+		if arch.File == nil {
+			arches[i] = &dumpFile{Error: arch.Err.Error()}
+		} else {
+			arches[i] = NewFile(arch.File).newDumpFile()
+		}
+		arches[i].Arch = ArchString(arch.Cpu, arch.SubCpu)
+	}
+
+	switch format {
+	case "text":
+		fmt.Fprintln(w, "Fat")
+		fat.writeText(w, 1)
+		for _, d := range arches {
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "Arch %s\n", d.Arch)
+			if err := d.writeText(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		return writeJSON(w, struct {
+			Fat    *dumpNode   `json:"fat"`
+			Arches []*dumpFile `json:"arches"`
+		}{fat, arches})
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+type dumpNode struct {
+	Name     string      `json:"name"`
+	Header   []string    `json:"header,omitempty"`
+	Rows     [][]string  `json:"rows,omitempty"`
+	Children []*dumpNode `json:"children,omitempty"`
+}
+
+type dumpTable struct {
+	Name   string     `json:"name,omitempty"`
+	Header []string   `json:"header"`
+	Rows   [][]string `json:"rows"`
+}
+
+type dumpFile struct {
+	Arch        string       `json:"arch,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Structure   *dumpNode    `json:"structure,omitempty"`
+	Symbols     *dumpTable   `json:"symbols,omitempty"`
+	Relocations []*dumpTable `json:"relocations,omitempty"`
+}
+
+func newDumpNode(n *StructNode) *dumpNode {
+	d := &dumpNode{Name: n.Name}
+	if n.HasRows() {
+		d.Header = n.Header
+		for _, row := range n.Rows() {
+			d.Rows = append(d.Rows, valueStrings(row))
+		}
+	}
+	for _, c := range n.Children {
+		if c.Section != nil {
+			continue
+		}
+		d.Children = append(d.Children, newDumpNode(c))
+	}
+	return d
+}
+
+func (f *File) newDumpFile() *dumpFile {
+	d := &dumpFile{
+		Structure: newDumpNode(f.StructTree()),
+	}
+
+	symtab := &dumpTable{Header: SymtabHeader, Rows: [][]string{}}
+	for i := range f.Syms {
+		row := make([]string, len(SymtabHeader))
+		for j := range row {
+			row[j] = f.SymtabCell(&f.Syms[i], j)
+		}
+		symtab.Rows = append(symtab.Rows, row)
+	}
+	d.Symbols = symtab
+
+	if f.Type == macho.TypeObj {
+		for i, s := range f.Sections {
+			reltab := &dumpTable{
+				Name:   fmt.Sprintf("%d (%s,%s)", i+1, s.Seg, s.Name),
+				Header: ReltabHeader,
+				Rows:   [][]string{},
+			}
+			for _, r := range f.RelocRows(s) {
+				row := make([]string, len(ReltabHeader))
+				for j := range row {
+					row[j] = f.ReltabCell(s, r, j)
+				}
+				reltab.Rows = append(reltab.Rows, row)
+			}
+			d.Relocations = append(d.Relocations, reltab)
+		}
+	}
+
+	return d
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (d *dumpFile) writeText(w io.Writer) error {
+	if d.Error != "" {
+		fmt.Fprintf(w, "Error\n  %s\n", d.Error)
+		return nil
+	}
+
+	fmt.Fprintln(w, "Structure")
+	d.Structure.writeText(w, 1)
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Symbols (%d)\n", len(d.Symbols.Rows))
+	d.Symbols.writeText(w, 1)
+
+	if d.Relocations != nil {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Relocations")
+		for _, t := range d.Relocations {
+			fmt.Fprintf(w, "  %s (%d)\n", t.Name, len(t.Rows))
+			t.writeText(w, 2)
+		}
+	}
+
+	return nil
+}
+
+func (d *dumpNode) writeText(w io.Writer, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	fmt.Fprintf(w, "%s%s\n", indent, d.Name)
+
+	if d.Header != nil {
+		if len(d.Header) == 2 && d.Header[0] == "Field" {
+			for _, row := range d.Rows {
+				fmt.Fprintf(w, "%s    %s: %s\n", indent, row[0], textCell(row[1]))
+			}
+		} else {
+			t := &dumpTable{Header: d.Header, Rows: d.Rows}
+			t.writeText(w, depth+2)
+		}
+	}
+
+	for _, c := range d.Children {
+		c.writeText(w, depth+1)
+	}
+}
+
+func (t *dumpTable) writeText(w io.Writer, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	fmt.Fprintf(w, "%s%s\n", indent, strings.Join(t.Header, "\t"))
+	for _, row := range t.Rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			cells[i] = textCell(c)
+		}
+		fmt.Fprintf(w, "%s%s\n", indent, strings.Join(cells, "\t"))
+	}
+}
+
+func valueStrings(vals []Value) []string {
+	ss := make([]string, len(vals))
+	for i, v := range vals {
+		ss[i] = v.String()
+	}
+	return ss
+}
+
+// textCell puts multi-line values on a line.
+func textCell(s string) string {
+	return strings.Replace(s, "\n", ", ", -1)
+}
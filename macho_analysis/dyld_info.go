@@ -0,0 +1,622 @@
+//go:generate stringer -type=RebaseType,RebaseOpcode,BindType,BindOpcode -output dyld_info_string.go
+
+package macho_analysis
+
+// reference:
+// <mach-o/loader.h>
+// https://opensource.apple.com/source/dyld/dyld-635.2/src/ImageLoaderMachOCompressed.cpp
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type RebaseType uint8
+
+const (
+	REBASE_TYPE_POINTER         RebaseType = 1
+	REBASE_TYPE_TEXT_ABSOLUTE32 RebaseType = 2
+	REBASE_TYPE_TEXT_PCREL32    RebaseType = 3
+)
+
+const (
+	REBASE_OPCODE_MASK    = 0xf0
+	REBASE_IMMEDIATE_MASK = 0x0f
+)
+
+type RebaseOpcode uint8
+
+const (
+	REBASE_OPCODE_DONE                               RebaseOpcode = 0x00
+	REBASE_OPCODE_SET_TYPE_IMM                       RebaseOpcode = 0x10
+	REBASE_OPCODE_SET_SEGMENT_AND_OFFSET_ULEB        RebaseOpcode = 0x20
+	REBASE_OPCODE_ADD_ADDR_ULEB                      RebaseOpcode = 0x30
+	REBASE_OPCODE_ADD_ADDR_IMM_SCALED                RebaseOpcode = 0x40
+	REBASE_OPCODE_DO_REBASE_IMM_TIMES                RebaseOpcode = 0x50
+	REBASE_OPCODE_DO_REBASE_ULEB_TIMES               RebaseOpcode = 0x60
+	REBASE_OPCODE_DO_REBASE_ADD_ADDR_ULEB            RebaseOpcode = 0x70
+	REBASE_OPCODE_DO_REBASE_ULEB_TIMES_SKIPPING_ULEB RebaseOpcode = 0x80
+)
+
+type BindType uint8
+
+const (
+	BIND_TYPE_POINTER         BindType = 1
+	BIND_TYPE_TEXT_ABSOLUTE32 BindType = 2
+	BIND_TYPE_TEXT_PCREL32    BindType = 3
+)
+
+const (
+	BIND_SPECIAL_DYLIB_SELF            = 0
+	BIND_SPECIAL_DYLIB_MAIN_EXECUTABLE = -1
+	BIND_SPECIAL_DYLIB_FLAT_LOOKUP     = -2
+	BIND_SPECIAL_DYLIB_WEAK_LOOKUP     = -3
+)
+
+const (
+	BIND_SYMBOL_FLAGS_WEAK_IMPORT         = 0x1
+	BIND_SYMBOL_FLAGS_NON_WEAK_DEFINITION = 0x8
+)
+
+const (
+	BIND_OPCODE_MASK    = 0xf0
+	BIND_IMMEDIATE_MASK = 0x0f
+)
+
+type BindOpcode uint8
+
+const (
+	BIND_OPCODE_DONE                             BindOpcode = 0x00
+	BIND_OPCODE_SET_DYLIB_ORDINAL_IMM            BindOpcode = 0x10
+	BIND_OPCODE_SET_DYLIB_ORDINAL_ULEB           BindOpcode = 0x20
+	BIND_OPCODE_SET_DYLIB_SPECIAL_IMM            BindOpcode = 0x30
+	BIND_OPCODE_SET_SYMBOL_TRAILING_FLAGS_IMM    BindOpcode = 0x40
+	BIND_OPCODE_SET_TYPE_IMM                     BindOpcode = 0x50
+	BIND_OPCODE_SET_ADDEND_SLEB                  BindOpcode = 0x60
+	BIND_OPCODE_SET_SEGMENT_AND_OFFSET_ULEB      BindOpcode = 0x70
+	BIND_OPCODE_ADD_ADDR_ULEB                    BindOpcode = 0x80
+	BIND_OPCODE_DO_BIND                          BindOpcode = 0x90
+	BIND_OPCODE_DO_BIND_ADD_ADDR_ULEB            BindOpcode = 0xa0
+	BIND_OPCODE_DO_BIND_ADD_ADDR_IMM_SCALED      BindOpcode = 0xb0
+	BIND_OPCODE_DO_BIND_ULEB_TIMES_SKIPPING_ULEB BindOpcode = 0xc0
+	BIND_OPCODE_THREADED                         BindOpcode = 0xd0
+)
+
+const (
+	BIND_SUBOPCODE_THREADED_SET_BIND_ORDINAL_TABLE_SIZE_ULEB = 0x00
+	BIND_SUBOPCODE_THREADED_APPLY                            = 0x01
+)
+
+// DyldInfoCmd is a struct dyld_info_command.
+type DyldInfoCmd struct {
+	Cmd          LoadCommand
+	Len          uint32
+	RebaseOff    uint32
+	RebaseSize   uint32
+	BindOff      uint32
+	BindSize     uint32
+	WeakBindOff  uint32
+	WeakBindSize uint32
+	LazyBindOff  uint32
+	LazyBindSize uint32
+	ExportOff    uint32
+	ExportSize   uint32
+}
+
+// DyldOpcode is an opcode of rebase or bind opcode streams.
+type DyldOpcode struct {
+	Off  uint64 // file offset
+	Data []byte
+	Name string
+	Args string
+}
+
+type DyldRebase struct {
+	Seg  *macho.Segment
+	Addr uint64
+	Type RebaseType
+}
+
+type DyldBind struct {
+	Seg     *macho.Segment
+	Addr    uint64
+	Type    BindType
+	Ordinal int
+	Symbol  string
+	Addend  int64
+	Flags   uint8
+}
+
+func (f *File) dyldInfoCmd() *DyldInfoCmd {
+	for _, lc := range f.Loads {
+		raw := lc.Raw()
+		if len(raw) < 8 {
+			continue
+		}
+		switch LoadCommand(f.ByteOrder.Uint32(raw[0:4])) {
+		case LC_DYLD_INFO, LC_DYLD_INFO_ONLY:
+			cmd := new(DyldInfoCmd)
+			if err := binary.Read(bytes.NewReader(raw), f.ByteOrder, cmd); err != nil {
+				// TODO warning
+				return nil
+			}
+			return cmd
+		}
+	}
+	return nil
+}
+
+// segments returns segments in the order of load commands.
+// Segment indices in dyld opcodes refer this order.
+func (f *File) segments() []*macho.Segment {
+	var segs []*macho.Segment
+	for _, lc := range f.Loads {
+		if seg, ok := lc.(*macho.Segment); ok {
+			segs = append(segs, seg)
+		}
+	}
+	return segs
+}
+
+// dylibs returns install names of dylibs in the order of load commands.
+// Library ordinals refer this order.
+func (f *File) dylibs() []string {
+	var libs []string
+	for _, lc := range f.Loads {
+		raw := lc.Raw()
+		if len(raw) < 24 {
+			continue
+		}
+		switch LoadCommand(f.ByteOrder.Uint32(raw[0:4])) {
+		case LC_LOAD_DYLIB, LC_LOAD_WEAK_DYLIB, LC_REEXPORT_DYLIB, LC_LAZY_LOAD_DYLIB, LC_LOAD_UPWARD_DYLIB:
+			off := f.ByteOrder.Uint32(raw[8:12])
+			if off >= uint32(len(raw)) {
+				libs = append(libs, "")
+				continue
+			}
+			name := raw[off:]
+			if i := bytes.IndexByte(name, 0); i != -1 {
+				name = name[:i]
+			}
+			libs = append(libs, string(name))
+		}
+	}
+	return libs
+}
+
+// bindSymbols returns symbols bound by the bind opcodes, which are keyed by the bound addresses.
+func (f *File) bindSymbols() map[uint64]string {
+	if f.bindSyms != nil {
+		return f.bindSyms
+	}
+
+	f.bindSyms = make(map[uint64]string)
+
+	cmd := f.dyldInfoCmd()
+	if cmd == nil {
+		return f.bindSyms
+	}
+
+	for _, b := range []struct {
+		off, size uint32
+		lazy      bool
+	}{
+		{cmd.BindOff, cmd.BindSize, false},
+		{cmd.WeakBindOff, cmd.WeakBindSize, false},
+		{cmd.LazyBindOff, cmd.LazyBindSize, true},
+	} {
+		if b.size == 0 {
+			continue
+		}
+		data, err := f.readFileData(uint64(b.off), uint64(b.size))
+		if err != nil {
+			// TODO warning
+			continue
+		}
+		_, binds, err := f.decodeBind(data, uint64(b.off), b.lazy)
+		if err != nil {
+			// TODO warning
+		}
+		for _, bind := range binds {
+			f.bindSyms[bind.Addr] = bind.Symbol
+		}
+	}
+
+	return f.bindSyms
+}
+
+// pointerSize returns the size of pointers, which is decided by the header magic and the CPU.
+// arm64_32 is a 64-bit CPU with 32-bit pointers.
+func (f *File) pointerSize() uint64 {
+	if f.Magic == macho.Magic64 || f.Cpu&0x01000000 != 0 { // CPU_ARCH_ABI64
+		return 8
+	}
+	return 4
+}
+
+// readFileData reads the data at the file offset from segments, typically from __LINKEDIT.
+func (f *File) readFileData(off, size uint64) ([]byte, error) {
+	for _, seg := range f.segments() {
+		if seg.Offset <= off && off+size <= seg.Offset+seg.Filesz {
+			data := make([]byte, size)
+			if _, err := seg.ReadAt(data, int64(off-seg.Offset)); err != nil {
+				return nil, err
+			}
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("file offset %#x is out of segments", off)
+}
+
+func (f *File) dylibOrdinalString(ord int) string {
+	switch ord {
+	case BIND_SPECIAL_DYLIB_SELF:
+		return "0 (BIND_SPECIAL_DYLIB_SELF)"
+	case BIND_SPECIAL_DYLIB_MAIN_EXECUTABLE:
+		return "-1 (BIND_SPECIAL_DYLIB_MAIN_EXECUTABLE)"
+	case BIND_SPECIAL_DYLIB_FLAT_LOOKUP:
+		return "-2 (BIND_SPECIAL_DYLIB_FLAT_LOOKUP)"
+	case BIND_SPECIAL_DYLIB_WEAK_LOOKUP:
+		return "-3 (BIND_SPECIAL_DYLIB_WEAK_LOOKUP)"
+	}
+	if libs := f.dylibs(); 0 < ord && ord <= len(libs) {
+		return fmt.Sprintf("%d (%s)", ord, libs[ord-1])
+	}
+	// TODO warning
+	return fmt.Sprintf("%d (?)", ord)
+}
+
+func (f *File) bindFlagsString(flags uint8) string {
+	var vals []string
+	if flags&BIND_SYMBOL_FLAGS_WEAK_IMPORT != 0 {
+		vals = append(vals, "0x1 (BIND_SYMBOL_FLAGS_WEAK_IMPORT)")
+		flags ^= BIND_SYMBOL_FLAGS_WEAK_IMPORT
+	}
+	if flags&BIND_SYMBOL_FLAGS_NON_WEAK_DEFINITION != 0 {
+		vals = append(vals, "0x8 (BIND_SYMBOL_FLAGS_NON_WEAK_DEFINITION)")
+		flags ^= BIND_SYMBOL_FLAGS_NON_WEAK_DEFINITION
+	}
+	if flags != 0 {
+		vals = append(vals, fmt.Sprintf("%#x (?)", flags))
+	}
+	return strings.Join(vals, "\n")
+}
+
+func (f *File) segIndexString(segs []*macho.Segment, i uint8) string {
+	if int(i) < len(segs) {
+		return fmt.Sprintf("%d (%s)", i, segs[i].Name)
+	}
+	return fmt.Sprintf("%d (?)", i)
+}
+
+// decodeRebase interprets rebase opcodes. off is the file offset of data.
+// On error, it returns opcodes and rebases decoded so far.
+func (f *File) decodeRebase(data []byte, off uint64) ([]DyldOpcode, []DyldRebase, error) {
+	var ops []DyldOpcode
+	var rebases []DyldRebase
+
+	segs := f.segments()
+	psize := f.pointerSize()
+
+	var typ RebaseType
+	var seg *macho.Segment
+	var addr uint64
+
+	rebase := func(count, skip uint64) error {
+		if seg == nil {
+			return errors.New("rebase before setting segment")
+		}
+		if count > seg.Memsz/psize { // avoid huge loop on broken data
+			return errors.New("too many rebases")
+		}
+		for i := uint64(0); i < count; i++ {
+			rebases = append(rebases, DyldRebase{Seg: seg, Addr: addr, Type: typ})
+			addr += psize + skip
+		}
+		return nil
+	}
+
+	for i := 0; i < len(data); {
+		start := i
+		op := RebaseOpcode(data[i] & REBASE_OPCODE_MASK)
+		imm := data[i] & REBASE_IMMEDIATE_MASK
+		i++
+
+		var args string
+		var err error
+
+		switch op {
+		case REBASE_OPCODE_DONE:
+		case REBASE_OPCODE_SET_TYPE_IMM:
+			typ = RebaseType(imm)
+			args = fmt.Sprintf("%d (%s)", imm, typ)
+		case REBASE_OPCODE_SET_SEGMENT_AND_OFFSET_ULEB:
+			var v uint64
+			var n int
+			v, n, err = readUleb128(data[i:])
+			i += n
+			if int(imm) < len(segs) {
+				seg = segs[imm]
+				addr = seg.Addr + v
+			} else {
+				err = fmt.Errorf("invalid segment index %d", imm)
+			}
+			args = fmt.Sprintf("%s, %#x", f.segIndexString(segs, imm), v)
+		case REBASE_OPCODE_ADD_ADDR_ULEB:
+			var v uint64
+			var n int
+			v, n, err = readUleb128(data[i:])
+			i += n
+			addr += v
+			args = fmt.Sprintf("%#x", v)
+		case REBASE_OPCODE_ADD_ADDR_IMM_SCALED:
+			addr += uint64(imm) * psize
+			args = fmt.Sprintf("%d (%#x)", imm, uint64(imm)*psize)
+		case REBASE_OPCODE_DO_REBASE_IMM_TIMES:
+			err = rebase(uint64(imm), 0)
+			args = fmt.Sprint(imm)
+		case REBASE_OPCODE_DO_REBASE_ULEB_TIMES:
+			var v uint64
+			var n int
+			v, n, err = readUleb128(data[i:])
+			i += n
+			if err == nil {
+				err = rebase(v, 0)
+			}
+			args = fmt.Sprint(v)
+		case REBASE_OPCODE_DO_REBASE_ADD_ADDR_ULEB:
+			var v uint64
+			var n int
+			v, n, err = readUleb128(data[i:])
+			i += n
+			if err == nil {
+				err = rebase(1, v)
+			}
+			args = fmt.Sprintf("%#x", v)
+		case REBASE_OPCODE_DO_REBASE_ULEB_TIMES_SKIPPING_ULEB:
+			var count, skip uint64
+			var n int
+			count, n, err = readUleb128(data[i:])
+			i += n
+			if err == nil {
+				skip, n, err = readUleb128(data[i:])
+				i += n
+			}
+			if err == nil {
+				err = rebase(count, skip)
+			}
+			args = fmt.Sprintf("%d, %#x", count, skip)
+		default:
+			err = fmt.Errorf("unknown rebase opcode %#02x", uint8(op))
+		}
+
+		ops = append(ops, DyldOpcode{
+			Off:  off + uint64(start),
+			Data: data[start:i],
+			Name: op.String(),
+			Args: args,
+		})
+
+		if err != nil {
+			return ops, rebases, err
+		}
+
+		if op == REBASE_OPCODE_DONE {
+			break
+		}
+	}
+
+	return ops, rebases, nil
+}
+
+// decodeBind interprets bind opcodes. off is the file offset of data.
+// If lazy is true, BIND_OPCODE_DONE doesn't terminate the stream.
+// On error, it returns opcodes and binds decoded so far.
+func (f *File) decodeBind(data []byte, off uint64, lazy bool) ([]DyldOpcode, []DyldBind, error) {
+	var ops []DyldOpcode
+	var binds []DyldBind
+
+	segs := f.segments()
+	psize := f.pointerSize()
+
+	typ := BIND_TYPE_POINTER
+	var seg *macho.Segment
+	var addr uint64
+	var ord int
+	var sym string
+	var addend int64
+	var flags uint8
+
+	bind := func(count, skip uint64) error {
+		if seg == nil {
+			return errors.New("bind before setting segment")
+		}
+		if count > seg.Memsz/psize { // avoid huge loop on broken data
+			return errors.New("too many binds")
+		}
+		for i := uint64(0); i < count; i++ {
+			binds = append(binds, DyldBind{
+				Seg:     seg,
+				Addr:    addr,
+				Type:    typ,
+				Ordinal: ord,
+				Symbol:  sym,
+				Addend:  addend,
+				Flags:   flags,
+			})
+			addr += psize + skip
+		}
+		return nil
+	}
+
+L:
+	for i := 0; i < len(data); {
+		start := i
+		op := BindOpcode(data[i] & BIND_OPCODE_MASK)
+		imm := data[i] & BIND_IMMEDIATE_MASK
+		i++
+
+		var args string
+		var err error
+
+		switch op {
+		case BIND_OPCODE_DONE:
+		case BIND_OPCODE_SET_DYLIB_ORDINAL_IMM:
+			ord = int(imm)
+			args = f.dylibOrdinalString(ord)
+		case BIND_OPCODE_SET_DYLIB_ORDINAL_ULEB:
+			var v uint64
+			var n int
+			v, n, err = readUleb128(data[i:])
+			i += n
+			ord = int(v)
+			args = f.dylibOrdinalString(ord)
+		case BIND_OPCODE_SET_DYLIB_SPECIAL_IMM:
+			if imm == 0 {
+				ord = 0
+			} else {
+				ord = int(int8(BIND_OPCODE_MASK | imm))
+			}
+			args = f.dylibOrdinalString(ord)
+		case BIND_OPCODE_SET_SYMBOL_TRAILING_FLAGS_IMM:
+			flags = imm
+			j := bytes.IndexByte(data[i:], 0)
+			if j == -1 {
+				err = errors.New("unterminated symbol name")
+				break
+			}
+			sym = string(data[i : i+j])
+			i += j + 1
+			args = sym
+			if flags != 0 {
+				args += ", " + strings.Replace(f.bindFlagsString(flags), "\n", ", ", -1)
+			}
+		case BIND_OPCODE_SET_TYPE_IMM:
+			typ = BindType(imm)
+			args = fmt.Sprintf("%d (%s)", imm, typ)
+		case BIND_OPCODE_SET_ADDEND_SLEB:
+			var n int
+			addend, n, err = readSleb128(data[i:])
+			i += n
+			args = fmt.Sprintf("%+d", addend)
+		case BIND_OPCODE_SET_SEGMENT_AND_OFFSET_ULEB:
+			var v uint64
+			var n int
+			v, n, err = readUleb128(data[i:])
+			i += n
+			if int(imm) < len(segs) {
+				seg = segs[imm]
+				addr = seg.Addr + v
+			} else {
+				err = fmt.Errorf("invalid segment index %d", imm)
+			}
+			args = fmt.Sprintf("%s, %#x", f.segIndexString(segs, imm), v)
+		case BIND_OPCODE_ADD_ADDR_ULEB:
+			var v uint64
+			var n int
+			v, n, err = readUleb128(data[i:])
+			i += n
+			addr += v
+			args = fmt.Sprintf("%#x", v)
+		case BIND_OPCODE_DO_BIND:
+			err = bind(1, 0)
+		case BIND_OPCODE_DO_BIND_ADD_ADDR_ULEB:
+			var v uint64
+			var n int
+			v, n, err = readUleb128(data[i:])
+			i += n
+			if err == nil {
+				err = bind(1, v)
+			}
+			args = fmt.Sprintf("%#x", v)
+		case BIND_OPCODE_DO_BIND_ADD_ADDR_IMM_SCALED:
+			err = bind(1, uint64(imm)*psize)
+			args = fmt.Sprintf("%d (%#x)", imm, uint64(imm)*psize)
+		case BIND_OPCODE_DO_BIND_ULEB_TIMES_SKIPPING_ULEB:
+			var count, skip uint64
+			var n int
+			count, n, err = readUleb128(data[i:])
+			i += n
+			if err == nil {
+				skip, n, err = readUleb128(data[i:])
+				i += n
+			}
+			if err == nil {
+				err = bind(count, skip)
+			}
+			args = fmt.Sprintf("%d, %#x", count, skip)
+		case BIND_OPCODE_THREADED:
+			// TODO resolve threaded binds. they are superseded by chained fixups.
+			switch imm {
+			case BIND_SUBOPCODE_THREADED_SET_BIND_ORDINAL_TABLE_SIZE_ULEB:
+				var v uint64
+				var n int
+				v, n, err = readUleb128(data[i:])
+				i += n
+				args = fmt.Sprintf("BIND_SUBOPCODE_THREADED_SET_BIND_ORDINAL_TABLE_SIZE_ULEB, %d", v)
+			case BIND_SUBOPCODE_THREADED_APPLY:
+				args = "BIND_SUBOPCODE_THREADED_APPLY"
+			default:
+				err = fmt.Errorf("unknown threaded bind subopcode %#x", imm)
+			}
+		default:
+			err = fmt.Errorf("unknown bind opcode %#02x", uint8(op))
+		}
+
+		ops = append(ops, DyldOpcode{
+			Off:  off + uint64(start),
+			Data: data[start:i],
+			Name: op.String(),
+			Args: args,
+		})
+
+		if err != nil {
+			return ops, binds, err
+		}
+
+		if op == BIND_OPCODE_DONE && !lazy {
+			break L
+		}
+	}
+
+	return ops, binds, nil
+}
+
+func readUleb128(p []byte) (uint64, int, error) {
+	var v uint64
+	var s uint
+	for i, c := range p {
+		if s >= 64 {
+			return 0, i, errors.New("uleb128 too large")
+		}
+		v |= uint64(c&0x7f) << s
+		if c&0x80 == 0 {
+			return v, i + 1, nil
+		}
+		s += 7
+	}
+	return 0, len(p), errors.New("truncated uleb128")
+}
+
+func readSleb128(p []byte) (int64, int, error) {
+	var v uint64
+	var s uint
+	for i, c := range p {
+		if s >= 64 {
+			return 0, i, errors.New("sleb128 too large")
+		}
+		v |= uint64(c&0x7f) << s
+		s += 7
+		if c&0x80 == 0 {
+			if c&0x40 != 0 && s < 64 {
+				v |= ^uint64(0) << s
+			}
+			return int64(v), i + 1, nil
+		}
+	}
+	return 0, len(p), errors.New("truncated sleb128")
+}
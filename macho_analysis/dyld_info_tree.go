@@ -0,0 +1,152 @@
+package macho_analysis
+
+import (
+	"fmt"
+)
+
+var (
+	dyldOpcodeHeader = []string{"Offset", "Data", "Opcode", "Operands"}
+	rebaseHeader     = []string{"Segment", "Address", "Type"}
+	bindHeader       = []string{"Segment", "Address", "Type", "Dylib", "Symbol", "Addend", "Flags"}
+)
+
+func (f *File) newDyldInfoNode() *StructNode {
+	cmd := f.dyldInfoCmd()
+	if cmd == nil {
+		// TODO warning
+		return &StructNode{Name: "LC_DYLD_INFO (?)"}
+	}
+
+	node := newStructNode(cmd.Cmd.String(), [][]string{
+		{"cmd", fmt.Sprintf("%#08x (%s)", uint32(cmd.Cmd), cmd.Cmd)},
+		{"cmdsize", fmt.Sprintf("%#08x", cmd.Len)},
+		{"rebase_off", fmt.Sprintf("%#08x", cmd.RebaseOff)},
+		{"rebase_size", fmt.Sprint(cmd.RebaseSize)},
+		{"bind_off", fmt.Sprintf("%#08x", cmd.BindOff)},
+		{"bind_size", fmt.Sprint(cmd.BindSize)},
+		{"weak_bind_off", fmt.Sprintf("%#08x", cmd.WeakBindOff)},
+		{"weak_bind_size", fmt.Sprint(cmd.WeakBindSize)},
+		{"lazy_bind_off", fmt.Sprintf("%#08x", cmd.LazyBindOff)},
+		{"lazy_bind_size", fmt.Sprint(cmd.LazyBindSize)},
+		{"export_off", fmt.Sprintf("%#08x", cmd.ExportOff)},
+		{"export_size", fmt.Sprint(cmd.ExportSize)},
+	})
+
+	if cmd.RebaseSize != 0 {
+		f.appendRebaseNodes(node, "Rebase", cmd.RebaseOff, cmd.RebaseSize)
+	}
+	if cmd.BindSize != 0 {
+		f.appendBindNodes(node, "Bind", cmd.BindOff, cmd.BindSize, false)
+	}
+	if cmd.WeakBindSize != 0 {
+		f.appendBindNodes(node, "Weak Bind", cmd.WeakBindOff, cmd.WeakBindSize, false)
+	}
+	if cmd.LazyBindSize != 0 {
+		f.appendBindNodes(node, "Lazy Bind", cmd.LazyBindOff, cmd.LazyBindSize, true)
+	}
+
+	return node
+}
+
+func (f *File) appendRebaseNodes(node *StructNode, name string, off, size uint32) {
+	var ops []DyldOpcode
+	var rebases []DyldRebase
+	var done bool
+
+	decode := func() {
+		if done {
+			return
+		}
+		done = true
+
+		data, err := f.readFileData(uint64(off), uint64(size))
+		if err != nil {
+			// TODO warning
+			return
+		}
+
+		ops, rebases, err = f.decodeRebase(data, uint64(off))
+		if err != nil {
+			// TODO warning
+		}
+	}
+
+	node.appendChild(newTableNode(fmt.Sprintf("%s Opcodes", name), dyldOpcodeHeader, func() [][]Value {
+		decode()
+		return f.dyldOpcodeRows(ops)
+	}))
+
+	node.appendChild(newTableNode(fmt.Sprintf("%s Table", name), rebaseHeader, func() [][]Value {
+		decode()
+		psize := f.pointerSize()
+		rows := make([][]Value, len(rebases))
+		for i, r := range rebases {
+			rows[i] = []Value{
+				Text(r.Seg.Name),
+				f.addrValue(r.Addr, psize),
+				Text(fmt.Sprintf("%d (%s)", r.Type, r.Type)),
+			}
+		}
+		return rows
+	}))
+}
+
+func (f *File) appendBindNodes(node *StructNode, name string, off, size uint32, lazy bool) {
+	var ops []DyldOpcode
+	var binds []DyldBind
+	var done bool
+
+	decode := func() {
+		if done {
+			return
+		}
+		done = true
+
+		data, err := f.readFileData(uint64(off), uint64(size))
+		if err != nil {
+			// TODO warning
+			return
+		}
+
+		ops, binds, err = f.decodeBind(data, uint64(off), lazy)
+		if err != nil {
+			// TODO warning
+		}
+	}
+
+	node.appendChild(newTableNode(fmt.Sprintf("%s Opcodes", name), dyldOpcodeHeader, func() [][]Value {
+		decode()
+		return f.dyldOpcodeRows(ops)
+	}))
+
+	node.appendChild(newTableNode(fmt.Sprintf("%s Table", name), bindHeader, func() [][]Value {
+		decode()
+		psize := f.pointerSize()
+		rows := make([][]Value, len(binds))
+		for i, b := range binds {
+			rows[i] = []Value{
+				Text(b.Seg.Name),
+				f.addrValue(b.Addr, psize),
+				Text(fmt.Sprintf("%d (%s)", b.Type, b.Type)),
+				Text(f.dylibOrdinalString(b.Ordinal)),
+				Text(b.Symbol),
+				Text(fmt.Sprintf("%+d", b.Addend)),
+				Text(f.bindFlagsString(b.Flags)),
+			}
+		}
+		return rows
+	}))
+}
+
+func (f *File) dyldOpcodeRows(ops []DyldOpcode) [][]Value {
+	rows := make([][]Value, len(ops))
+	for i, op := range ops {
+		rows[i] = []Value{
+			Text(fmt.Sprintf("%#08x", op.Off)),
+			Text(fmt.Sprintf("% x", op.Data)),
+			Text(op.Name),
+			Text(op.Args),
+		}
+	}
+	return rows
+}
@@ -1,6 +1,6 @@
-//go:generate stringer -type=DW_EH_PE_basicType,DW_EH_PE_modType -output eh_frame_model_string.go
+//go:generate stringer -type=DW_EH_PE_basicType,DW_EH_PE_modType -output eh_frame_string.go
 
-package macho_widgets
+package macho_analysis
 
 // reference:
 // http://www.airs.com/blog/archives/460
@@ -14,9 +14,6 @@ import (
 	"fmt"
 	"io"
 	"strings"
-
-	"github.com/therecipe/qt/core"
-	"github.com/therecipe/qt/gui"
 )
 
 type DW_EH_PE_basicType uint8
@@ -64,6 +61,7 @@ type parser struct {
 	cieInfos map[uint64]*cieInfo
 	scratch  [8]byte
 	cieNum   int
+	lsdas    map[uint64]uint64 // LSDA address -> function address
 }
 
 type cieInfo struct {
@@ -73,28 +71,38 @@ type cieInfo struct {
 	fenc uint8  // FDE encoding
 	lenc uint8  // LSDA encoding
 
-	cfiItem *gui.QStandardItem
+	caf   uint64    // Code Alignment Factor
+	daf   int64     // Data Alignment Factor
+	insts []cfaInst // Initial Instructions
+
+	cfiNode *DataNode
 	fdeNum  int
 }
 
-func (f *File) NewEHFrameSectionModel(sect *macho.Section) core.QAbstractItemModel_ITF {
-	m := gui.NewQStandardItemModel(nil)
-	m.SetHorizontalHeaderItem(0, gui.NewQStandardItem2("Address"))
-	m.SetHorizontalHeaderItem(1, gui.NewQStandardItem2("Data"))
-	m.SetHorizontalHeaderItem(2, gui.NewQStandardItem2("Name"))
-	m.SetHorizontalHeaderItem(3, gui.NewQStandardItem2("Interpretation"))
+var ehFrameHeader = []string{"Address", "Data", "Name", "Interpretation"}
+
+// EHFrame returns CFIs of the __eh_frame section. Each CFI has a CIE and FDEs.
+func (f *File) EHFrame(sect *macho.Section) *DataTree {
+	t, _ := f.ehFrame(sect)
+	return t
+}
+
+// ehFrame is like EHFrame, but also returns the parser, which knows LSDAs of FDEs parsed so far.
+func (f *File) ehFrame(sect *macho.Section) (*DataTree, *parser) {
+	t := &DataTree{Header: ehFrameHeader}
 
 	p := &parser{
 		f:        f,
 		cieInfos: make(map[uint64]*cieInfo),
+		lsdas:    make(map[uint64]uint64),
 	}
 
 	off := uint64(0)
 
 	for off < sect.Size {
-		length, extended, ok := p.populateItem(m, sect, off)
+		length, extended, ok := p.populateItem(t, sect, off)
 		if !ok {
-			return nil
+			return nil, p
 		}
 
 		if extended {
@@ -112,11 +120,11 @@ func (f *File) NewEHFrameSectionModel(sect *macho.Section) core.QAbstractItemMod
 		}
 	}
 
-	return m
+	return t, p
 }
 
-func (p *parser) populateItem(m *gui.QStandardItemModel, sect *macho.Section, top uint64) (length uint64, extended bool, ok bool) {
-	item := gui.NewQStandardItem()
+func (p *parser) populateItem(t *DataTree, sect *macho.Section, top uint64) (length uint64, extended bool, ok bool) {
+	item := new(DataNode)
 
 	bo := p.f.ByteOrder
 
@@ -130,22 +138,22 @@ func (p *parser) populateItem(m *gui.QStandardItemModel, sect *macho.Section, to
 	if l := bo.Uint32(p.scratch[:4]); l == 0xFFFFFFFF {
 		extended = true
 		length = bo.Uint64(p.scratch[:8])
-		item.AppendRow([]*gui.QStandardItem{
-			gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-			gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:8])),
-			gui.NewQStandardItem2("Length"),
-			gui.NewQStandardItem2(fmt.Sprintf("%d", length)),
-		})
+		item.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:8])),
+			Text("Length"),
+			Text(fmt.Sprintf("%d", length)),
+		)
 		off += 8
 	} else {
 		extended = false
 		length = uint64(l)
-		item.AppendRow([]*gui.QStandardItem{
-			gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-			gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:4])),
-			gui.NewQStandardItem2("Length"),
-			gui.NewQStandardItem2(fmt.Sprintf("%d", length)),
-		})
+		item.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:4])),
+			Text("Length"),
+			Text(fmt.Sprintf("%d", length)),
+		)
 		off += 4
 	}
 
@@ -158,27 +166,26 @@ func (p *parser) populateItem(m *gui.QStandardItemModel, sect *macho.Section, to
 	}
 	cieId := bo.Uint32(p.scratch[:4])
 	if cieId == 0 {
-		cfiItem := gui.NewQStandardItem2(fmt.Sprintf("CFI %d", p.cieNum))
+		cfiNode := t.appendRow(Text(fmt.Sprintf("CFI %d", p.cieNum)))
 		p.cieInfos[top] = &cieInfo{
-			cfiItem: cfiItem,
+			cfiNode: cfiNode,
 		}
-		m.AppendRow2(cfiItem)
 
-		item.SetText(fmt.Sprintf("CIE %d", p.cieNum))
+		item.Values = []Value{Text(fmt.Sprintf("CIE %d", p.cieNum))}
 
-		item.AppendRow([]*gui.QStandardItem{
-			gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-			gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:4])),
-			gui.NewQStandardItem2("CIE ID"),
-			gui.NewQStandardItem2(fmt.Sprintf("%d", cieId)),
-		})
+		item.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:4])),
+			Text("CIE ID"),
+			Text(fmt.Sprintf("%d", cieId)),
+		)
 		off += 4
 		if ok := p.populateCIEItem(item, sect, top, off, end, cieId); !ok {
 			// TODO warning
 			return 0, false, false
 		}
 
-		cfiItem.AppendRow2(item)
+		cfiNode.Children = append(cfiNode.Children, item)
 
 		p.cieNum++
 	} else {
@@ -189,23 +196,23 @@ func (p *parser) populateItem(m *gui.QStandardItemModel, sect *macho.Section, to
 			return 0, false, false
 		}
 
-		cfiItem := info.cfiItem
+		cfiNode := info.cfiNode
 
-		item.SetText(fmt.Sprintf("FDE %d", info.fdeNum))
+		item.Values = []Value{Text(fmt.Sprintf("FDE %d", info.fdeNum))}
 
-		item.AppendRow([]*gui.QStandardItem{
-			gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-			gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:4])),
-			gui.NewQStandardItem2("CIE Pointer"),
-			gui.NewQStandardItem2(fmt.Sprintf("addr = -%#x(%%rip) = %#x", cieId, sect.Addr+uint64(off)-uint64(cieId))),
-		})
+		item.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:4])),
+			Text("CIE Pointer"),
+			Text(fmt.Sprintf("addr = -%#x(%%rip) = %#x", cieId, sect.Addr+uint64(off)-uint64(cieId))),
+		)
 		off += 4
 		if ok := p.populateFDEItem(item, sect, off, end, info); !ok {
 			// TODO warning
 			return 0, false, false
 		}
 
-		cfiItem.AppendRow2(item)
+		cfiNode.Children = append(cfiNode.Children, item)
 
 		info.fdeNum++
 	}
@@ -213,19 +220,19 @@ func (p *parser) populateItem(m *gui.QStandardItemModel, sect *macho.Section, to
 	return length, extended, true
 }
 
-func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, top uint64, off, end int64, id uint32) (ok bool) {
+func (p *parser) populateCIEItem(item *DataNode, sect *macho.Section, top uint64, off, end int64, id uint32) (ok bool) {
 	_, err := sect.ReadAt(p.scratch[:1], off)
 	if err != nil {
 		// TODO warning
 		return
 	}
 	version := p.scratch[0]
-	item.AppendRow([]*gui.QStandardItem{
-		gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-		gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:1])),
-		gui.NewQStandardItem2("Version"),
-		gui.NewQStandardItem2(fmt.Sprintf("%d", version)),
-	})
+	item.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		Text(fmt.Sprintf("% x", p.scratch[:1])),
+		Text("Version"),
+		Text(fmt.Sprintf("%d", version)),
+	)
 	off++
 
 	n, err := sect.ReadAt(p.scratch[:], off)
@@ -239,12 +246,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 		return
 	}
 	aug := string(p.scratch[:i])
-	item.AppendRow([]*gui.QStandardItem{
-		gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-		gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:1])),
-		gui.NewQStandardItem2("Augumentation String"),
-		gui.NewQStandardItem2(aug),
-	})
+	item.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		Text(fmt.Sprintf("% x", p.scratch[:1])),
+		Text("Augumentation String"),
+		Text(aug),
+	)
 	off += int64(i) + 1
 
 	if aug == "eh" {
@@ -254,12 +261,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 				// TODO warning
 				return
 			}
-			item.AppendRow([]*gui.QStandardItem{
-				gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-				gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:4])),
-				gui.NewQStandardItem2("EH Data"),
+			item.appendRow(
+				Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+				Text(fmt.Sprintf("% x", p.scratch[:4])),
+				Text("EH Data"),
 				nil, // TODO
-			})
+			)
 			off += 4
 		} else {
 			_, err := sect.ReadAt(p.scratch[:8], off)
@@ -267,12 +274,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 				// TODO warning
 				return
 			}
-			item.AppendRow([]*gui.QStandardItem{
-				gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-				gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:8])),
-				gui.NewQStandardItem2("EH Data"),
+			item.appendRow(
+				Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+				Text(fmt.Sprintf("% x", p.scratch[:8])),
+				Text("EH Data"),
 				nil, // TODO
-			})
+			)
 			off += 8
 		}
 	}
@@ -283,12 +290,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 		// TODO warning
 		return
 	}
-	item.AppendRow([]*gui.QStandardItem{
-		gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-		gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:n])),
-		gui.NewQStandardItem2("Code Alignment Factor"),
-		gui.NewQStandardItem2(fmt.Sprintf("%d", caf)),
-	})
+	item.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		Text(fmt.Sprintf("% x", p.scratch[:n])),
+		Text("Code Alignment Factor"),
+		Text(fmt.Sprintf("%d", caf)),
+	)
 	off += int64(n)
 
 	var daf int64
@@ -297,12 +304,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 		// TODO warning
 		return
 	}
-	item.AppendRow([]*gui.QStandardItem{
-		gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-		gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:n])),
-		gui.NewQStandardItem2("Data Alignment Factor"),
-		gui.NewQStandardItem2(fmt.Sprintf("%d", daf)),
-	})
+	item.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		Text(fmt.Sprintf("% x", p.scratch[:n])),
+		Text("Data Alignment Factor"),
+		Text(fmt.Sprintf("%d", daf)),
+	)
 	off += int64(n)
 
 	var rar uint64
@@ -314,12 +321,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 			return
 		}
 		rar = uint64(p.scratch[0])
-		item.AppendRow([]*gui.QStandardItem{
-			gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-			gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:1])),
-			gui.NewQStandardItem2("Return Address Register"),
-			gui.NewQStandardItem2(p.f.registerString(rar)),
-		})
+		item.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:1])),
+			Text("Return Address Register"),
+			Text(p.f.registerString(rar)),
+		)
 		off++
 	case 3:
 		n, err := p.uleb128(sect, off, &rar)
@@ -327,12 +334,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 			// TODO warning
 			return
 		}
-		item.AppendRow([]*gui.QStandardItem{
-			gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-			gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:n])),
-			gui.NewQStandardItem2("Return Address Register"),
-			gui.NewQStandardItem2(p.f.registerString(rar)),
-		})
+		item.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:n])),
+			Text("Return Address Register"),
+			Text(p.f.registerString(rar)),
+		)
 		off += int64(n)
 	}
 
@@ -348,12 +355,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 			// TODO warning
 			return
 		}
-		item.AppendRow([]*gui.QStandardItem{
-			gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-			gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:n])),
-			gui.NewQStandardItem2("Augumentation Data Length"),
-			gui.NewQStandardItem2(fmt.Sprintf("%d", augdatalen)),
-		})
+		item.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:n])),
+			Text("Augumentation Data Length"),
+			Text(fmt.Sprintf("%d", augdatalen)),
+		)
 		off += int64(n)
 
 		augend := off + int64(augdatalen)
@@ -367,12 +374,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 					return
 				}
 				penc = p.scratch[0]
-				item.AppendRow([]*gui.QStandardItem{
-					gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-					gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:1])),
-					gui.NewQStandardItem2("Augumentation Data (Personlity Encoding)"),
-					gui.NewQStandardItem2(p.encodingString(penc, true)),
-				})
+				item.appendRow(
+					Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+					Text(fmt.Sprintf("% x", p.scratch[:1])),
+					Text("Augumentation Data (Personlity Encoding)"),
+					Text(p.encodingString(penc)),
+				)
 				off++
 
 				n, err := p.pointer(sect, off, penc, &pptr)
@@ -380,12 +387,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 					// TODO warning
 					return
 				}
-				item.AppendRow([]*gui.QStandardItem{
-					gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-					gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:n])),
-					gui.NewQStandardItem2("Augumentation Data (Personlity Pointer)"),
-					gui.NewQStandardItem2(p.pointerString(pptr, sect.Addr+uint64(off), penc)),
-				})
+				item.appendRow(
+					Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+					Text(fmt.Sprintf("% x", p.scratch[:n])),
+					Text("Augumentation Data (Personlity Pointer)"),
+					Text(p.pointerString(pptr, sect.Addr+uint64(off), penc)),
+				)
 				off += int64(n)
 			case 'R': // FDE encoding
 				_, err := sect.ReadAt(p.scratch[:1], off)
@@ -394,12 +401,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 					return
 				}
 				fenc = p.scratch[0]
-				item.AppendRow([]*gui.QStandardItem{
-					gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-					gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:1])),
-					gui.NewQStandardItem2("Augumentation Data (FDE Encoding)"),
-					gui.NewQStandardItem2(p.encodingString(fenc, true)),
-				})
+				item.appendRow(
+					Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+					Text(fmt.Sprintf("% x", p.scratch[:1])),
+					Text("Augumentation Data (FDE Encoding)"),
+					Text(p.encodingString(fenc)),
+				)
 				off++
 			case 'L': // LSDA encoding
 				_, err := sect.ReadAt(p.scratch[:1], off)
@@ -408,12 +415,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 					return
 				}
 				lenc = p.scratch[0]
-				item.AppendRow([]*gui.QStandardItem{
-					gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-					gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:1])),
-					gui.NewQStandardItem2("Augumentation Data (LSDA Encoding)"),
-					gui.NewQStandardItem2(p.encodingString(lenc, true)),
-				})
+				item.appendRow(
+					Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+					Text(fmt.Sprintf("% x", p.scratch[:1])),
+					Text("Augumentation Data (LSDA Encoding)"),
+					Text(p.encodingString(lenc)),
+				)
 				off++
 			default:
 				if off > augend {
@@ -428,12 +435,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 						// TODO warning
 						return
 					}
-					item.AppendRow([]*gui.QStandardItem{
-						gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-						gui.NewQStandardItem2(fmt.Sprintf("% x", rest)),
-						gui.NewQStandardItem2("Augumentation Data (Unknown)"),
+					item.appendRow(
+						Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+						Text(fmt.Sprintf("% x", rest)),
+						Text("Augumentation Data (Unknown)"),
 						nil, // TODO
-					})
+					)
 				}
 
 				off = augend
@@ -452,12 +459,12 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 				// TODO warning
 				return
 			}
-			item.AppendRow([]*gui.QStandardItem{
-				gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-				gui.NewQStandardItem2(fmt.Sprintf("% x", rest)),
-				gui.NewQStandardItem2("Augumentation Data (Remains)"),
+			item.appendRow(
+				Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+				Text(fmt.Sprintf("% x", rest)),
+				Text("Augumentation Data (Remains)"),
 				nil, // TODO
-			})
+			)
 		}
 
 		off = augend
@@ -468,54 +475,64 @@ func (p *parser) populateCIEItem(item *gui.QStandardItem, sect *macho.Section, t
 		return
 	}
 
-	insts := make([]byte, end-off)
-	_, err = sect.ReadAt(insts, off)
-	if err != nil {
-		// TODO warning
-		return
-	}
-	item.AppendRow([]*gui.QStandardItem{
-		gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-		gui.NewQStandardItem2(fmt.Sprintf("% x", insts)),
-		gui.NewQStandardItem2("Initial Instructions"),
-		nil, // TODO
-	})
-
 	info := p.cieInfos[top]
 
 	info.aug = aug
 	info.penc = penc
 	info.pptr = pptr
+	info.fenc = fenc
 	info.lenc = lenc
+	info.caf = caf
+	info.daf = daf
+
+	data := make([]byte, end-off)
+	_, err = sect.ReadAt(data, off)
+	if err != nil {
+		// TODO warning
+		return
+	}
+	insts, err := p.cfaInsts(sect, sect.Addr, off, end, info)
+	if err != nil {
+		// TODO warning
+	}
+	p.appendCFARows(item.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		Text(fmt.Sprintf("% x", data)),
+		Text("Initial Instructions"),
+		Text(fmt.Sprintf("%d", len(insts))),
+	), insts)
+
+	info.insts = insts
 
 	return true
 }
 
-func (p *parser) populateFDEItem(item *gui.QStandardItem, sect *macho.Section, off, end int64, info *cieInfo) (ok bool) {
+func (p *parser) populateFDEItem(item *DataNode, sect *macho.Section, off, end int64, info *cieInfo) (ok bool) {
 	var pcBegin, pcRange uint64
+	pcBeginAddr := sect.Addr + uint64(off)
 	n, err := p.pointer(sect, off, info.fenc, &pcBegin)
 	if err != nil {
 		// TODO warning
 		return false
 	}
-	item.AppendRow([]*gui.QStandardItem{
-		gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-		gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:n])),
-		gui.NewQStandardItem2("PC Begin"),
-		gui.NewQStandardItem2(p.pointerString(pcBegin, sect.Addr+uint64(off), info.fenc)),
-	})
+	item.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		Text(fmt.Sprintf("% x", p.scratch[:n])),
+		Text("PC Begin"),
+		Text(p.pointerString(pcBegin, sect.Addr+uint64(off), info.fenc)),
+	)
 	off += int64(n)
-	n, err = p.pointer(sect, off, info.fenc, &pcRange)
+	n, err = p.pointer(sect, off, info.fenc&DW_EH_PE_basic, &pcRange)
 	if err != nil {
 		// TODO warning
 		return false
 	}
-	item.AppendRow([]*gui.QStandardItem{
-		gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-		gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:n])),
-		gui.NewQStandardItem2("PC Range"),
-		gui.NewQStandardItem2(fmt.Sprintf("%d", pcRange)),
-	})
+	item.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		Text(fmt.Sprintf("% x", p.scratch[:n])),
+		Text("PC Range"),
+		Text(fmt.Sprintf("%d", pcRange)),
+	)
 	off += int64(n)
 
 	if info.aug != "" && info.aug[0] == 'z' {
@@ -525,12 +542,12 @@ func (p *parser) populateFDEItem(item *gui.QStandardItem, sect *macho.Section, o
 			// TODO warning
 			return
 		}
-		item.AppendRow([]*gui.QStandardItem{
-			gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-			gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:n])),
-			gui.NewQStandardItem2("Augumentation Data Length"),
-			gui.NewQStandardItem2(fmt.Sprintf("%d", augdatalen)),
-		})
+		item.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:n])),
+			Text("Augumentation Data Length"),
+			Text(fmt.Sprintf("%d", augdatalen)),
+		)
 		off += int64(n)
 
 		augend := off + int64(augdatalen)
@@ -546,12 +563,19 @@ func (p *parser) populateFDEItem(item *gui.QStandardItem, sect *macho.Section, o
 					// TODO warning
 					return false
 				}
-				item.AppendRow([]*gui.QStandardItem{
-					gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-					gui.NewQStandardItem2(fmt.Sprintf("% x", p.scratch[:n])),
-					gui.NewQStandardItem2("Augumentation Data (LSDA Pointer)"),
-					gui.NewQStandardItem2(p.pointerString(lptr, sect.Addr+uint64(off), info.lenc)),
-				})
+				lsda := Text(p.pointerString(lptr, sect.Addr+uint64(off), info.lenc))
+				if addr, ok := p.pointerAddr(lptr, sect.Addr+uint64(off), info.lenc); ok && addr != 0 {
+					lsda = Value{}.appendLink(lsda.String(), addressLink(addr, 0))
+					if begin, ok := p.pointerAddr(pcBegin, pcBeginAddr, info.fenc); ok {
+						p.lsdas[addr] = begin
+					}
+				}
+				item.appendRow(
+					Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+					Text(fmt.Sprintf("% x", p.scratch[:n])),
+					Text("Augumentation Data (LSDA Pointer)"),
+					lsda,
+				)
 				off += int64(n)
 			default:
 				if off > augend {
@@ -566,12 +590,12 @@ func (p *parser) populateFDEItem(item *gui.QStandardItem, sect *macho.Section, o
 						// TODO warning
 						return
 					}
-					item.AppendRow([]*gui.QStandardItem{
-						gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-						gui.NewQStandardItem2(fmt.Sprintf("% x", rest)),
-						gui.NewQStandardItem2("Augumentation Data (Unknown)"),
+					item.appendRow(
+						Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+						Text(fmt.Sprintf("% x", rest)),
+						Text("Augumentation Data (Unknown)"),
 						nil, // TODO
-					})
+					)
 				}
 
 				off = augend
@@ -590,12 +614,12 @@ func (p *parser) populateFDEItem(item *gui.QStandardItem, sect *macho.Section, o
 				// TODO warning
 				return false
 			}
-			item.AppendRow([]*gui.QStandardItem{
-				gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-				gui.NewQStandardItem2(fmt.Sprintf("% x", rest)),
-				gui.NewQStandardItem2("Augumentation Data (Remains)"),
+			item.appendRow(
+				Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+				Text(fmt.Sprintf("% x", rest)),
+				Text("Augumentation Data (Remains)"),
 				nil, // TODO
-			})
+			)
 		}
 
 		off = augend
@@ -606,37 +630,41 @@ func (p *parser) populateFDEItem(item *gui.QStandardItem, sect *macho.Section, o
 		return false
 	}
 
-	insts := make([]byte, end-off)
-	_, err = sect.ReadAt(insts, off)
+	data := make([]byte, end-off)
+	_, err = sect.ReadAt(data, off)
 	if err != nil {
 		// TODO warning
 		return false
 	}
-	item.AppendRow([]*gui.QStandardItem{
-		gui.NewQStandardItem2(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
-		gui.NewQStandardItem2(fmt.Sprintf("% x", insts)),
-		gui.NewQStandardItem2("Initial Instructions"),
-		nil, // TODO
-	})
+	insts, err := p.cfaInsts(sect, sect.Addr, off, end, info)
+	if err != nil {
+		// TODO warning
+	}
+	p.appendCFARows(item.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		Text(fmt.Sprintf("% x", data)),
+		Text("Instructions"),
+		Text(fmt.Sprintf("%d", len(insts))),
+	), insts)
+
+	if begin, ok := p.pointerAddr(pcBegin, pcBeginAddr, info.fenc); ok {
+		item.Children = append(item.Children, p.unwindNode(info, insts, begin, begin+pcRange))
+	}
 
 	return true
 }
 
-func (p *parser) encodingString(enc uint8, html bool) string {
+func (p *parser) encodingString(enc uint8) string {
 	if enc == DW_EH_PE_omit {
 		return "0xff (DW_EH_PE_omit)"
 	}
 	var values []string
 	values = append(values, fmt.Sprintf("%#02x (%s)", enc&DW_EH_PE_basic, DW_EH_PE_basicType(enc&DW_EH_PE_basic)))
-	values = append(values, fmt.Sprintf("%#02x (%s)", enc&DW_EH_PE_modifier, DW_EH_PE_modType(enc&DW_EH_PE_modifier)))
+	values = append(values, fmt.Sprintf("%#02x (%s)", enc&DW_EH_PE_modifier&^DW_EH_PE_indirect, DW_EH_PE_modType(enc&DW_EH_PE_modifier&^DW_EH_PE_indirect)))
 	if enc&DW_EH_PE_indirect != 0 {
 		values = append(values, "0x80 (DW_EH_PE_indirect)")
 	}
-	s := strings.Join(values, "\n")
-	if html {
-		s = "<body>" + s + "</body>"
-	}
-	return s
+	return strings.Join(values, "\n")
 }
 
 func (p *parser) pointerString(val uint64, addr uint64, enc uint8) string {
@@ -664,6 +692,27 @@ func (p *parser) pointerString(val uint64, addr uint64, enc uint8) string {
 	return fmt.Sprintf("%#x", val)
 }
 
+// pointerAddr returns the address of val, which is read at addr.
+func (p *parser) pointerAddr(val uint64, addr uint64, enc uint8) (uint64, bool) {
+	if enc == DW_EH_PE_omit || enc&DW_EH_PE_indirect != 0 {
+		return 0, false
+	}
+
+	switch DW_EH_PE_modType(enc & DW_EH_PE_modifier) {
+	case DW_EH_PE_absptr:
+	case DW_EH_PE_pcrel:
+		val += addr
+	default:
+		return 0, false
+	}
+
+	if p.f.Cpu&0x01000000 == 0 { // 32bit
+		val &= 0xffffffff
+	}
+
+	return val, true
+}
+
 func (p *parser) pointer(r io.ReaderAt, off int64, enc uint8, val *uint64) (int, error) {
 	if enc == DW_EH_PE_omit {
 		return 0, nil
@@ -698,20 +747,34 @@ func (p *parser) pointer(r io.ReaderAt, off int64, enc uint8, val *uint64) (int,
 		}
 		*val = uint64(i64)
 		return n, nil
-	case DW_EH_PE_udata2, DW_EH_PE_sdata2:
+	case DW_EH_PE_udata2:
 		_, err := r.ReadAt(p.scratch[:2], off)
 		if err != nil {
 			return 0, err
 		}
 		*val = uint64(bo.Uint16(p.scratch[:2]))
 		return 2, nil
-	case DW_EH_PE_udata4, DW_EH_PE_sdata4:
+	case DW_EH_PE_sdata2:
+		_, err := r.ReadAt(p.scratch[:2], off)
+		if err != nil {
+			return 0, err
+		}
+		*val = uint64(int16(bo.Uint16(p.scratch[:2])))
+		return 2, nil
+	case DW_EH_PE_udata4:
 		_, err := r.ReadAt(p.scratch[:4], off)
 		if err != nil {
 			return 0, err
 		}
 		*val = uint64(bo.Uint32(p.scratch[:4]))
 		return 4, nil
+	case DW_EH_PE_sdata4:
+		_, err := r.ReadAt(p.scratch[:4], off)
+		if err != nil {
+			return 0, err
+		}
+		*val = uint64(int32(bo.Uint32(p.scratch[:4])))
+		return 4, nil
 	case DW_EH_PE_udata8, DW_EH_PE_sdata8:
 		_, err := r.ReadAt(p.scratch[:8], off)
 		if err != nil {
@@ -0,0 +1,483 @@
+package macho_analysis
+
+// reference:
+// https://itanium-cxx-abi.github.io/cxx-abi/exceptions.pdf
+// https://www.airs.com/blog/archives/464
+
+import (
+	"debug/macho"
+	"fmt"
+	"sort"
+)
+
+// lsdaFuncs returns LSDAs referred by __eh_frame and __unwind_info, which map to the functions.
+func (f *File) lsdaFuncs() map[uint64]uint64 {
+	if f.lsdas != nil {
+		return f.lsdas
+	}
+
+	f.lsdas = make(map[uint64]uint64)
+
+	if sect := f.Section("__eh_frame"); sect != nil {
+		_, p := f.ehFrame(sect)
+		for lsda, fn := range p.lsdas {
+			f.lsdas[lsda] = fn
+		}
+	}
+	if sect := f.Section("__unwind_info"); sect != nil {
+		_, u := f.decodeUnwindInfo(sect)
+		for lsda, fn := range u.lsdas {
+			f.lsdas[lsda] = fn
+		}
+	}
+
+	return f.lsdas
+}
+
+// ExceptTable returns LSDAs of the __gcc_except_tab section.
+// LSDAs aren't self-describing, so only LSDAs referred by __eh_frame or __unwind_info are decoded.
+func (f *File) ExceptTable(sect *macho.Section) *DataTree {
+	var addrs []uint64
+	for lsda := range f.lsdaFuncs() {
+		if sect.Addr <= lsda && lsda < sect.Addr+sect.Size {
+			addrs = append(addrs, lsda)
+		}
+	}
+	if len(addrs) == 0 {
+		// TODO warning
+		return f.newDataSectionData(sect)
+	}
+
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i] < addrs[j]
+	})
+
+	t := &DataTree{Header: ehFrameHeader}
+	for i, lsda := range addrs {
+		t.rows = append(t.rows, f.lsdaNode(sect, lsda, fmt.Sprintf("LSDA %d", i)))
+	}
+	return t
+}
+
+// LSDA returns the LSDA at addr, or nil if addr isn't a LSDA.
+func (f *File) LSDA(addr uint64) *DataTree {
+	if _, ok := f.lsdaFuncs()[addr]; !ok {
+		return nil
+	}
+	for _, sect := range f.Sections {
+		if sect.Addr <= addr && addr < sect.Addr+sect.Size {
+			return &DataTree{Header: ehFrameHeader, rows: []*DataNode{f.lsdaNode(sect, addr, "LSDA")}}
+		}
+	}
+	return nil
+}
+
+type lsdaParser struct {
+	*parser
+
+	sect   *macho.Section
+	fn     uint64 // function start
+	lpBase uint64 // landing pad base
+	ttBase uint64 // end of the type table, 0 if there is no type table
+	ttenc  uint8
+}
+
+func (f *File) lsdaNode(sect *macho.Section, lsda uint64, label string) *DataNode {
+	fn := f.lsdaFuncs()[lsda]
+
+	n := &DataNode{Values: []Value{Text(label), nil, nil, f.addrSymValue(fn, 0)}}
+
+	p := &lsdaParser{
+		parser: &parser{f: f},
+		sect:   sect,
+		fn:     fn,
+		lpBase: fn,
+	}
+	if ok := p.populateLSDA(n, int64(lsda-sect.Addr)); !ok {
+		// TODO warning
+	}
+	return n
+}
+
+func (p *lsdaParser) populateLSDA(n *DataNode, off int64) (ok bool) {
+	sect := p.sect
+
+	enc := func(name string) (uint8, bool) {
+		if _, err := sect.ReadAt(p.scratch[:1], off); err != nil {
+			return 0, false
+		}
+		e := p.scratch[0]
+		n.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:1])),
+			Text(name),
+			Text(p.encodingString(e)),
+		)
+		off++
+		return e, true
+	}
+
+	lpenc, ok := enc("LPStart Encoding")
+	if !ok {
+		return false
+	}
+	if lpenc != DW_EH_PE_omit {
+		var val uint64
+		m, err := p.pointer(sect, off, lpenc, &val)
+		if err != nil {
+			return false
+		}
+		if addr, ok := p.pointerAddr(val, sect.Addr+uint64(off), lpenc); ok {
+			p.lpBase = addr
+		}
+		n.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:m])),
+			Text("LPStart"),
+			p.f.addrSymValue(p.lpBase, 0),
+		)
+		off += int64(m)
+	}
+
+	p.ttenc, ok = enc("TType Encoding")
+	if !ok {
+		return false
+	}
+	if p.ttenc != DW_EH_PE_omit {
+		var ttoff uint64
+		m, err := p.uleb128(sect, off, &ttoff)
+		if err != nil {
+			return false
+		}
+		p.ttBase = sect.Addr + uint64(off) + uint64(m) + ttoff
+		n.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:m])),
+			Text("TType Base Offset"),
+			Text(fmt.Sprintf("%d (%#x)", ttoff, p.ttBase)),
+		)
+		off += int64(m)
+	}
+
+	csenc, ok := enc("Call-Site Encoding")
+	if !ok {
+		return false
+	}
+
+	var cslen uint64
+	m, err := p.uleb128(sect, off, &cslen)
+	if err != nil {
+		return false
+	}
+	n.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		Text(fmt.Sprintf("% x", p.scratch[:m])),
+		Text("Call-Site Table Length"),
+		Text(fmt.Sprintf("%d", cslen)),
+	)
+	off += int64(m)
+
+	// action records are numbered by offsets from the top of the action table
+	actBase := off + int64(cslen)
+
+	cs := n.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		nil,
+		Text("Call-Site Table"),
+		nil,
+	)
+
+	actions := make(map[uint64]bool)
+
+	for i := 0; off < actBase; i++ {
+		site := &DataNode{}
+		top := off
+
+		var vals [3]uint64
+		for j, name := range []string{"Start", "Length", "Landing Pad"} {
+			m, err := p.pointer(sect, off, csenc, &vals[j])
+			if err != nil || m == 0 {
+				return false
+			}
+			var val Value
+			switch j {
+			case 0:
+				val = p.f.addrSymValue(p.fn+vals[0], 0)
+			case 1:
+				val = Text(fmt.Sprintf("%d", vals[1]))
+			case 2:
+				if vals[2] == 0 {
+					val = Text("0 (none)")
+				} else {
+					val = p.f.addrSymValue(p.lpBase+vals[2], 0)
+				}
+			}
+			site.appendRow(
+				Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+				Text(fmt.Sprintf("% x", p.scratch[:m])),
+				Text(name),
+				val,
+			)
+			off += int64(m)
+		}
+
+		var action uint64
+		m, err := p.uleb128(sect, off, &action)
+		if err != nil {
+			return false
+		}
+		act := site.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+			Text(fmt.Sprintf("% x", p.scratch[:m])),
+			Text("Action"),
+			Text(p.actionString(action, vals[2])),
+		)
+		off += int64(m)
+
+		if action != 0 {
+			actions[action-1] = true
+			if ok := p.populateActionChain(act, actBase, action-1, actions); !ok {
+				// TODO warning
+			}
+		}
+
+		data := make([]byte, off-top)
+		if _, err := sect.ReadAt(data, top); err != nil {
+			return false
+		}
+
+		v := Value{}.appendText(fmt.Sprintf("%#x - %#x", p.fn+vals[0], p.fn+vals[0]+vals[1]))
+		if vals[2] != 0 {
+			v = v.appendText(", landing pad ").appendLink(fmt.Sprintf("%#x", p.lpBase+vals[2]), addressLink(p.lpBase+vals[2], 0))
+		}
+		if vals[2] != 0 {
+			v = v.appendText(", action " + p.actionString(action, vals[2]))
+		}
+
+		site.Values = []Value{
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(top))),
+			Text(fmt.Sprintf("% x", data)),
+			Text(fmt.Sprintf("Call-Site %d", i)),
+			v,
+		}
+		cs.Children = append(cs.Children, site)
+	}
+
+	cs.Values[3] = Text(fmt.Sprintf("%d entries", len(cs.Children)))
+
+	if len(actions) != 0 {
+		offs := make([]uint64, 0, len(actions))
+		for a := range actions {
+			offs = append(offs, a)
+		}
+		sort.Slice(offs, func(i, j int) bool {
+			return offs[i] < offs[j]
+		})
+
+		at := n.appendRow(
+			Text(fmt.Sprintf("%#016x", sect.Addr+uint64(actBase))),
+			nil,
+			Text("Action Table"),
+			Text(fmt.Sprintf("%d records", len(offs))),
+		)
+		for _, a := range offs {
+			if _, _, ok := p.appendActionRow(at, actBase, a); !ok {
+				// TODO warning
+				break
+			}
+		}
+	}
+
+	if p.ttBase != 0 {
+		if ok := p.populateTypeTable(n, actions, actBase); !ok {
+			// TODO warning
+		}
+	}
+
+	return true
+}
+
+func (p *lsdaParser) actionString(action, lp uint64) string {
+	if action == 0 {
+		if lp != 0 {
+			return "0 (cleanup)"
+		}
+		return "0"
+	}
+	return fmt.Sprintf("%d (action record %#x)", action, action-1)
+}
+
+// populateActionChain appends action records following a, and marks them as used.
+func (p *lsdaParser) populateActionChain(n *DataNode, actBase int64, a uint64, actions map[uint64]bool) (ok bool) {
+	for i := 0; i < 256; i++ { // guard against loops
+		next, more, ok := p.appendActionRow(n, actBase, a)
+		if !ok {
+			return false
+		}
+		if !more {
+			return true
+		}
+		a = next
+		actions[a] = true
+	}
+	return false
+}
+
+// appendActionRow appends the action record at a, and returns the next record of the chain if more is true.
+func (p *lsdaParser) appendActionRow(n *DataNode, actBase int64, a uint64) (next uint64, more bool, ok bool) {
+	sect := p.sect
+	off := actBase + int64(a)
+
+	var filter, disp int64
+	m1, err := p.sleb128(sect, off, &filter)
+	if err != nil {
+		return 0, false, false
+	}
+	m2, err := p.sleb128(sect, off+int64(m1), &disp)
+	if err != nil {
+		return 0, false, false
+	}
+
+	data := make([]byte, m1+m2)
+	if _, err := sect.ReadAt(data, off); err != nil {
+		return 0, false, false
+	}
+
+	v := Value{}.appendText(fmt.Sprintf("filter %d ", filter))
+	switch {
+	case filter > 0:
+		v = append(v.appendText("catch "), p.typeValue(uint64(filter))...)
+	case filter == 0:
+		v = v.appendText("(cleanup)")
+	default:
+		v = v.appendText("(exception specification)")
+	}
+	if disp == 0 {
+		v = v.appendText(", end")
+	} else {
+		// the displacement is relative to itself
+		next, more = uint64(int64(a)+int64(m1)+disp), true
+		v = v.appendText(fmt.Sprintf(", next %#x", next))
+	}
+
+	n.appendRow(
+		Text(fmt.Sprintf("%#016x", sect.Addr+uint64(off))),
+		Text(fmt.Sprintf("% x", data)),
+		Text(fmt.Sprintf("Action Record %#x", a)),
+		v,
+	)
+
+	return next, more, true
+}
+
+// populateTypeTable appends type infos referred by the actions, which are indexed backward from ttBase.
+func (p *lsdaParser) populateTypeTable(n *DataNode, actions map[uint64]bool, actBase int64) (ok bool) {
+	max := int64(0)
+	for a := range actions {
+		var filter int64
+		if _, err := p.sleb128(p.sect, actBase+int64(a), &filter); err != nil {
+			return false
+		}
+		if filter > max {
+			max = filter
+		}
+	}
+
+	size := p.ttypeSize()
+	if size == 0 {
+		return false
+	}
+
+	tt := n.appendRow(
+		Text(fmt.Sprintf("%#016x", p.ttBase-uint64(max)*size)),
+		nil,
+		Text("Type Table"),
+		Text(fmt.Sprintf("%d entries", max)),
+	)
+	for i := max; i > 0; i-- {
+		addr := p.ttBase - uint64(i)*size
+		if addr < p.sect.Addr || p.sect.Addr+p.sect.Size < addr+size {
+			return false
+		}
+		data := make([]byte, size)
+		if _, err := p.sect.ReadAt(data, int64(addr-p.sect.Addr)); err != nil {
+			return false
+		}
+		tt.appendRow(
+			Text(fmt.Sprintf("%#016x", addr)),
+			Text(fmt.Sprintf("% x", data)),
+			Text(fmt.Sprintf("Type %d", i)),
+			p.typeValue(uint64(i)),
+		)
+	}
+
+	return true
+}
+
+func (p *lsdaParser) ttypeSize() uint64 {
+	switch DW_EH_PE_basicType(p.ttenc & DW_EH_PE_basic) {
+	case DW_EH_PE_ptr, DW_EH_PE_signed:
+		return uint64(p.ptrSize())
+	case DW_EH_PE_udata2, DW_EH_PE_sdata2:
+		return 2
+	case DW_EH_PE_udata4, DW_EH_PE_sdata4:
+		return 4
+	case DW_EH_PE_udata8, DW_EH_PE_sdata8:
+		return 8
+	}
+	return 0
+}
+
+// typeValue returns i-th type info of the type table, which links to the symbol.
+func (p *lsdaParser) typeValue(i uint64) Value {
+	size := p.ttypeSize()
+	if p.ttBase == 0 || size == 0 {
+		return Text(fmt.Sprintf("type %d", i))
+	}
+
+	addr := p.ttBase - i*size
+	if addr < p.sect.Addr {
+		return Text(fmt.Sprintf("type %d", i))
+	}
+
+	var val uint64
+	if _, err := p.pointer(p.sect, int64(addr-p.sect.Addr), p.ttenc, &val); err != nil {
+		return Text(fmt.Sprintf("type %d", i))
+	}
+	if val == 0 {
+		return Text("...")
+	}
+
+	target, ok := p.pointerAddr(val, addr, p.ttenc&^DW_EH_PE_indirect)
+	if !ok {
+		return Text(fmt.Sprintf("%#x", val))
+	}
+	if p.ttenc&DW_EH_PE_indirect == 0 {
+		return p.f.addrSymValue(target, 0)
+	}
+
+	// the type info is bound to the pointer at target
+	v := p.f.addrSymValue(target, uint64(p.ptrSize()))
+	if fx := p.f.Fixups.Lookup(target); fx != nil {
+		v = v.appendText(" -> " + p.f.fixupString(p.f.Fixups, fx))
+	} else if sym := p.f.indirectSymbol(target); sym != nil {
+		v = v.appendText(" -> " + p.f.symName(sym.Name))
+	} else if s := p.pointeeString(target); s != "" {
+		v = v.appendText(" -> " + s)
+	}
+	return v
+}
+
+// pointeeString returns the symbol pointed by the pointer at addr, or "" if the pointer isn't bound yet.
+func (p *lsdaParser) pointeeString(addr uint64) string {
+	for _, sect := range p.f.Sections {
+		if sect.Addr <= addr && addr < sect.Addr+sect.Size && !p.f.isZeroSect(sect) {
+			var val uint64
+			if _, err := p.pointer(sect, int64(addr-sect.Addr), uint8(DW_EH_PE_ptr), &val); err != nil || val == 0 {
+				return ""
+			}
+			return p.f.symAddrString(val, false)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,302 @@
+package macho_analysis
+
+// reference:
+// <mach-o/loader.h>
+// https://opensource.apple.com/source/dyld/dyld-635.2/src/ImageLoaderMachOCompressed.cpp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	EXPORT_SYMBOL_FLAGS_KIND_MASK         = 0x03
+	EXPORT_SYMBOL_FLAGS_KIND_REGULAR      = 0x00
+	EXPORT_SYMBOL_FLAGS_KIND_THREAD_LOCAL = 0x01
+	EXPORT_SYMBOL_FLAGS_KIND_ABSOLUTE     = 0x02
+	EXPORT_SYMBOL_FLAGS_WEAK_DEFINITION   = 0x04
+	EXPORT_SYMBOL_FLAGS_REEXPORT          = 0x08
+	EXPORT_SYMBOL_FLAGS_STUB_AND_RESOLVER = 0x10
+	EXPORT_SYMBOL_FLAGS_STATIC_RESOLVER   = 0x20
+)
+
+// LinkeditDataCmd is a struct linkedit_data_command.
+type LinkeditDataCmd struct {
+	Cmd      LoadCommand
+	Len      uint32
+	Dataoff  uint32
+	Datasize uint32
+}
+
+var ExptabHeader = []string{"Name", "Flags", "Address", "Re-export"}
+
+type ExportSymbol struct {
+	Name       string
+	Flags      uint64
+	Addr       uint64 // exist if it isn't a re-export
+	Resolver   uint64 // exist if flags has EXPORT_SYMBOL_FLAGS_STUB_AND_RESOLVER
+	Ordinal    int    // exist if flags has EXPORT_SYMBOL_FLAGS_REEXPORT
+	ImportName string // exist if flags has EXPORT_SYMBOL_FLAGS_REEXPORT
+}
+
+func (f *File) linkeditDataCmd(lc LoadCommand) *LinkeditDataCmd {
+	for _, l := range f.Loads {
+		raw := l.Raw()
+		if len(raw) < 16 {
+			continue
+		}
+		if LoadCommand(f.ByteOrder.Uint32(raw[0:4])) == lc {
+			cmd := new(LinkeditDataCmd)
+			if err := binary.Read(bytes.NewReader(raw), f.ByteOrder, cmd); err != nil {
+				// TODO warning
+				return nil
+			}
+			return cmd
+		}
+	}
+	return nil
+}
+
+// baseAddr returns the address of the mach header.
+func (f *File) baseAddr() uint64 {
+	for _, seg := range f.segments() {
+		if seg.Offset == 0 && seg.Filesz != 0 {
+			return seg.Addr
+		}
+	}
+	return 0
+}
+
+// exportTrie returns the export trie from LC_DYLD_EXPORTS_TRIE or LC_DYLD_INFO(_ONLY).
+func (f *File) exportTrie() ([]byte, error) {
+	if cmd := f.linkeditDataCmd(LC_DYLD_EXPORTS_TRIE); cmd != nil {
+		return f.readFileData(uint64(cmd.Dataoff), uint64(cmd.Datasize))
+	}
+	if cmd := f.dyldInfoCmd(); cmd != nil && cmd.ExportSize != 0 {
+		return f.readFileData(uint64(cmd.ExportOff), uint64(cmd.ExportSize))
+	}
+	return nil, nil
+}
+
+func (f *File) HasExportTrie() bool {
+	if cmd := f.linkeditDataCmd(LC_DYLD_EXPORTS_TRIE); cmd != nil {
+		return cmd.Datasize != 0
+	}
+	if cmd := f.dyldInfoCmd(); cmd != nil {
+		return cmd.ExportSize != 0
+	}
+	return false
+}
+
+// ExportSymbols decodes the export trie. On error, it returns symbols decoded so far.
+func (f *File) ExportSymbols() ([]ExportSymbol, error) {
+	data, err := f.exportTrie()
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return f.decodeExportTrie(data)
+}
+
+// decodeExportTrie walks the trie in depth-first order.
+// On error, it returns symbols decoded so far.
+func (f *File) decodeExportTrie(data []byte) ([]ExportSymbol, error) {
+	var syms []ExportSymbol
+
+	base := f.baseAddr()
+
+	type node struct {
+		off    uint64
+		prefix string
+	}
+
+	visited := make(map[uint64]bool)
+
+	stack := []node{{0, ""}}
+
+	for len(stack) != 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if n.off >= uint64(len(data)) {
+			return syms, fmt.Errorf("trie node offset %#x is out of range", n.off)
+		}
+		if visited[n.off] {
+			return syms, fmt.Errorf("trie node %#x is visited twice", n.off)
+		}
+		visited[n.off] = true
+
+		p := data[n.off:]
+
+		tsize, i, err := readUleb128(p)
+		if err != nil {
+			return syms, err
+		}
+
+		if tsize != 0 {
+			if uint64(i)+tsize > uint64(len(p)) {
+				return syms, errors.New("truncated terminal info")
+			}
+
+			t := p[i : uint64(i)+tsize]
+
+			sym := ExportSymbol{Name: n.prefix}
+
+			var j int
+			sym.Flags, j, err = readUleb128(t)
+			if err != nil {
+				return syms, err
+			}
+			t = t[j:]
+
+			switch {
+			case sym.Flags&EXPORT_SYMBOL_FLAGS_REEXPORT != 0:
+				var ord uint64
+				ord, j, err = readUleb128(t)
+				if err != nil {
+					return syms, err
+				}
+				t = t[j:]
+				sym.Ordinal = int(ord)
+				if k := bytes.IndexByte(t, 0); k != -1 {
+					sym.ImportName = string(t[:k])
+				}
+				if sym.ImportName == "" {
+					sym.ImportName = sym.Name
+				}
+			default:
+				var off uint64
+				off, j, err = readUleb128(t)
+				if err != nil {
+					return syms, err
+				}
+				t = t[j:]
+				if sym.Flags&EXPORT_SYMBOL_FLAGS_KIND_MASK == EXPORT_SYMBOL_FLAGS_KIND_ABSOLUTE {
+					sym.Addr = off
+				} else {
+					sym.Addr = base + off
+				}
+				if sym.Flags&EXPORT_SYMBOL_FLAGS_STUB_AND_RESOLVER != 0 {
+					off, _, err = readUleb128(t)
+					if err != nil {
+						return syms, err
+					}
+					sym.Resolver = base + off
+				}
+			}
+
+			syms = append(syms, sym)
+		}
+
+		p = p[uint64(i)+tsize:]
+
+		if len(p) == 0 {
+			return syms, errors.New("truncated trie node")
+		}
+
+		nchild := int(p[0])
+		p = p[1:]
+
+		children := make([]node, nchild)
+
+		for k := range children {
+			j := bytes.IndexByte(p, 0)
+			if j == -1 {
+				return syms, errors.New("unterminated edge string")
+			}
+			edge := string(p[:j])
+			p = p[j+1:]
+
+			off, j, err := readUleb128(p)
+			if err != nil {
+				return syms, err
+			}
+			p = p[j:]
+
+			children[k] = node{off, n.prefix + edge}
+		}
+
+		// push in reverse order, so that children are visited in order.
+		for k := len(children) - 1; k >= 0; k-- {
+			stack = append(stack, children[k])
+		}
+	}
+
+	return syms, nil
+}
+
+// ExptabCell returns the column of ExptabHeader.
+func (f *File) ExptabCell(sym *ExportSymbol, col int) Value {
+	switch col {
+	case 0:
+		return Text(f.symName(sym.Name))
+	case 1:
+		return Text(f.exportFlagsString(sym.Flags))
+	case 2:
+		if sym.Flags&EXPORT_SYMBOL_FLAGS_REEXPORT != 0 {
+			break
+		}
+		if sym.Flags&EXPORT_SYMBOL_FLAGS_STUB_AND_RESOLVER != 0 {
+			v := Text("stub: ")
+			v = append(v, f.addrValue(sym.Addr, 0)...)
+			v = v.appendText("\nresolver: ")
+			return append(v, f.addrValue(sym.Resolver, 0)...)
+		}
+		if sym.Flags&EXPORT_SYMBOL_FLAGS_KIND_MASK == EXPORT_SYMBOL_FLAGS_KIND_ABSOLUTE {
+			return Text(fmt.Sprintf("%#016x", sym.Addr))
+		}
+		return f.addrValue(sym.Addr, 0)
+	case 3:
+		return Text(f.exportSourceString(sym))
+	}
+	return nil
+}
+
+func (f *File) exportFlagsString(flags uint64) string {
+	var vals []string
+
+	switch flags & EXPORT_SYMBOL_FLAGS_KIND_MASK {
+	case EXPORT_SYMBOL_FLAGS_KIND_REGULAR:
+		vals = append(vals, "0x00 (EXPORT_SYMBOL_FLAGS_KIND_REGULAR)")
+	case EXPORT_SYMBOL_FLAGS_KIND_THREAD_LOCAL:
+		vals = append(vals, "0x01 (EXPORT_SYMBOL_FLAGS_KIND_THREAD_LOCAL)")
+	case EXPORT_SYMBOL_FLAGS_KIND_ABSOLUTE:
+		vals = append(vals, "0x02 (EXPORT_SYMBOL_FLAGS_KIND_ABSOLUTE)")
+	default:
+		vals = append(vals, fmt.Sprintf("%#02x (?)", flags&EXPORT_SYMBOL_FLAGS_KIND_MASK))
+	}
+	flags &^= EXPORT_SYMBOL_FLAGS_KIND_MASK
+
+	if flags&EXPORT_SYMBOL_FLAGS_WEAK_DEFINITION != 0 {
+		vals = append(vals, "0x04 (EXPORT_SYMBOL_FLAGS_WEAK_DEFINITION)")
+		flags ^= EXPORT_SYMBOL_FLAGS_WEAK_DEFINITION
+	}
+	if flags&EXPORT_SYMBOL_FLAGS_REEXPORT != 0 {
+		vals = append(vals, "0x08 (EXPORT_SYMBOL_FLAGS_REEXPORT)")
+		flags ^= EXPORT_SYMBOL_FLAGS_REEXPORT
+	}
+	if flags&EXPORT_SYMBOL_FLAGS_STUB_AND_RESOLVER != 0 {
+		vals = append(vals, "0x10 (EXPORT_SYMBOL_FLAGS_STUB_AND_RESOLVER)")
+		flags ^= EXPORT_SYMBOL_FLAGS_STUB_AND_RESOLVER
+	}
+	if flags&EXPORT_SYMBOL_FLAGS_STATIC_RESOLVER != 0 {
+		vals = append(vals, "0x20 (EXPORT_SYMBOL_FLAGS_STATIC_RESOLVER)")
+		flags ^= EXPORT_SYMBOL_FLAGS_STATIC_RESOLVER
+	}
+	if flags != 0 {
+		// TODO warning
+		vals = append(vals, fmt.Sprintf("%#02x (??)", flags))
+	}
+
+	return strings.Join(vals, "\n")
+}
+
+// exportSourceString describes where the symbol comes from.
+func (f *File) exportSourceString(sym *ExportSymbol) string {
+	if sym.Flags&EXPORT_SYMBOL_FLAGS_REEXPORT == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s from %s", sym.ImportName, f.dylibOrdinalString(sym.Ordinal))
+}
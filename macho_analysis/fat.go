@@ -0,0 +1,205 @@
+package macho_analysis
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// FatFile is almost same as macho.FatFile, but it also accepts FAT_MAGIC_64.
+type FatFile struct {
+	Magic  uint32
+	Arches []FatArch
+
+	closer io.Closer
+}
+
+type FatArchHeader struct {
+	Cpu    macho.Cpu
+	SubCpu uint32
+	Offset uint64
+	Size   uint64
+	Align  uint32
+}
+
+type FatArch struct {
+	FatArchHeader
+	*macho.File
+
+	// Err is the error opening the architecture, in which case File is nil.
+	Err error
+}
+
+func OpenFat(name string) (*FatFile, error) {
+	r, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	ff, err := NewFatFile(r)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	ff.closer = r
+	return ff, nil
+}
+
+// NewFatFile returns macho.ErrNotFat if r isn't a universal binary.
+func NewFatFile(r io.ReaderAt) (*FatFile, error) {
+	bo := binary.BigEndian
+
+	var hdr [8]byte
+	if _, err := r.ReadAt(hdr[:], 0); err != nil {
+		return nil, err
+	}
+
+	ff := &FatFile{
+		Magic: bo.Uint32(hdr[0:4]),
+	}
+
+	var archSize int64
+
+	switch Magic(ff.Magic) {
+	case FAT_MAGIC:
+		archSize = 20
+	case FAT_MAGIC_64:
+		archSize = 32
+	default:
+		return nil, macho.ErrNotFat
+	}
+
+	narch := bo.Uint32(hdr[4:8])
+	if narch == 0 {
+		return nil, fmt.Errorf("invalid fat header: no architectures")
+	}
+
+	if size, ok := readerSize(r); ok && int64(narch) > (size-8)/archSize {
+		return nil, fmt.Errorf("invalid fat header: too many architectures: %d", narch)
+	}
+
+	buf := make([]byte, archSize)
+
+	for i := 0; i < int(narch); i++ {
+		if _, err := r.ReadAt(buf, 8+int64(i)*archSize); err != nil {
+			return nil, err
+		}
+
+		ff.Arches = append(ff.Arches, FatArch{})
+		arch := &ff.Arches[i]
+
+		arch.Cpu = macho.Cpu(bo.Uint32(buf[0:4]))
+		arch.SubCpu = bo.Uint32(buf[4:8])
+
+		if archSize == 20 {
+			arch.Offset = uint64(bo.Uint32(buf[8:12]))
+			arch.Size = uint64(bo.Uint32(buf[12:16]))
+			arch.Align = bo.Uint32(buf[16:20])
+		} else {
+			arch.Offset = bo.Uint64(buf[8:16])
+			arch.Size = bo.Uint64(buf[16:24])
+			arch.Align = bo.Uint32(buf[24:28])
+		}
+
+		f, err := macho.NewFile(io.NewSectionReader(r, int64(arch.Offset), int64(arch.Size)))
+		if err != nil {
+			arch.Err = fmt.Errorf("fat arch %d: %v", i, err)
+			continue
+		}
+
+		if f.Cpu != arch.Cpu {
+			arch.Err = fmt.Errorf("fat arch %d: cputype doesn't match with mach header", i)
+			continue
+		}
+
+		arch.File = f
+	}
+
+	return ff, nil
+}
+
+// readerSize returns the size of r if it's known.
+func readerSize(r io.ReaderAt) (int64, bool) {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		return r.Size(), true
+	case interface{ Stat() (os.FileInfo, error) }:
+		if fi, err := r.Stat(); err == nil {
+			return fi.Size(), true
+		}
+	}
+	return 0, false
+}
+
+func (ff *FatFile) Close() error {
+	if ff.closer != nil {
+		return ff.closer.Close()
+	}
+	return nil
+}
+
+// Arch returns the architecture named by ArchString, or nil.
+func (ff *FatFile) Arch(name string) *FatArch {
+	for i := range ff.Arches {
+		arch := &ff.Arches[i]
+		if ArchString(arch.Cpu, arch.SubCpu) == name {
+			return arch
+		}
+	}
+	return nil
+}
+
+// ArchString returns the architecture name in the same manner as lipo(1) and ld(1).
+func ArchString(cpu macho.Cpu, cpusub uint32) string {
+	cpusub &^= CPU_SUBTYPE_LIB64
+
+	switch cpu {
+	case macho.Cpu386:
+		return "i386"
+	case macho.CpuAmd64:
+		if CpuSubtypeX86_64(cpusub) == CPU_SUBTYPE_X86_64_H {
+			return "x86_64h"
+		}
+		return "x86_64"
+	case macho.CpuArm:
+		switch CpuSubtypeARM(cpusub) {
+		case CPU_SUBTYPE_ARM_V4T:
+			return "armv4t"
+		case CPU_SUBTYPE_ARM_V6:
+			return "armv6"
+		case CPU_SUBTYPE_ARM_V5TEJ:
+			return "armv5"
+		case CPU_SUBTYPE_ARM_XSCALE:
+			return "xscale"
+		case CPU_SUBTYPE_ARM_V7:
+			return "armv7"
+		case CPU_SUBTYPE_ARM_V7F:
+			return "armv7f"
+		case CPU_SUBTYPE_ARM_V7S:
+			return "armv7s"
+		case CPU_SUBTYPE_ARM_V7K:
+			return "armv7k"
+		case CPU_SUBTYPE_ARM_V6M:
+			return "armv6m"
+		case CPU_SUBTYPE_ARM_V7M:
+			return "armv7m"
+		case CPU_SUBTYPE_ARM_V7EM:
+			return "armv7em"
+		case CPU_SUBTYPE_ARM_V8:
+			return "armv8"
+		}
+		return "arm"
+	case macho.CpuArm | 0x01000000:
+		if CpuSubtypeARM64(cpusub&0x00ffffff) == CPU_SUBTYPE_ARM64E {
+			return "arm64e"
+		}
+		return "arm64"
+	case macho.CpuPpc:
+		return "ppc"
+	case macho.CpuPpc64:
+		return "ppc64"
+	}
+
+	return fmt.Sprintf("%#x:%#x", uint32(cpu), cpusub)
+}
@@ -0,0 +1,995 @@
+package macho_analysis
+
+// reference:
+// https://itanium-cxx-abi.github.io/cxx-abi/abi.html#mangling
+// https://github.com/llvm/llvm-project/blob/main/llvm/include/llvm/Demangle/ItaniumDemangle.h
+//
+// This is a subset of the Itanium C++ demangler, which covers symbols typically found in binaries.
+// Expressions other than literals aren't supported, and names which aren't understood are left mangled.
+
+import (
+	"fmt"
+	"strings"
+)
+
+type itKind int
+
+const (
+	itNameType     itKind = iota
+	itPointerType         // text is "*", "&" or "&&"
+	itQualType            // text is the qualifiers, e.g. " const"
+	itFunctionType        // text follows the parameters, e.g. " const"; elems are the return type and the parameters
+	itArrayType           // text is the dimension
+	itMemberType          // elems are the class and the member
+	itPackType            // elems are the elements
+)
+
+// itType is a demangled type.
+type itType struct {
+	kind  itKind
+	text  string
+	elems []*itType
+}
+
+func itName(s string) *itType {
+	return &itType{kind: itNameType, text: s}
+}
+
+func (t *itType) String() string {
+	return t.decl("")
+}
+
+// decl prints the type declaring inner like C, e.g. "int (*inner)()" for a pointer to a function.
+func (t *itType) decl(inner string) string {
+	switch t.kind {
+	case itQualType:
+		return t.elems[0].decl(t.text + itSpace(inner))
+	case itPointerType:
+		if t.elems[0].needsParen() {
+			return t.elems[0].decl("(" + t.text + inner + ")")
+		}
+		return t.elems[0].decl(t.text + itSpace(inner))
+	case itMemberType:
+		member := t.elems[0].String() + "::*"
+		if t.elems[1].needsParen() {
+			return t.elems[1].decl("(" + member + inner + ")")
+		}
+		return t.elems[1].decl(" " + member + itSpace(inner))
+	case itFunctionType:
+		return t.elems[0].decl(inner + "(" + itList(t.elems[1:]) + ")" + t.text)
+	case itArrayType:
+		if !strings.HasSuffix(inner, "]") {
+			inner += " "
+		}
+		return t.elems[0].decl(inner + "[" + t.text + "]")
+	case itPackType:
+		ss := make([]string, len(t.elems))
+		for i, e := range t.elems {
+			ss[i] = e.decl(inner)
+		}
+		return strings.Join(ss, ", ")
+	}
+	return t.text + itSpace(inner)
+}
+
+// needsParen reports whether a pointer to the type needs parentheses, e.g. "void (*)()".
+func (t *itType) needsParen() bool {
+	switch t.kind {
+	case itFunctionType, itArrayType:
+		return true
+	case itQualType:
+		return t.elems[0].needsParen()
+	}
+	return false
+}
+
+// itSpace separates inner from the preceding type unless it's a declarator, e.g. "*".
+func itSpace(inner string) string {
+	if inner == "" || strings.IndexByte("*& ", inner[0]) >= 0 {
+		return inner
+	}
+	return " " + inner
+}
+
+// itList prints the types separated by commas. Packs are expanded, so empty packs print nothing.
+func itList(ts []*itType) string {
+	var ss []string
+	var add func(ts []*itType)
+	add = func(ts []*itType) {
+		for _, t := range ts {
+			if t.kind == itPackType {
+				add(t.elems)
+			} else {
+				ss = append(ss, t.String())
+			}
+		}
+	}
+	add(ts)
+	return strings.Join(ss, ", ")
+}
+
+// itExpand expands the pack expansion of t, i.e. t for each element of the pack in t.
+func itExpand(t *itType) *itType {
+	pack := t.pack()
+	if pack == nil {
+		return t
+	}
+	n := &itType{kind: itPackType, elems: make([]*itType, len(pack.elems))}
+	for i, e := range pack.elems {
+		n.elems[i] = t.replace(pack, e)
+	}
+	return n
+}
+
+func (t *itType) pack() *itType {
+	if t.kind == itPackType {
+		return t
+	}
+	for _, e := range t.elems {
+		if p := e.pack(); p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+func (t *itType) replace(old, new *itType) *itType {
+	if t == old {
+		return new
+	}
+	n := &itType{kind: t.kind, text: t.text, elems: make([]*itType, len(t.elems))}
+	for i, e := range t.elems {
+		n.elems[i] = e.replace(old, new)
+	}
+	return n
+}
+
+type itDemangler struct {
+	text  string
+	pos   int
+	subst []*itType
+	targs []*itType // template arguments referred by T_
+
+	// state of the last name
+	template bool   // the name ends with template arguments
+	ctor     bool   // the name is a constructor, a destructor or a conversion
+	conv     bool   // the last operator name is a conversion
+	cv       string // qualifiers of the member function
+}
+
+var errItanium = fmt.Errorf("invalid itanium mangling")
+
+// ItaniumDemangle returns the demangled name of the C++ symbol, or false if the name isn't understood.
+// The name is like "_Z3foov", without the leading underscore of Mach-O.
+func ItaniumDemangle(name string) (string, bool) {
+	var text string
+	var block bool
+	switch {
+	case strings.HasPrefix(name, "_Z"):
+		text = name[2:]
+	case strings.HasPrefix(name, "___Z"):
+		// blocks
+		text = name[4:]
+		block = true
+	default:
+		return name, false
+	}
+
+	d := &itDemangler{text: text}
+	s, err := d.demangle(block)
+	if err != nil {
+		return name, false
+	}
+	return s, true
+}
+
+func (d *itDemangler) demangle(block bool) (s string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errItanium
+		}
+	}()
+
+	s = d.encoding()
+
+	rest := d.text[d.pos:]
+	switch {
+	case block:
+		i := strings.Index(rest, "_block_invoke")
+		if i != 0 {
+			return "", errItanium
+		}
+		return "invocation function for block in " + s, nil
+	case rest == "":
+		return s, nil
+	case rest[0] == '.':
+		// e.g. .cold.1
+		return s + " (" + rest + ")", nil
+	}
+	return "", errItanium
+}
+
+func (d *itDemangler) fail() {
+	panic(errItanium)
+}
+
+func (d *itDemangler) peek() byte {
+	if d.pos < len(d.text) {
+		return d.text[d.pos]
+	}
+	return 0
+}
+
+func (d *itDemangler) peekAt(i int) byte {
+	if d.pos+i < len(d.text) {
+		return d.text[d.pos+i]
+	}
+	return 0
+}
+
+func (d *itDemangler) next() byte {
+	c := d.peek()
+	if c == 0 {
+		d.fail()
+	}
+	d.pos++
+	return c
+}
+
+func (d *itDemangler) nextIf(s string) bool {
+	if strings.HasPrefix(d.text[d.pos:], s) {
+		d.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (d *itDemangler) expect(c byte) {
+	if d.next() != c {
+		d.fail()
+	}
+}
+
+func (d *itDemangler) number() int {
+	if !isDigit(d.peek()) {
+		d.fail()
+	}
+	n := 0
+	for isDigit(d.peek()) {
+		n = n*10 + int(d.next()-'0')
+		if n > 1<<20 {
+			d.fail()
+		}
+	}
+	return n
+}
+
+// seqID returns the base 36 number followed by '_', where "_" is 0 and "0_" is 1.
+func (d *itDemangler) seqID() int {
+	if d.nextIf("_") {
+		return 0
+	}
+	n := 0
+	for {
+		c := d.next()
+		switch {
+		case c == '_':
+			return n + 1
+		case isDigit(c):
+			n = n*36 + int(c-'0')
+		case isUpper(c):
+			n = n*36 + int(c-'A') + 10
+		default:
+			d.fail()
+		}
+		if n > 1<<20 {
+			d.fail()
+		}
+	}
+}
+
+// encoding returns a function, data, or special name.
+func (d *itDemangler) encoding() string {
+	switch {
+	case d.peek() == 'T' || d.peek() == 'G':
+		return d.specialName()
+	}
+
+	name := d.name()
+	if d.pos == len(d.text) || d.peek() == 'E' || d.peek() == '.' || strings.HasPrefix(d.text[d.pos:], "_block_invoke") {
+		// data
+		return name
+	}
+
+	template, ctor, cv := d.template, d.ctor, d.cv
+
+	if template && !ctor {
+		targs := d.targs
+		ret := d.typ()
+		d.targs = targs
+		return ret.decl(name + "(" + itList(d.params()) + ")" + cv)
+	}
+	return name + "(" + itList(d.params()) + ")" + cv
+}
+
+// params returns the parameter types of a function type.
+func (d *itDemangler) params() []*itType {
+	if d.nextIf("v") {
+		return nil
+	}
+	targs := d.targs
+	var ps []*itType
+	for {
+		rest := d.text[d.pos:]
+		if rest == "" || rest[0] == 'E' || rest[0] == '.' || strings.HasPrefix(rest, "RE") || strings.HasPrefix(rest, "OE") || strings.HasPrefix(rest, "_block_invoke") {
+			break
+		}
+		ps = append(ps, d.typ())
+		d.targs = targs
+	}
+	if len(ps) == 0 {
+		d.fail()
+	}
+	return ps
+}
+
+func (d *itDemangler) callOffset() {
+	switch d.next() {
+	case 'h':
+		d.nextIf("n")
+		d.number()
+		d.expect('_')
+	case 'v':
+		d.nextIf("n")
+		d.number()
+		d.expect('_')
+		d.nextIf("n")
+		d.number()
+		d.expect('_')
+	default:
+		d.fail()
+	}
+}
+
+func (d *itDemangler) specialName() string {
+	switch {
+	case d.nextIf("TV"):
+		return "vtable for " + d.typ().String()
+	case d.nextIf("TT"):
+		return "VTT for " + d.typ().String()
+	case d.nextIf("TI"):
+		return "typeinfo for " + d.typ().String()
+	case d.nextIf("TS"):
+		return "typeinfo name for " + d.typ().String()
+	case d.nextIf("TW"):
+		return "thread-local wrapper routine for " + d.name()
+	case d.nextIf("TH"):
+		return "thread-local initialization routine for " + d.name()
+	case d.nextIf("Th"):
+		d.pos--
+		d.callOffset()
+		return "non-virtual thunk to " + d.encoding()
+	case d.nextIf("Tv"):
+		d.pos--
+		d.callOffset()
+		return "virtual thunk to " + d.encoding()
+	case d.nextIf("Tc"):
+		d.callOffset()
+		d.callOffset()
+		return "covariant return thunk to " + d.encoding()
+	case d.nextIf("GV"):
+		return "guard variable for " + d.name()
+	case d.nextIf("GR"):
+		name := d.name()
+		if !d.nextIf("_") {
+			d.seqID()
+		}
+		return "reference temporary for " + name
+	case d.nextIf("GTt"):
+		return "transaction clone for " + d.encoding()
+	}
+	d.fail()
+	return ""
+}
+
+// name returns a nested, unscoped, or local name.
+func (d *itDemangler) name() string {
+	d.template, d.ctor, d.conv, d.cv = false, false, false, ""
+
+	switch c := d.peek(); {
+	case c == 'N':
+		return d.nestedName()
+	case c == 'Z':
+		return d.localName()
+	case c == 'S' && d.peekAt(1) != 't':
+		// a substituted template name
+		s := d.substitution()
+		if d.peek() != 'I' {
+			d.fail()
+		}
+		args := d.templateArgs()
+		d.template = true
+		return s.String() + args
+	}
+
+	var name string
+	if d.nextIf("St") {
+		name = "std::"
+	}
+	name += d.unqualifiedName()
+	if d.peek() == 'I' {
+		d.subst = append(d.subst, itName(name))
+		name += d.templateArgs()
+		d.template = true
+	}
+	return name
+}
+
+// cvQualifiers returns the qualifiers mangled as "r V K" in the order of c++filt.
+func (d *itDemangler) cvQualifiers() string {
+	r, v, k := d.nextIf("r"), d.nextIf("V"), d.nextIf("K")
+	var s string
+	if k {
+		s += " const"
+	}
+	if v {
+		s += " volatile"
+	}
+	if r {
+		s += " restrict"
+	}
+	return s
+}
+
+func (d *itDemangler) nestedName() string {
+	d.expect('N')
+	cv := d.cvQualifiers()
+	switch {
+	case d.nextIf("R"):
+		cv += " &"
+	case d.nextIf("O"):
+		cv += " &&"
+	}
+
+	var name string
+	template, ctor := false, false
+	push := func(s string) {
+		if name != "" && !strings.HasPrefix(s, "<") {
+			name += "::"
+		}
+		name += s
+	}
+
+	for !d.nextIf("E") {
+		if d.peek() != 'I' {
+			template, ctor = false, false
+		}
+		switch c := d.peek(); {
+		case c == 'S' && d.peekAt(1) != 't':
+			push(d.substitution().String())
+			continue
+		case c == 'S':
+			// std:: isn't a substitution candidate
+			d.pos += 2
+			push("std")
+			continue
+		case c == 'I':
+			if name == "" {
+				d.fail()
+			}
+			args := d.templateArgs()
+			name += args
+			template = true
+		case c == 'T':
+			push(d.templateParam().String())
+		case c == 'C' || c == 'D' && d.peekAt(1) != 't' && d.peekAt(1) != 'T' && d.peekAt(1) != 'C':
+			push(d.ctorDtorName(name) + d.abiTags())
+			ctor = true
+		default:
+			d.conv = false
+			push(d.unqualifiedName())
+			ctor = d.conv
+		}
+		d.subst = append(d.subst, itName(name))
+	}
+	if name == "" || len(d.subst) == 0 {
+		d.fail()
+	}
+	d.subst = d.subst[:len(d.subst)-1]
+
+	d.template, d.ctor, d.cv = template, ctor, cv
+	return name
+}
+
+func (d *itDemangler) localName() string {
+	d.expect('Z')
+	enc := d.encoding()
+	d.expect('E')
+	if d.nextIf("s") {
+		d.discriminator()
+		d.template, d.ctor, d.cv = false, false, ""
+		return enc + "::string literal"
+	}
+	targs := d.targs
+	name := d.name()
+	d.targs = targs
+	d.discriminator()
+	return enc + "::" + name
+}
+
+func (d *itDemangler) discriminator() {
+	if d.peek() != '_' {
+		return
+	}
+	d.pos++
+	if d.nextIf("_") {
+		d.number()
+		d.expect('_')
+		return
+	}
+	if !isDigit(d.peek()) {
+		d.fail()
+	}
+	d.pos++
+}
+
+var itStdBaseNames = map[string]string{
+	"string":   "basic_string",
+	"istream":  "basic_istream",
+	"ostream":  "basic_ostream",
+	"iostream": "basic_iostream",
+}
+
+// ctorDtorName returns the name of the constructor or the destructor of the class.
+func (d *itDemangler) ctorDtorName(class string) string {
+	// strip template arguments
+	if strings.HasSuffix(class, ">") {
+		depth := 0
+		for i := len(class) - 1; i >= 0; i-- {
+			switch class[i] {
+			case '>':
+				depth++
+			case '<':
+				depth--
+			}
+			if depth == 0 {
+				class = class[:i]
+				break
+			}
+		}
+	}
+	base := class[strings.LastIndex(class, ":")+1:]
+	if s, ok := itStdBaseNames[base]; ok && strings.HasPrefix(class, "std::") {
+		base = s
+	}
+	switch c := d.next(); c {
+	case 'C':
+		if d.nextIf("I") {
+			d.next()
+			d.typ() // inheriting constructor
+			return base
+		}
+		switch d.next() {
+		case '1', '2', '3', '4', '5':
+			return base
+		}
+	case 'D':
+		switch d.next() {
+		case '0', '1', '2', '4', '5':
+			return "~" + base
+		}
+	}
+	d.fail()
+	return ""
+}
+
+func (d *itDemangler) unqualifiedName() string {
+	var s string
+	switch c := d.peek(); {
+	case isDigit(c):
+		s = d.sourceName()
+	case c == 'U':
+		s = d.unnamedTypeName()
+	case c == 'D' && d.peekAt(1) == 'C':
+		// structured bindings
+		d.pos += 2
+		var names []string
+		for !d.nextIf("E") {
+			names = append(names, d.sourceName())
+		}
+		s = "[" + strings.Join(names, ", ") + "]"
+	case c == 'L':
+		// internal linkage
+		d.pos++
+		s = d.sourceName()
+		d.discriminator()
+	default:
+		s = d.operatorName()
+	}
+	return s + d.abiTags()
+}
+
+func (d *itDemangler) abiTags() string {
+	var s string
+	for d.nextIf("B") {
+		s += "[abi:" + d.sourceName() + "]"
+	}
+	return s
+}
+
+func (d *itDemangler) sourceName() string {
+	n := d.number()
+	if len(d.text) < d.pos+n {
+		d.fail()
+	}
+	s := d.text[d.pos : d.pos+n]
+	d.pos += n
+	if strings.HasPrefix(s, "_GLOBAL__N") {
+		return "(anonymous namespace)"
+	}
+	return s
+}
+
+func (d *itDemangler) unnamedTypeName() string {
+	switch {
+	case d.nextIf("Ut"):
+		n := 1
+		if d.peek() != '_' {
+			n = d.number() + 2
+		}
+		d.expect('_')
+		return fmt.Sprintf("{unnamed type#%d}", n)
+	case d.nextIf("Ul"):
+		var ps []string
+		for !d.nextIf("E") {
+			ps = append(ps, d.typ().String())
+		}
+		if len(ps) == 1 && ps[0] == "void" {
+			ps = nil
+		}
+		n := 1
+		if d.peek() != '_' {
+			n = d.number() + 2
+		}
+		d.expect('_')
+		return fmt.Sprintf("{lambda(%s)#%d}", strings.Join(ps, ", "), n)
+	}
+	d.fail()
+	return ""
+}
+
+var itOperators = map[string]string{
+	"nw": "new", "na": "new[]", "dl": "delete", "da": "delete[]",
+	"ps": "+", "ng": "-", "ad": "&", "de": "*", "co": "~",
+	"pl": "+", "mi": "-", "ml": "*", "dv": "/", "rm": "%",
+	"an": "&", "or": "|", "eo": "^", "aS": "=",
+	"pL": "+=", "mI": "-=", "mL": "*=", "dV": "/=", "rM": "%=",
+	"aN": "&=", "oR": "|=", "eO": "^=",
+	"ls": "<<", "rs": ">>", "lS": "<<=", "rS": ">>=",
+	"eq": "==", "ne": "!=", "lt": "<", "gt": ">", "le": "<=", "ge": ">=", "ss": "<=>",
+	"nt": "!", "aa": "&&", "oo": "||", "pp": "++", "mm": "--",
+	"cm": ",", "pm": "->*", "pt": "->", "cl": "()", "ix": "[]", "qu": "?",
+	"aw": "co_await",
+}
+
+func (d *itDemangler) operatorName() string {
+	if d.pos+2 > len(d.text) {
+		d.fail()
+	}
+	code := d.text[d.pos : d.pos+2]
+	d.pos += 2
+	switch code {
+	case "cv":
+		t := d.typ()
+		d.conv = true
+		return "operator " + t.String()
+	case "li":
+		return `operator"" ` + d.sourceName()
+	}
+	if code[0] == 'v' && isDigit(code[1]) {
+		return "operator " + d.sourceName()
+	}
+	op, ok := itOperators[code]
+	if !ok {
+		d.fail()
+	}
+	if isLower(op[0]) {
+		return "operator " + op
+	}
+	return "operator" + op
+}
+
+func (d *itDemangler) templateArgs() string {
+	d.expect('I')
+	var args []*itType
+	for !d.nextIf("E") {
+		args = append(args, d.templateArg())
+	}
+	d.targs = args
+
+	s := itList(args)
+	if strings.HasSuffix(s, ">") {
+		s += " "
+	}
+	return "<" + s + ">"
+}
+
+func (d *itDemangler) templateArg() *itType {
+	switch d.peek() {
+	case 'L':
+		return itName(d.exprPrimary())
+	case 'X':
+		d.fail() // TODO expressions
+	case 'J':
+		d.pos++
+		pack := &itType{kind: itPackType}
+		targs := d.targs
+		for !d.nextIf("E") {
+			pack.elems = append(pack.elems, d.templateArg())
+		}
+		d.targs = targs
+		return pack
+	}
+	targs := d.targs
+	t := d.typ()
+	d.targs = targs
+	return t
+}
+
+var itLiteralSuffixes = map[byte]string{
+	'j': "u", 'l': "l", 'm': "ul", 'x': "ll", 'y': "ull",
+}
+
+func (d *itDemangler) exprPrimary() string {
+	d.expect('L')
+	if d.nextIf("_Z") {
+		targs := d.targs
+		s := d.encoding()
+		d.targs = targs
+		d.expect('E')
+		return s
+	}
+
+	c := d.peek()
+	switch c {
+	case 'b':
+		d.pos++
+		var s string
+		switch d.next() {
+		case '0':
+			s = "false"
+		case '1':
+			s = "true"
+		default:
+			d.fail()
+		}
+		d.expect('E')
+		return s
+	case 'i', 'j', 'l', 'm', 'x', 'y', 's', 't', 'c', 'a', 'h':
+		d.pos++
+		neg := d.nextIf("n")
+		n := d.number()
+		d.expect('E')
+		s := fmt.Sprint(n)
+		if neg {
+			s = "-" + s
+		}
+		switch c {
+		case 'i':
+			return s
+		case 'j', 'l', 'm', 'x', 'y':
+			return s + itLiteralSuffixes[c]
+		}
+		return fmt.Sprintf("(%s)%s", itBuiltinTypes[c], s)
+	}
+
+	t := d.typ()
+	if d.nextIf("E") {
+		return t.String() // nullptr, etc.
+	}
+	neg := d.nextIf("n")
+	i := strings.IndexByte(d.text[d.pos:], 'E')
+	if i == -1 {
+		d.fail()
+	}
+	v := d.text[d.pos : d.pos+i]
+	d.pos += i + 1
+	if neg {
+		v = "-" + v
+	}
+	return fmt.Sprintf("(%s)%s", t, v)
+}
+
+func (d *itDemangler) templateParam() *itType {
+	d.expect('T')
+	i := d.seqID()
+	if len(d.targs) <= i {
+		d.fail()
+	}
+	return d.targs[i]
+}
+
+var itStdSubstitutions = map[byte]string{
+	'a': "std::allocator",
+	'b': "std::basic_string",
+	's': "std::string",
+	'i': "std::istream",
+	'o': "std::ostream",
+	'd': "std::iostream",
+}
+
+func (d *itDemangler) substitution() *itType {
+	d.expect('S')
+	if s, ok := itStdSubstitutions[d.peek()]; ok {
+		d.pos++
+		return itName(s)
+	}
+	i := d.seqID()
+	if len(d.subst) <= i {
+		d.fail()
+	}
+	return d.subst[i]
+}
+
+var itBuiltinTypes = map[byte]string{
+	'v': "void",
+	'w': "wchar_t",
+	'b': "bool",
+	'c': "char",
+	'a': "signed char",
+	'h': "unsigned char",
+	's': "short",
+	't': "unsigned short",
+	'i': "int",
+	'j': "unsigned int",
+	'l': "long",
+	'm': "unsigned long",
+	'x': "long long",
+	'y': "unsigned long long",
+	'n': "__int128",
+	'o': "unsigned __int128",
+	'f': "float",
+	'd': "double",
+	'e': "long double",
+	'g': "__float128",
+	'z': "...",
+}
+
+var itBuiltinDTypes = map[byte]string{
+	'd': "decimal64",
+	'e': "decimal128",
+	'f': "decimal32",
+	'h': "half",
+	'i': "char32_t",
+	's': "char16_t",
+	'u': "char8_t",
+	'a': "auto",
+	'c': "decltype(auto)",
+	'n': "std::nullptr_t",
+}
+
+func (d *itDemangler) typ() *itType {
+	c := d.peek()
+
+	if s, ok := itBuiltinTypes[c]; ok {
+		d.pos++
+		return itName(s)
+	}
+
+	var t *itType
+	switch c {
+	case 'u':
+		// unlike builtin types, vendor extended types are substitution candidates
+		d.pos++
+		t = itName(d.sourceName())
+	case 'D':
+		if s, ok := itBuiltinDTypes[d.peekAt(1)]; ok {
+			d.pos += 2
+			return itName(s)
+		}
+		switch d.peekAt(1) {
+		case 'p':
+			d.pos += 2
+			t = itExpand(d.typ())
+		case 'F':
+			d.pos += 2
+			n := d.number()
+			d.expect('_')
+			return itName(fmt.Sprintf("_Float%d", n))
+		case 'x', 'o', 'O', 'w':
+			t = d.functionType("")
+		default:
+			d.fail() // TODO decltype and vectors
+		}
+	case 'r', 'V', 'K':
+		cv := d.cvQualifiers()
+		if d.peek() == 'F' || d.peek() == 'D' && strings.IndexByte("xoOw", d.peekAt(1)) >= 0 {
+			t = d.functionType(cv)
+		} else {
+			t = &itType{kind: itQualType, text: cv, elems: []*itType{d.typ()}}
+		}
+	case 'P':
+		d.pos++
+		t = &itType{kind: itPointerType, text: "*", elems: []*itType{d.typ()}}
+	case 'R':
+		d.pos++
+		t = &itType{kind: itPointerType, text: "&", elems: []*itType{d.typ()}}
+	case 'O':
+		d.pos++
+		t = &itType{kind: itPointerType, text: "&&", elems: []*itType{d.typ()}}
+	case 'C':
+		d.pos++
+		t = itName(d.typ().String() + " _Complex")
+	case 'G':
+		d.pos++
+		t = itName(d.typ().String() + " _Imaginary")
+	case 'F':
+		t = d.functionType("")
+	case 'A':
+		d.pos++
+		var n string
+		if d.peek() != '_' {
+			n = fmt.Sprint(d.number())
+		}
+		d.expect('_')
+		t = &itType{kind: itArrayType, text: n, elems: []*itType{d.typ()}}
+	case 'M':
+		d.pos++
+		class := d.typ()
+		t = &itType{kind: itMemberType, elems: []*itType{class, d.typ()}}
+	case 'T':
+		t = d.templateParam()
+		d.subst = append(d.subst, t)
+		if d.peek() == 'I' {
+			t = itName(t.String() + d.templateArgs())
+		} else {
+			return t
+		}
+	case 'S':
+		if d.peekAt(1) == 't' {
+			t = itName(d.name())
+			break
+		}
+		t = d.substitution()
+		if d.peek() != 'I' {
+			return t
+		}
+		t = itName(t.String() + d.templateArgs())
+	case 'N', 'Z':
+		t = itName(d.name())
+	default:
+		if !isDigit(c) && c != 'U' {
+			d.fail()
+		}
+		t = itName(d.name())
+	}
+
+	d.subst = append(d.subst, t)
+	return t
+}
+
+// functionType returns the type of "[Dx] F [Y] <return type> <parameter types> [<ref-qualifier>] E".
+// cv is the qualifiers preceding the type.
+func (d *itDemangler) functionType(cv string) *itType {
+	var exc string
+	switch {
+	case d.nextIf("Dx"):
+		exc = " transaction_safe"
+	case d.nextIf("Do"):
+		exc = " noexcept"
+	}
+	d.expect('F')
+	d.nextIf("Y")
+	ret := d.typ()
+	params := d.params()
+	var ref string
+	switch {
+	case d.nextIf("RE"):
+		ref = " &"
+	case d.nextIf("OE"):
+		ref = " &&"
+	default:
+		d.expect('E')
+	}
+	return &itType{kind: itFunctionType, text: cv + ref + exc, elems: append([]*itType{ret}, params...)}
+}
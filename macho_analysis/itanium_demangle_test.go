@@ -0,0 +1,82 @@
+package macho_analysis
+
+import "testing"
+
+func TestItaniumDemangle(t *testing.T) {
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{"_Z3foov", "foo()"},
+		{"_ZNK3Foo3getEv", "Foo::get() const"},
+		{"_ZNVK1A1fEv", "A::f() const volatile"},
+		{"_ZNKR1A1fEv", "A::f() const &"},
+		{"_ZN3FooC1Ev", "Foo::Foo()"},
+		{"_ZN3FooD2Ev", "Foo::~Foo()"},
+		{"_ZN3FooplERKS_", "Foo::operator+(Foo const&)"},
+		{"_ZN1N1A1fERKS0_", "N::A::f(N::A const&)"},
+		{"_ZTV3Foo", "vtable for Foo"},
+		{"_ZThn8_N3Foo3barEv", "non-virtual thunk to Foo::bar()"},
+		{"_ZGVZ3foovE1x", "guard variable for foo()::x"},
+		{"_ZN12_GLOBAL__N_13fooEv", "(anonymous namespace)::foo()"},
+		{"_Z3maxIiET_S0_S0_", "int max<int>(int, int)"},
+		{"_Z1fILi3EEvv", "void f<3>()"},
+
+		// substitutions
+		{"_ZNKSt3__16vectorIiNS_9allocatorIiEEE4sizeEv", "std::__1::vector<int, std::__1::allocator<int> >::size() const"},
+		{"_ZNSt3__110unique_ptrI1ANS_14default_deleteIS1_EEED2Ev", "std::__1::unique_ptr<A, std::__1::default_delete<A> >::~unique_ptr()"},
+		{"_ZNSt3__16vectorIiNS_9allocatorIiEEEC2ESt16initializer_listIiE", "std::__1::vector<int, std::__1::allocator<int> >::vector(std::initializer_list<int>)"},
+		{"_Z1fRKSt6vectorIiSaIiEE", "f(std::vector<int, std::allocator<int> > const&)"},
+		{"_Z1fPKcS0_", "f(char const*, char const*)"},
+		{"_Z1fu3fooS_", "f(foo, foo)"},
+
+		// declarators
+		{"_Z1fPVKi", "f(int const volatile*)"},
+		{"_Z1fPrVKi", "f(int const volatile restrict*)"},
+		{"_Z1fPKPi", "f(int* const*)"},
+		{"_Z1fA3_i", "f(int [3])"},
+		{"_Z1fPA3_A4_i", "f(int (*) [3][4])"},
+		{"_Z1fRKA3_i", "f(int const (&) [3])"},
+		{"_Z1fPFvvE", "f(void (*)())"},
+		{"_Z1fPKFivE", "f(int (*)() const)"},
+		{"_Z1fKPFvvE", "f(void (* const)())"},
+		{"_Z1fPPFvvE", "f(void (**)())"},
+		{"_Z1fRPFvvE", "f(void (*&)())"},
+		{"_Z1fPFPivE", "f(int* (*)())"},
+		{"_Z1fPFPFivEvE", "f(int (*(*)())())"},
+		{"_Z1fPFPA3_ivE", "f(int (*(*)()) [3])"},
+		{"_Z1fPDoFvvE", "f(void (*)() noexcept)"},
+		{"_Z1fM1Ai", "f(int A::*)"},
+		{"_Z1fM1AFivE", "f(int (A::*)())"},
+		{"_Z1fM1AKFvvE", "f(void (A::*)() const)"},
+		{"_Z1fPM1AFvvE", "f(void (A::**)())"},
+		{"_Z1fCd", "f(double _Complex)"},
+
+		// return types
+		{"_Z1fIiEPiv", "int* f<int>()"},
+		{"_Z1fIiERKiv", "int const& f<int>()"},
+		{"_Z1fIiEPFvvEv", "void (*f<int>())()"},
+		{"_Z1fIiERA3_iv", "int (&f<int>()) [3]"},
+		{"_Z1fIiEM1Aiv", "int A::* f<int>()"},
+		{"_Z1fIiEM1AFvvEv", "void (A::*f<int>())()"},
+		{"_Z1fIFvvEEvv", "void f<void ()>()"},
+
+		// packs
+		{"_Z1fIJidEEvDpT_", "void f<int, double>(int, double)"},
+		{"_Z1fIJEEvDpT_", "void f<>()"},
+		{"_Z1fIiJEEvv", "void f<int>()"},
+		{"_Z1fIJiEEvDpRT_", "void f<int>(int&)"},
+		{"_Z1fIJicEEvDpPT_", "void f<int, char>(int*, char*)"},
+		{"_Z1fIiJcdEEvT_DpT0_", "void f<int, char, double>(int, char, double)"},
+	} {
+		out, ok := ItaniumDemangle(tt.in)
+		if !ok || out != tt.out {
+			t.Errorf("ItaniumDemangle(%q) = %q, %v; want %q", tt.in, out, ok, tt.out)
+		}
+	}
+
+	for _, in := range []string{"main", "_Z", "_ZN3foo", "_Z1fPA3_"} {
+		if out, ok := ItaniumDemangle(in); ok {
+			t.Errorf("ItaniumDemangle(%q) = %q; want failure", in, out)
+		}
+	}
+}
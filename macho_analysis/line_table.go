@@ -0,0 +1,163 @@
+package macho_analysis
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LineEntry is a row of the DWARF line table.
+type LineEntry struct {
+	Addr   uint64
+	File   string
+	Line   int
+	Column int
+
+	end bool // the first address after a sequence
+}
+
+func (e *LineEntry) String() string {
+	if e.Column != 0 {
+		return fmt.Sprintf("%s:%d:%d", filepath.Base(e.File), e.Line, e.Column)
+	}
+	return fmt.Sprintf("%s:%d", filepath.Base(e.File), e.Line)
+}
+
+type lineTable struct {
+	entries []LineEntry
+}
+
+// lineTable returns line tables of all compile units, which are merged and sorted by address.
+func (f *File) lineTable() *lineTable {
+	if f.lines != nil {
+		return f.lines
+	}
+
+	f.lines = new(lineTable)
+
+	if !f.HasDebugInfo() {
+		return f.lines
+	}
+
+	d, err := f.DWARF()
+	if err != nil {
+		// TODO warning
+		return f.lines
+	}
+
+	var entries []LineEntry
+
+	r := d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			// TODO warning
+			break
+		}
+		if e == nil {
+			break
+		}
+
+		lr, err := d.LineReader(e)
+		if err != nil {
+			// TODO warning
+		} else if lr != nil {
+			var le dwarf.LineEntry
+			for lr.Next(&le) == nil {
+				var file string
+				if le.File != nil {
+					file = le.File.Name
+				}
+				entries = append(entries, LineEntry{
+					Addr:   le.Address,
+					File:   file,
+					Line:   le.Line,
+					Column: le.Column,
+					end:    le.EndSequence,
+				})
+			}
+		}
+
+		r.SkipChildren()
+	}
+
+	// sequences may be adjacent, so the end of a sequence precedes the start of the next one
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Addr != entries[j].Addr {
+			return entries[i].Addr < entries[j].Addr
+		}
+		return entries[i].end && !entries[j].end
+	})
+
+	f.lines.entries = entries
+
+	return f.lines
+}
+
+// lookup returns the row covering addr, or nil if there is nothing.
+func (t *lineTable) lookup(addr uint64) *LineEntry {
+	i := sort.Search(len(t.entries), func(i int) bool {
+		return addr < t.entries[i].Addr
+	})
+	if i == 0 || t.entries[i-1].end {
+		return nil
+	}
+	return &t.entries[i-1]
+}
+
+// LineAt returns the source position of the instruction at addr, or nil if there is no debug info.
+func (f *File) LineAt(addr uint64) *LineEntry {
+	return f.lineTable().lookup(addr)
+}
+
+// SourceLine returns the line of the source file, which is read from the disk.
+//
+// If SourceRoot isn't empty, the file is looked up under SourceRoot instead,
+// by stripping leading directories until the file is found.
+// e.g. /build/src/hello.c is looked up as SourceRoot/build/src/hello.c, SourceRoot/src/hello.c and SourceRoot/hello.c.
+func (f *File) SourceLine(file string, line int) (string, bool) {
+	if f.sources == nil {
+		f.sources = make(map[string][]string)
+	}
+
+	lines, ok := f.sources[file]
+	if !ok {
+		if data, err := ioutil.ReadFile(f.sourcePath(file)); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		f.sources[file] = lines
+	}
+
+	if line < 1 || len(lines) < line {
+		return "", false
+	}
+
+	return strings.TrimRight(lines[line-1], " \t\r"), true
+}
+
+func (f *File) sourcePath(file string) string {
+	if f.SourceRoot == "" {
+		return file
+	}
+
+	rel := filepath.ToSlash(file)
+	for {
+		rel = strings.TrimLeft(rel, "/")
+		if rel == "" {
+			return file
+		}
+		path := filepath.Join(f.SourceRoot, filepath.FromSlash(rel))
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		i := strings.IndexByte(rel, '/')
+		if i == -1 {
+			return file
+		}
+		rel = rel[i:]
+	}
+}
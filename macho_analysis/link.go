@@ -0,0 +1,485 @@
+package macho_analysis
+
+import (
+	"debug/macho"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// LinkLayout is the layout where an object file is linked virtually.
+type LinkLayout struct {
+	Base uint64 // the address of the first section
+}
+
+// DefaultLinkLayout returns the layout at the usual base address of executables.
+func (f *File) DefaultLinkLayout() LinkLayout {
+	if f.pointerSize() == 8 {
+		return LinkLayout{Base: 0x100000000}
+	}
+	return LinkLayout{Base: 0x1000}
+}
+
+// LinkedSectionData is SectionData of sect in the object file linked with layout.
+// Addresses are in the layout and the bytes patched by relocations are marked.
+// Only "Code", "Source", "Pointer32", "Pointer64" and "Data" are linked, other types are the same as SectionData.
+func (f *File) LinkedSectionData(typ string, sect *macho.Section, layout LinkLayout) *DataTree {
+	if f.Type != macho.TypeObj {
+		return f.SectionData(typ, sect)
+	}
+
+	l := f.link(layout)
+
+	valueFunc := l.valueFunc(typ)
+	if valueFunc == nil {
+		return f.SectionData(typ, sect)
+	}
+
+	t := f.newSectionData(sect, valueFunc, false)
+	if t.idx == nil {
+		return t
+	}
+
+	ls := l.sect(sect)
+	t.idx.data = ls.data
+	t.idx.patched = ls.patched
+	t.idx.slide = l.slide
+
+	if typ == "Code" || typ == "Source" {
+		return t.withLines(typ == "Source")
+	}
+	return t
+}
+
+// LinkedSymbolData is SymbolData of sym in the object file linked with layout.
+// See LinkedSectionData for the linked types.
+func (f *File) LinkedSymbolData(typ string, sym *macho.Symbol, layout LinkLayout) *DataTree {
+	if f.Type != macho.TypeObj || sym.Type&N_STAB != 0 || SymbolType(sym.Type&N_TYPE) != N_SECT {
+		return f.SymbolData(typ, sym)
+	}
+
+	l := f.link(layout)
+
+	valueFunc := l.valueFunc(typ)
+	if valueFunc == nil {
+		return f.SymbolData(typ, sym)
+	}
+
+	t := f.newSymbolData(sym, valueFunc, false)
+	if t == nil || t.idx == nil {
+		return t
+	}
+
+	sect := f.Sections[sym.Sect-1]
+	ls := l.sect(sect)
+	off := sym.Value - sect.Addr
+	if off+uint64(len(t.idx.data)) > uint64(len(ls.data)) {
+		// TODO warning
+		return t
+	}
+	t.idx.data = ls.data[off : off+uint64(len(t.idx.data))]
+	t.idx.patched = ls.patched[off : off+uint64(len(t.idx.data))]
+	t.idx.slide = l.slide
+
+	if typ == "Code" || typ == "Source" {
+		return t.withLines(typ == "Source")
+	}
+	return t
+}
+
+// linkImage is an object file linked virtually. Sections are slid to the base address and undefined
+// symbols are placed in slots after the sections, which serve as their stubs and GOT entries.
+type linkImage struct {
+	f        *File
+	layout   LinkLayout
+	slide    uint64
+	addrs    []uint64       // sorted addresses of SymInfos
+	slots    map[int]uint64 // symbol number -> slot address
+	slotSyms map[uint64]int // slot address -> symbol number
+	sects    map[*macho.Section]*linkSection
+}
+
+// linkSection is the data of a section, where relocations are applied.
+type linkSection struct {
+	data    []byte
+	patched []bool
+}
+
+// link returns the image linked with layout. The last image is cached.
+func (f *File) link(layout LinkLayout) *linkImage {
+	if f.linked != nil && f.linked.layout == layout {
+		return f.linked
+	}
+
+	l := &linkImage{
+		f:        f,
+		layout:   layout,
+		slots:    make(map[int]uint64),
+		slotSyms: make(map[uint64]int),
+		sects:    make(map[*macho.Section]*linkSection),
+	}
+
+	var lo, hi uint64 = math.MaxUint64, 0
+	for _, sect := range f.Sections {
+		if sect.Addr < lo {
+			lo = sect.Addr
+		}
+		if sect.Addr+sect.Size > hi {
+			hi = sect.Addr + sect.Size
+		}
+	}
+	if lo > hi {
+		lo = 0
+	}
+	l.slide = layout.Base - lo
+
+	slot := (hi + l.slide + 0xfff) &^ 0xfff
+	for i, sym := range f.Syms {
+		if sym.Type&N_STAB != 0 || SymbolType(sym.Type&N_TYPE) != N_UNDF {
+			continue
+		}
+		l.slots[i] = slot
+		l.slotSyms[slot] = i
+		slot += f.pointerSize()
+	}
+
+	for addr := range f.SymInfos {
+		l.addrs = append(l.addrs, addr)
+	}
+	sort.Slice(l.addrs, func(i, j int) bool { return l.addrs[i] < l.addrs[j] })
+
+	f.linked = l
+
+	return l
+}
+
+// valueFunc returns the valueFunc of typ in the image, or nil if typ isn't linked.
+func (l *linkImage) valueFunc(typ string) func(data []byte, addr uint64) (string, int) {
+	switch typ {
+	case "Code", "Source":
+		return l.f.disasmFuncWith(l.lookup)
+	case "Pointer32":
+		return l.pointerFunc(4)
+	case "Pointer64":
+		return l.pointerFunc(8)
+	case "Data":
+		return func(data []byte, addr uint64) (string, int) {
+			size := 8
+			if len(data) < 8 {
+				size = len(data)
+			}
+			return l.f.toASCII(data[:size]), size
+		}
+	}
+	return nil
+}
+
+// pointerFunc returns the valueFunc of pointers of size, which shows the symbols at the pointers.
+func (l *linkImage) pointerFunc(size int) func(data []byte, addr uint64) (string, int) {
+	return func(data []byte, addr uint64) (string, int) {
+		if len(data) < size {
+			return "", len(data)
+		}
+		var paddr uint64
+		if size == 4 {
+			paddr = uint64(l.f.ByteOrder.Uint32(data))
+		} else {
+			paddr = l.f.ByteOrder.Uint64(data)
+		}
+		if s, base := l.lookup(paddr); s != "" {
+			if base != paddr {
+				s += fmt.Sprintf("%+x", paddr-base)
+			}
+			return fmt.Sprintf("%#016x (%s)", paddr, s), size
+		}
+		return fmt.Sprintf("%#016x", paddr), size
+	}
+}
+
+// lookup looks up the symbol containing addr in the image, including undefined symbols in their slots.
+func (l *linkImage) lookup(addr uint64) (string, uint64) {
+	if i, ok := l.slotSyms[addr]; ok {
+		return l.f.symName(l.f.Syms[i].Name), addr
+	}
+
+	oaddr := addr - l.slide
+	j := sort.Search(len(l.addrs), func(i int) bool {
+		return oaddr < l.addrs[i]
+	})
+	if j == 0 {
+		return "", 0
+	}
+	base := l.addrs[j-1]
+	info := l.f.SymInfos[base]
+	if base+info.Size <= oaddr {
+		return "", 0
+	}
+	ss := make([]string, len(info.SymbolIndices))
+	for i, si := range info.SymbolIndices {
+		ss[i] = l.f.symName(l.f.Syms[si].Name)
+	}
+	return strings.Join(ss, "|"), base + l.slide
+}
+
+// sect returns the data of s, where the relocations of s are applied.
+func (l *linkImage) sect(s *macho.Section) *linkSection {
+	if ls := l.sects[s]; ls != nil {
+		return ls
+	}
+
+	f := l.f
+
+	ls := &linkSection{patched: make([]bool, s.Size)}
+	l.sects[s] = ls
+
+	if f.isZeroSect(s) {
+		ls.data = make([]byte, s.Size)
+		return ls
+	}
+
+	data, err := s.Data()
+	if err != nil {
+		// TODO warning
+		ls.data = make([]byte, s.Size)
+		return ls
+	}
+	ls.data = data
+
+	for i, r := range s.Relocs {
+		if i > 0 && isRelocPair(f.Cpu, s.Relocs[i-1], r) {
+			continue
+		}
+		off := uint64(r.Addr)
+		size := relocSize(f.Cpu, r)
+		if off+size > uint64(len(data)) {
+			// TODO warning
+			continue
+		}
+		b := data[off : off+size]
+		_, t := f.relocDataString(s, i, 0, b)
+		if t == nil || !l.apply(r, t, b, s.Addr+off+l.slide) {
+			continue
+		}
+		for j := off; j < off+size; j++ {
+			ls.patched[j] = true
+		}
+	}
+
+	// symbol pointers are bound to the symbols in the indirect symbol table
+	switch SectionType(s.Flags & SECTION_TYPE) {
+	case S_NON_LAZY_SYMBOL_POINTERS, S_LAZY_SYMBOL_POINTERS, S_LAZY_DYLIB_SYMBOL_POINTERS:
+		size := f.pointerSize()
+		for off := uint64(0); off+size <= uint64(len(data)); off += size {
+			i, _, _ := f.indirectSlot(s.Addr + off)
+			if i == -1 {
+				continue
+			}
+			if addr, ok := l.symAddr(i); ok && l.put(data[off:off+size], addr) {
+				for j := off; j < off+size; j++ {
+					ls.patched[j] = true
+				}
+			}
+		}
+	}
+
+	return ls
+}
+
+// symAddr returns the address of the symbol i in the image.
+func (l *linkImage) symAddr(i int) (uint64, bool) {
+	if addr, ok := l.slots[i]; ok {
+		return addr, true
+	}
+	if i < 0 || i >= len(l.f.Syms) {
+		return 0, false
+	}
+	sym := &l.f.Syms[i]
+	switch SymbolType(sym.Type & N_TYPE) {
+	case N_SECT:
+		return sym.Value + l.slide, true
+	case N_ABS:
+		return sym.Value, true
+	}
+	return 0, false
+}
+
+// targetAddr returns the address of t in the image.
+func (l *linkImage) targetAddr(t *RelocTarget) (uint64, bool) {
+	addr := t.Symaddr + l.slide
+	if t.Symnum != -1 {
+		var ok bool
+		if addr, ok = l.symAddr(t.Symnum); !ok {
+			return 0, false
+		}
+	}
+	addr = addAddend(addr, t.Addend)
+	if t.Sub != nil {
+		sub, ok := l.targetAddr(t.Sub)
+		if !ok {
+			return 0, false
+		}
+		addr -= sub
+	}
+	return addr, true
+}
+
+// apply writes the target t of r to b, which is located at addr in the image.
+// It reports whether r is applied.
+func (l *linkImage) apply(r macho.Reloc, t *RelocTarget, b []byte, addr uint64) bool {
+	target, ok := l.targetAddr(t)
+	if !ok {
+		return false
+	}
+
+	switch l.f.Cpu {
+	case macho.Cpu386:
+		switch macho.RelocTypeGeneric(r.Type) {
+		case macho.GENERIC_RELOC_VANILLA, macho.GENERIC_RELOC_TLV:
+			if r.Pcrel {
+				target -= addr + uint64(len(b))
+			}
+			return l.put(b, target)
+		case macho.GENERIC_RELOC_SECTDIFF, macho.GENERIC_RELOC_LOCAL_SECTDIFF:
+			// the difference doesn't depend on the layout
+			return true
+		case macho.GENERIC_RELOC_PB_LA_PTR:
+			return l.put(b, target)
+		}
+	case macho.CpuAmd64:
+		if r.Pcrel {
+			// the displacement is relative to the end of the instruction, which may have an immediate
+			target -= addr + uint64(len(b))
+			switch macho.RelocTypeX86_64(r.Type) {
+			case macho.X86_64_RELOC_SIGNED_1:
+				target--
+			case macho.X86_64_RELOC_SIGNED_2:
+				target -= 2
+			case macho.X86_64_RELOC_SIGNED_4:
+				target -= 4
+			}
+		}
+		return l.put(b, target)
+	case macho.CpuArm:
+		return l.applyARM(r, target, b, addr)
+	case macho.CpuArm | 0x01000000:
+		return l.applyARM64(r, target, b, addr)
+	}
+
+	return false
+}
+
+// applyARM is apply for CpuArm.
+func (l *linkImage) applyARM(r macho.Reloc, target uint64, b []byte, addr uint64) bool {
+	bo := l.f.ByteOrder
+
+	switch macho.RelocTypeARM(r.Type) {
+	case macho.ARM_RELOC_VANILLA:
+		if r.Pcrel {
+			target -= addr + 8
+		}
+		return l.put(b, target)
+	case macho.ARM_RELOC_SECTDIFF, macho.ARM_RELOC_LOCAL_SECTDIFF, macho.ARM_RELOC_HALF_SECTDIFF:
+		// the difference doesn't depend on the layout
+		return true
+	case macho.ARM_RELOC_PB_LA_PTR:
+		return l.put(b, target)
+	case macho.ARM_RELOC_BR24:
+		inst := bo.Uint32(b)
+		disp := uint32(target - (addr + 8))
+		inst = inst&^0xffffff | disp>>2&0xffffff
+		if inst>>28 == 0xf { // BLX
+			inst = inst&^(1<<24) | (disp>>1&1)<<24
+		}
+		bo.PutUint32(b, inst)
+		return true
+	case macho.ARM_THUMB_RELOC_BR22:
+		hw1 := uint32(bo.Uint16(b))
+		hw2 := uint32(bo.Uint16(b[2:]))
+		pc := addr + 4
+		if hw2&0x1000 == 0 { // BLX
+			pc &^= 3
+		}
+		disp := uint32(target - pc)
+		sign := disp >> 24 & 1
+		j1 := ^(disp>>23 ^ sign) & 1
+		j2 := ^(disp>>22 ^ sign) & 1
+		hw1 = hw1&^0x7ff | sign<<10 | disp>>12&0x3ff
+		hw2 = hw2&^0x2fff | j1<<13 | j2<<11 | disp>>1&0x7ff
+		bo.PutUint16(b, uint16(hw1))
+		bo.PutUint16(b[2:], uint16(hw2))
+		return true
+	case macho.ARM_RELOC_HALF:
+		half := uint32(target) & 0xffff
+		if r.Len&1 != 0 {
+			half = uint32(target) >> 16
+		}
+		if r.Len&2 != 0 { // thumb
+			hw1 := uint32(bo.Uint16(b))
+			hw2 := uint32(bo.Uint16(b[2:]))
+			hw1 = hw1&^0x40f | half>>12 | (half>>11&1)<<10
+			hw2 = hw2&^0x70ff | (half>>8&7)<<12 | half&0xff
+			bo.PutUint16(b, uint16(hw1))
+			bo.PutUint16(b[2:], uint16(hw2))
+		} else {
+			inst := bo.Uint32(b)
+			inst = inst&^0xf0fff | half>>12<<16 | half&0xfff
+			bo.PutUint32(b, inst)
+		}
+		return true
+	}
+
+	return false
+}
+
+// applyARM64 is apply for CpuArm64.
+func (l *linkImage) applyARM64(r macho.Reloc, target uint64, b []byte, addr uint64) bool {
+	bo := l.f.ByteOrder
+
+	switch macho.RelocTypeARM64(r.Type) {
+	case macho.ARM64_RELOC_UNSIGNED, macho.ARM64_RELOC_SUBTRACTOR:
+		return l.put(b, target)
+	case macho.ARM64_RELOC_POINTER_TO_GOT:
+		if r.Pcrel {
+			target -= addr
+		}
+		return l.put(b, target)
+	case macho.ARM64_RELOC_BRANCH26:
+		inst := bo.Uint32(b)
+		inst = inst&^0x3ffffff | uint32(target-addr)>>2&0x3ffffff
+		bo.PutUint32(b, inst)
+		return true
+	case macho.ARM64_RELOC_PAGE21, macho.ARM64_RELOC_GOT_LOAD_PAGE21, macho.ARM64_RELOC_TLVP_LOAD_PAGE21:
+		// ADRP
+		inst := bo.Uint32(b)
+		disp := uint32((target&^0xfff - addr&^0xfff) >> 12)
+		inst = inst&^(3<<29|0x7ffff<<5) | (disp&3)<<29 | (disp>>2&0x7ffff)<<5
+		bo.PutUint32(b, inst)
+		return true
+	case macho.ARM64_RELOC_PAGEOFF12, macho.ARM64_RELOC_GOT_LOAD_PAGEOFF12, macho.ARM64_RELOC_TLVP_LOAD_PAGEOFF12:
+		inst := bo.Uint32(b)
+		scale, _ := arm64PageOffScale(inst)
+		inst = inst&^(0xfff<<10) | (uint32(target&0xfff)>>scale&0xfff)<<10
+		bo.PutUint32(b, inst)
+		return true
+	}
+
+	return false
+}
+
+// put writes v to b in the byte order of the file.
+func (l *linkImage) put(b []byte, v uint64) bool {
+	switch len(b) {
+	case 1:
+		b[0] = byte(v)
+	case 2:
+		l.f.ByteOrder.PutUint16(b, uint16(v))
+	case 4:
+		l.f.ByteOrder.PutUint32(b, uint32(v))
+	case 8:
+		l.f.ByteOrder.PutUint64(b, v)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,647 @@
+package macho_analysis
+
+// reference:
+// https://opensource.apple.com/source/objc4/objc4-818.2/runtime/objc-runtime-new.h
+// https://opensource.apple.com/source/objc4/objc4-818.2/runtime/objc-abi.h
+
+import (
+	"bytes"
+	"debug/macho"
+	"fmt"
+	"strings"
+)
+
+const (
+	// class_ro_t flags
+
+	RO_META                       = 1 << 0
+	RO_ROOT                       = 1 << 1
+	RO_HAS_CXX_STRUCTORS          = 1 << 2
+	RO_HIDDEN                     = 1 << 4
+	RO_EXCEPTION                  = 1 << 5
+	RO_HAS_SWIFT_INITIALIZER      = 1 << 6
+	RO_IS_ARC                     = 1 << 7
+	RO_HAS_CXX_DTOR_ONLY          = 1 << 8
+	RO_HAS_WEAK_WITHOUT_ARC       = 1 << 9
+	RO_FORBIDS_ASSOCIATED_OBJECTS = 1 << 10
+
+	// method_list_t flags
+
+	objcSmallMethodListFlag = 0x80000000
+	objcDirectSelectorsFlag = 0x40000000
+	objcMethodListFlagMask  = 0xffff0003
+)
+
+var objcROFlagNames = []struct {
+	flag uint32
+	name string
+}{
+	{RO_META, "RO_META"},
+	{RO_ROOT, "RO_ROOT"},
+	{RO_HAS_CXX_STRUCTORS, "RO_HAS_CXX_STRUCTORS"},
+	{RO_HIDDEN, "RO_HIDDEN"},
+	{RO_EXCEPTION, "RO_EXCEPTION"},
+	{RO_HAS_SWIFT_INITIALIZER, "RO_HAS_SWIFT_INITIALIZER"},
+	{RO_IS_ARC, "RO_IS_ARC"},
+	{RO_HAS_CXX_DTOR_ONLY, "RO_HAS_CXX_DTOR_ONLY"},
+	{RO_HAS_WEAK_WITHOUT_ARC, "RO_HAS_WEAK_WITHOUT_ARC"},
+	{RO_FORBIDS_ASSOCIATED_OBJECTS, "RO_FORBIDS_ASSOCIATED_OBJECTS"},
+}
+
+var objcHeader = []string{"Address", "Kind", "Name", "Value"}
+
+// HasObjC reports whether f has metadata of the Objective-C 2.0 runtime.
+// The legacy runtime (the __OBJC segment) isn't supported.
+func (f *File) HasObjC() bool {
+	for _, sect := range f.Sections {
+		if strings.HasPrefix(sect.Name, "__objc_") {
+			return true
+		}
+	}
+	return false
+}
+
+// ObjC returns classes, categories, protocols and references of the Objective-C runtime.
+// Members of classes, categories and protocols are decoded when their children are requested.
+func (f *File) ObjC() *DataTree {
+	t := &DataTree{Header: objcHeader}
+
+	p := &objcParser{
+		f:       f,
+		ptrSize: f.pointerSize(),
+		data:    make(map[*macho.Section][]byte),
+		externs: make(map[*macho.Section]map[uint64]*macho.Symbol),
+	}
+
+	for _, list := range []struct {
+		sect string
+		name string
+		node func(addr uint64) *DataNode
+	}{
+		{"__objc_classlist", "Classes", p.classNode},
+		{"__objc_nlclslist", "Non-Lazy Classes", p.classNode},
+		{"__objc_catlist", "Categories", p.categoryNode},
+		{"__objc_nlcatlist", "Non-Lazy Categories", p.categoryNode},
+		{"__objc_protolist", "Protocols", p.protocolNode},
+	} {
+		sect := f.Section(list.sect)
+		if sect == nil {
+			continue
+		}
+		n := t.appendRow(Text(list.name), nil, nil, nil)
+		for addr := sect.Addr; addr+p.ptrSize <= sect.Addr+sect.Size; addr += p.ptrSize {
+			target, sym, ok := p.pointer(addr)
+			if !ok {
+				// TODO warning
+				break
+			}
+			if sym != "" {
+				n.appendRow(p.addrValue(addr), nil, Text(sym), nil)
+				continue
+			}
+			n.Children = append(n.Children, list.node(target))
+		}
+		n.Values[3] = Text(fmt.Sprintf("%d", len(n.Children)))
+	}
+
+	for _, refs := range []struct {
+		sect string
+		name string
+		kind string
+	}{
+		{"__objc_classrefs", "Class References", "Class"},
+		{"__objc_superrefs", "Super References", "Class"},
+		{"__objc_protorefs", "Protocol References", "Protocol"},
+		{"__objc_selrefs", "Selector References", "SEL"},
+	} {
+		sect := f.Section(refs.sect)
+		if sect == nil {
+			continue
+		}
+		kind := refs.kind
+		n := t.appendRow(Text(refs.name), nil, nil, Text(fmt.Sprintf("%d", sect.Size/p.ptrSize)))
+		n.childrenFunc = func() []*DataNode {
+			var nodes []*DataNode
+			for addr := sect.Addr; addr+p.ptrSize <= sect.Addr+sect.Size; addr += p.ptrSize {
+				nodes = append(nodes, p.refNode(addr, kind))
+			}
+			return nodes
+		}
+	}
+
+	return t
+}
+
+type objcParser struct {
+	f       *File
+	ptrSize uint64
+	data    map[*macho.Section][]byte
+	externs map[*macho.Section]map[uint64]*macho.Symbol // extern relocations of object files
+}
+
+func (p *objcParser) section(addr uint64) *macho.Section {
+	for _, sect := range p.f.Sections {
+		if sect.Addr <= addr && addr < sect.Addr+sect.Size && !p.f.isZeroSect(sect) {
+			return sect
+		}
+	}
+	return nil
+}
+
+// read returns n bytes at addr, or nil if addr isn't in any section.
+func (p *objcParser) read(addr, n uint64) []byte {
+	sect := p.section(addr)
+	if sect == nil {
+		return nil
+	}
+	data, ok := p.data[sect]
+	if !ok {
+		var err error
+		data, err = sect.Data()
+		if err != nil {
+			// TODO warning
+		}
+		p.data[sect] = data
+	}
+	off := addr - sect.Addr
+	if uint64(len(data)) < off+n {
+		return nil
+	}
+	return data[off : off+n]
+}
+
+func (p *objcParser) uint32(addr uint64) (uint32, bool) {
+	b := p.read(addr, 4)
+	if b == nil {
+		return 0, false
+	}
+	return p.f.ByteOrder.Uint32(b), true
+}
+
+func (p *objcParser) int32(addr uint64) (int32, bool) {
+	v, ok := p.uint32(addr)
+	return int32(v), ok
+}
+
+// pointer returns the target of the pointer at addr. If the pointer is bound to a symbol, sym is the name.
+func (p *objcParser) pointer(addr uint64) (target uint64, sym string, ok bool) {
+	if fx := p.f.Fixups.Lookup(addr); fx != nil {
+		if fx.Bind {
+			return 0, p.f.chainedImportString(p.f.Fixups, fx.Import), true
+		}
+		return fx.Target, "", true
+	}
+	if s, ok := p.f.bindSymbols()[addr]; ok {
+		return 0, s, true
+	}
+
+	b := p.read(addr, p.ptrSize)
+	if b == nil {
+		return 0, "", false
+	}
+	if p.ptrSize == 4 {
+		target = uint64(p.f.ByteOrder.Uint32(b))
+	} else {
+		target = p.f.ByteOrder.Uint64(b)
+	}
+
+	// the addend is in place
+	if sym := p.extern(addr); sym != nil {
+		if sym.Type&N_STAB == 0 && SymbolType(sym.Type&N_TYPE) == N_SECT {
+			return target + sym.Value, "", true
+		}
+		return target, sym.Name, true
+	}
+
+	return target, "", true
+}
+
+// extern returns the symbol of the extern relocation at addr, or nil.
+func (p *objcParser) extern(addr uint64) *macho.Symbol {
+	sect := p.section(addr)
+	if sect == nil || len(sect.Relocs) == 0 {
+		return nil
+	}
+	m, ok := p.externs[sect]
+	if !ok {
+		m = make(map[uint64]*macho.Symbol)
+		for _, r := range sect.Relocs {
+			if r.Extern && !r.Scattered {
+				if sym := p.f.symIndex(r.Value); sym != nil {
+					m[sect.Addr+uint64(r.Addr)] = sym
+				}
+			}
+		}
+		p.externs[sect] = m
+	}
+	return m[addr]
+}
+
+// field returns the pointer of i-th pointer sized field of the struct at addr, which follows hdr bytes.
+func (p *objcParser) field(addr, hdr uint64, i int) (target uint64, sym string, ok bool) {
+	return p.pointer(addr + hdr + uint64(i)*p.ptrSize)
+}
+
+func (p *objcParser) cstring(addr uint64) string {
+	sect := p.section(addr)
+	if sect == nil {
+		return ""
+	}
+	b := p.read(addr, sect.Addr+sect.Size-addr)
+	if i := bytes.IndexByte(b, 0); i != -1 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// stringAt returns the string pointed by the pointer at addr.
+func (p *objcParser) stringAt(addr uint64) string {
+	target, sym, ok := p.pointer(addr)
+	if !ok || target == 0 && sym != "" {
+		return sym
+	}
+	return p.cstring(target)
+}
+
+func (p *objcParser) addrValue(addr uint64) Value {
+	return Value{}.appendLink(fmt.Sprintf("%#016x", addr), addressLink(addr, 0))
+}
+
+// class_t is {isa, superclass, cache, vtable, data}
+// class_ro_t is {flags, instanceStart, instanceSize, [reserved,] ivarLayout, name, baseMethods, baseProtocols, ivars, weakIvarLayout, baseProperties}
+
+// classRO returns class_ro_t of the class at addr.
+func (p *objcParser) classRO(addr uint64) (uint64, bool) {
+	data, _, ok := p.field(addr, 0, 4)
+	if !ok || data == 0 {
+		return 0, false
+	}
+	// low bits are flags of Swift
+	if p.ptrSize == 4 {
+		data &^= 3
+	} else {
+		data &= 0x00007ffffffffff8
+	}
+	return data, true
+}
+
+func (p *objcParser) roHeaderSize() uint64 {
+	if p.ptrSize == 4 {
+		return 12
+	}
+	return 16
+}
+
+func (p *objcParser) className(addr uint64) string {
+	ro, ok := p.classRO(addr)
+	if !ok {
+		return ""
+	}
+	return p.stringAt(ro + p.roHeaderSize() + p.ptrSize)
+}
+
+// classRefValue returns the class pointed by the pointer at addr.
+func (p *objcParser) classRefValue(addr uint64) Value {
+	target, sym, ok := p.pointer(addr)
+	switch {
+	case !ok:
+		return nil
+	case sym != "":
+		return Text(objcClassName(sym))
+	case target == 0:
+		return Text("nil")
+	}
+	return Value{}.appendText(p.className(target)+" (").appendLink(fmt.Sprintf("%#x", target), addressLink(target, 0)).appendText(")")
+}
+
+// classRefName is like classRefValue, but returns only the name.
+func (p *objcParser) classRefName(addr uint64) string {
+	target, sym, ok := p.pointer(addr)
+	switch {
+	case !ok:
+		return ""
+	case sym != "":
+		return objcClassName(sym)
+	}
+	return p.className(target)
+}
+
+// objcClassName returns the class name of the symbol of class_t.
+func objcClassName(sym string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(sym, "_OBJC_CLASS_$_"), "_OBJC_METACLASS_$_")
+}
+
+func (p *objcParser) classNode(addr uint64) *DataNode {
+	name := p.className(addr)
+
+	n := &DataNode{Values: []Value{p.addrValue(addr), Text("Class"), Text(name), p.classRefValue(addr + p.ptrSize)}}
+
+	n.childrenFunc = func() []*DataNode {
+		var nodes []*DataNode
+
+		ro, ok := p.classRO(addr)
+		if !ok {
+			return nil
+		}
+
+		meta, msym, _ := p.field(addr, 0, 0)
+		if msym != "" {
+			nodes = append(nodes, &DataNode{Values: []Value{p.addrValue(addr), Text("Metaclass"), Text(msym), nil}})
+		} else if meta != 0 {
+			nodes = append(nodes, &DataNode{Values: []Value{p.addrValue(meta), Text("Metaclass"), Text(p.className(meta)), p.classRefValue(meta + p.ptrSize)}})
+		}
+
+		flags, _ := p.uint32(ro)
+		start, _ := p.uint32(ro + 4)
+		size, _ := p.uint32(ro + 8)
+		nodes = append(nodes,
+			&DataNode{Values: []Value{p.addrValue(ro), Text("Flags"), nil, Text(objcROFlagsString(flags))}},
+			&DataNode{Values: []Value{p.addrValue(ro + 4), Text("Instance Start"), nil, Text(fmt.Sprintf("%d", start))}},
+			&DataNode{Values: []Value{p.addrValue(ro + 8), Text("Instance Size"), nil, Text(fmt.Sprintf("%d", size))}},
+		)
+
+		hdr := p.roHeaderSize()
+		if ivars, _, _ := p.field(ro, hdr, 4); ivars != 0 {
+			nodes = append(nodes, p.ivarsNode(ivars))
+		}
+		if protos, _, _ := p.field(ro, hdr, 3); protos != 0 {
+			nodes = append(nodes, p.protocolsNode(protos))
+		}
+		if methods, _, _ := p.field(ro, hdr, 2); methods != 0 {
+			nodes = append(nodes, p.methodsNode(methods, "Instance Methods", "-"))
+		}
+		if meta != 0 && msym == "" {
+			if mro, ok := p.classRO(meta); ok {
+				if methods, _, _ := p.field(mro, hdr, 2); methods != 0 {
+					nodes = append(nodes, p.methodsNode(methods, "Class Methods", "+"))
+				}
+			}
+		}
+		if props, _, _ := p.field(ro, hdr, 6); props != 0 {
+			nodes = append(nodes, p.propertiesNode(props, "Properties"))
+		}
+
+		return nodes
+	}
+
+	return n
+}
+
+func objcROFlagsString(flags uint32) string {
+	var ss []string
+	for _, f := range objcROFlagNames {
+		if flags&f.flag != 0 {
+			ss = append(ss, f.name)
+			flags &^= f.flag
+		}
+	}
+	if flags != 0 {
+		ss = append(ss, fmt.Sprintf("%#x", flags))
+	}
+	if len(ss) == 0 {
+		return "0"
+	}
+	return strings.Join(ss, "|")
+}
+
+// category_t is {name, cls, instanceMethods, classMethods, protocols, instanceProperties}
+
+func (p *objcParser) categoryNode(addr uint64) *DataNode {
+	n := &DataNode{Values: []Value{
+		p.addrValue(addr),
+		Text("Category"),
+		Text(fmt.Sprintf("%s(%s)", p.classRefName(addr+p.ptrSize), p.stringAt(addr))),
+		p.classRefValue(addr + p.ptrSize),
+	}}
+
+	n.childrenFunc = func() []*DataNode {
+		var nodes []*DataNode
+		if protos, _, _ := p.field(addr, 0, 4); protos != 0 {
+			nodes = append(nodes, p.protocolsNode(protos))
+		}
+		if methods, _, _ := p.field(addr, 0, 2); methods != 0 {
+			nodes = append(nodes, p.methodsNode(methods, "Instance Methods", "-"))
+		}
+		if methods, _, _ := p.field(addr, 0, 3); methods != 0 {
+			nodes = append(nodes, p.methodsNode(methods, "Class Methods", "+"))
+		}
+		if props, _, _ := p.field(addr, 0, 5); props != 0 {
+			nodes = append(nodes, p.propertiesNode(props, "Properties"))
+		}
+		return nodes
+	}
+
+	return n
+}
+
+// protocol_t is {isa, name, protocols, instanceMethods, classMethods, optionalInstanceMethods, optionalClassMethods, instanceProperties, size, flags, ...}
+
+func (p *objcParser) protocolNode(addr uint64) *DataNode {
+	n := &DataNode{Values: []Value{p.addrValue(addr), Text("Protocol"), Text(p.stringAt(addr + p.ptrSize)), nil}}
+
+	n.childrenFunc = func() []*DataNode {
+		var nodes []*DataNode
+		if protos, _, _ := p.field(addr, 0, 2); protos != 0 {
+			nodes = append(nodes, p.protocolsNode(protos))
+		}
+		for i, name := range []string{"Instance Methods", "Class Methods", "Optional Instance Methods", "Optional Class Methods"} {
+			kind := "-"
+			if i%2 == 1 {
+				kind = "+"
+			}
+			if methods, _, _ := p.field(addr, 0, 3+i); methods != 0 {
+				nodes = append(nodes, p.methodsNode(methods, name, kind))
+			}
+		}
+		if props, _, _ := p.field(addr, 0, 7); props != 0 {
+			nodes = append(nodes, p.propertiesNode(props, "Properties"))
+		}
+		return nodes
+	}
+
+	return n
+}
+
+// protocol_list_t is {count, list[count]}
+
+func (p *objcParser) protocolsNode(addr uint64) *DataNode {
+	n := &DataNode{Values: []Value{p.addrValue(addr), Text("Protocols"), nil, nil}}
+
+	count, _, ok := p.pointer(addr)
+	if !ok {
+		return n
+	}
+	for i := uint64(0); i < count && i < 0x10000; i++ {
+		a := addr + (1+i)*p.ptrSize
+		target, sym, ok := p.pointer(a)
+		if !ok {
+			break
+		}
+		if sym != "" {
+			n.appendRow(p.addrValue(a), Text("Protocol"), Text(strings.TrimPrefix(sym, "__OBJC_PROTOCOL_$_")), nil)
+			continue
+		}
+		n.appendRow(
+			p.addrValue(a),
+			Text("Protocol"),
+			Text(p.stringAt(target+p.ptrSize)),
+			Value{}.appendLink(fmt.Sprintf("%#x", target), addressLink(target, 0)),
+		)
+	}
+	n.Values[3] = Text(fmt.Sprintf("%d", len(n.Children)))
+
+	return n
+}
+
+// listHeader returns entsize and count of entsize_list_tt.
+func (p *objcParser) listHeader(addr uint64) (entsize, flags, count uint32, ok bool) {
+	v, ok1 := p.uint32(addr)
+	count, ok2 := p.uint32(addr + 4)
+	if !ok1 || !ok2 || count > 0x100000 {
+		return 0, 0, 0, false
+	}
+	return v &^ objcMethodListFlagMask, v & objcMethodListFlagMask, count, true
+}
+
+// method_t is {name, types, imp}, or {int32 name, int32 types, int32 imp} which are relative to each field.
+
+func (p *objcParser) methodsNode(addr uint64, name, kind string) *DataNode {
+	n := &DataNode{Values: []Value{p.addrValue(addr), Text(name), nil, nil}}
+
+	entsize, flags, count, ok := p.listHeader(addr)
+	if !ok {
+		return n
+	}
+
+	for i := uint32(0); i < count; i++ {
+		m := addr + 8 + uint64(i)*uint64(entsize)
+
+		var sel, types string
+		var imp Value
+
+		if flags&objcSmallMethodListFlag != 0 {
+			nameOff, ok1 := p.int32(m)
+			typesOff, ok2 := p.int32(m + 4)
+			impOff, ok3 := p.int32(m + 8)
+			if !ok1 || !ok2 || !ok3 {
+				break
+			}
+			if flags&objcDirectSelectorsFlag != 0 {
+				sel = p.cstring(m + uint64(int64(nameOff)))
+			} else {
+				// the name refers the selector reference
+				sel = p.stringAt(m + uint64(int64(nameOff)))
+			}
+			types = p.cstring(m + 4 + uint64(int64(typesOff)))
+			if impOff != 0 {
+				imp = p.f.addrSymValue(m+8+uint64(int64(impOff)), 0)
+			}
+		} else {
+			sel = p.stringAt(m)
+			types = p.stringAt(m + p.ptrSize)
+			target, sym, ok := p.pointer(m + 2*p.ptrSize)
+			switch {
+			case !ok:
+			case sym != "":
+				imp = Text(sym)
+			case target != 0:
+				imp = p.f.addrSymValue(target, 0)
+			default:
+				// functions may be at 0 in object files
+				if sym := p.extern(m + 2*p.ptrSize); sym != nil {
+					imp = p.f.addrSymValue(target, 0).appendText(fmt.Sprintf(" (%s)", p.f.symName(sym.Name)))
+				}
+			}
+		}
+
+		v := Text(types)
+		if imp != nil {
+			v = append(v.appendText(" "), imp...)
+		}
+		n.appendRow(p.addrValue(m), Text(kind), Text(sel), v)
+	}
+	n.Values[3] = Text(fmt.Sprintf("%d", len(n.Children)))
+
+	return n
+}
+
+// ivar_t is {offset, name, type, alignment, size}, where offset points to the offset of the ivar.
+
+func (p *objcParser) ivarsNode(addr uint64) *DataNode {
+	n := &DataNode{Values: []Value{p.addrValue(addr), Text("Ivars"), nil, nil}}
+
+	entsize, _, count, ok := p.listHeader(addr)
+	if !ok {
+		return n
+	}
+
+	for i := uint32(0); i < count; i++ {
+		iv := addr + 8 + uint64(i)*uint64(entsize)
+
+		offset := "?"
+		if target, _, ok := p.pointer(iv); ok && target != 0 {
+			if off, ok := p.uint32(target); ok {
+				offset = fmt.Sprintf("%d", off)
+			}
+		}
+		size, _ := p.uint32(iv + 3*p.ptrSize + 4)
+
+		n.appendRow(
+			p.addrValue(iv),
+			Text("Ivar"),
+			Text(p.stringAt(iv+p.ptrSize)),
+			Text(fmt.Sprintf("%s offset=%s size=%d", p.stringAt(iv+2*p.ptrSize), offset, size)),
+		)
+	}
+	n.Values[3] = Text(fmt.Sprintf("%d", len(n.Children)))
+
+	return n
+}
+
+// property_t is {name, attributes}
+
+func (p *objcParser) propertiesNode(addr uint64, name string) *DataNode {
+	n := &DataNode{Values: []Value{p.addrValue(addr), Text(name), nil, nil}}
+
+	entsize, _, count, ok := p.listHeader(addr)
+	if !ok {
+		return n
+	}
+
+	for i := uint32(0); i < count; i++ {
+		prop := addr + 8 + uint64(i)*uint64(entsize)
+		n.appendRow(
+			p.addrValue(prop),
+			Text("Property"),
+			Text(p.stringAt(prop)),
+			Text(p.stringAt(prop+p.ptrSize)),
+		)
+	}
+	n.Values[3] = Text(fmt.Sprintf("%d", len(n.Children)))
+
+	return n
+}
+
+func (p *objcParser) refNode(addr uint64, kind string) *DataNode {
+	target, sym, ok := p.pointer(addr)
+
+	var name, val Value
+	switch {
+	case !ok:
+	case sym != "" && kind == "Class":
+		name = Text(objcClassName(sym))
+	case sym != "":
+		name = Text(sym)
+	case kind == "SEL":
+		name = Text(p.cstring(target))
+		val = Value{}.appendLink(fmt.Sprintf("%#x", target), addressLink(target, 0))
+	case kind == "Protocol":
+		name = Text(p.stringAt(target + p.ptrSize))
+		val = Value{}.appendLink(fmt.Sprintf("%#x", target), addressLink(target, 0))
+	default:
+		name = Text(p.className(target))
+		val = Value{}.appendLink(fmt.Sprintf("%#x", target), addressLink(target, 0))
+	}
+
+	return &DataNode{Values: []Value{p.addrValue(addr), Text(kind), name, val}}
+}
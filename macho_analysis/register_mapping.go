@@ -1,4 +1,4 @@
-package macho_widgets
+package macho_analysis
 
 import (
 	"debug/macho"
@@ -0,0 +1,533 @@
+package macho_analysis
+
+import (
+	"debug/macho"
+	"fmt"
+)
+
+var ReltabHeader = []string{"Address", "Address (Offset)", "Value", "Type", "Len", "PC Relative", "Extern", "Scattered"}
+
+// RelocRow is a row of the relocation table, which is a relocation and its pair if any.
+type RelocRow struct {
+	macho.Reloc
+
+	Pair *macho.Reloc // e.g. X86_64_RELOC_UNSIGNED after X86_64_RELOC_SUBTRACTOR
+}
+
+// RelocRows returns the rows of the relocation table of s, where a pair of relocations is a row.
+func (f *File) RelocRows(s *macho.Section) []RelocRow {
+	rows := make([]RelocRow, 0, len(s.Relocs))
+	for i := 0; i < len(s.Relocs); i++ {
+		row := RelocRow{Reloc: s.Relocs[i]}
+		if i+1 < len(s.Relocs) && isRelocPair(f.Cpu, s.Relocs[i], s.Relocs[i+1]) {
+			row.Pair = &s.Relocs[i+1]
+			i++
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// relocRow returns the row of the i-th relocation of s, which isn't the second of a pair.
+func (f *File) relocRow(s *macho.Section, i int) RelocRow {
+	r := s.Relocs[i]
+	row := RelocRow{Reloc: r}
+	if n := nextReloc(s, i); n != nil && isRelocPair(f.Cpu, r, *n) {
+		row.Pair = n
+	}
+	return row
+}
+
+// isRelocPair reports whether n is the pair of r, which follows r.
+func isRelocPair(cpu macho.Cpu, r, n macho.Reloc) bool {
+	switch cpu {
+	case macho.Cpu386:
+		switch macho.RelocTypeGeneric(r.Type) {
+		case macho.GENERIC_RELOC_SECTDIFF, macho.GENERIC_RELOC_LOCAL_SECTDIFF:
+			return macho.RelocTypeGeneric(n.Type) == macho.GENERIC_RELOC_PAIR
+		}
+	case macho.CpuAmd64:
+		return macho.RelocTypeX86_64(r.Type) == macho.X86_64_RELOC_SUBTRACTOR && macho.RelocTypeX86_64(n.Type) == macho.X86_64_RELOC_UNSIGNED
+	case macho.CpuArm:
+		switch macho.RelocTypeARM(r.Type) {
+		case macho.ARM_RELOC_SECTDIFF, macho.ARM_RELOC_LOCAL_SECTDIFF, macho.ARM_RELOC_HALF, macho.ARM_RELOC_HALF_SECTDIFF:
+			return macho.RelocTypeARM(n.Type) == macho.ARM_RELOC_PAIR
+		}
+	case macho.CpuArm | 0x01000000:
+		return macho.RelocTypeARM64(r.Type) == macho.ARM64_RELOC_SUBTRACTOR && macho.RelocTypeARM64(n.Type) == macho.ARM64_RELOC_UNSIGNED
+	}
+	return false
+}
+
+// isSubtractor reports whether r is X86_64_RELOC_SUBTRACTOR or ARM64_RELOC_SUBTRACTOR,
+// which subtracts its symbol from the symbol of the pair.
+func (f *File) isSubtractor(r macho.Reloc) bool {
+	switch f.Cpu {
+	case macho.CpuAmd64:
+		return macho.RelocTypeX86_64(r.Type) == macho.X86_64_RELOC_SUBTRACTOR
+	case macho.CpuArm | 0x01000000:
+		return macho.RelocTypeARM64(r.Type) == macho.ARM64_RELOC_SUBTRACTOR
+	}
+	return false
+}
+
+// ReltabCell returns the column of ReltabHeader.
+// The value of a pair is shown as A - B.
+func (f *File) ReltabCell(s *macho.Section, r RelocRow, col int) string {
+	switch col {
+	case 0: // Addr
+		return fmt.Sprintf("%#016x", s.Addr+uint64(r.Addr))
+	case 1: // Addr Offset
+		return fmt.Sprintf("%#016x", r.Addr)
+	case 2: // Value
+		if r.Pair != nil {
+			switch {
+			case f.isSubtractor(r.Reloc):
+				return fmt.Sprintf("%s - %s", f.relocValueString(*r.Pair), f.relocValueString(r.Reloc))
+			case r.Pair.Scattered: // SECTDIFF
+				return fmt.Sprintf("%s - %s", f.relocValueString(r.Reloc), f.relocValueString(*r.Pair))
+			}
+		}
+		return f.relocValueString(r.Reloc)
+	case 3: // Type
+		if r.Pair != nil {
+			return fmt.Sprintf("%s + %s", f.relocTypeString(r.Type), f.relocTypeString(r.Pair.Type))
+		}
+		return f.relocTypeString(r.Type)
+	case 4: // Length
+		return f.relocLenString(r.Len)
+	case 5: // Pcrel
+		return fmt.Sprintf("%t", r.Pcrel)
+	case 6: // Extern
+		if r.Pair != nil && f.isSubtractor(r.Reloc) {
+			return fmt.Sprintf("%t - %t", r.Pair.Extern, r.Extern)
+		}
+		if !r.Scattered {
+			return fmt.Sprintf("%t", r.Extern)
+		}
+	case 7: // Scattered
+		if r.Scattered {
+			return fmt.Sprintf("%t", r.Scattered)
+		}
+	}
+	return ""
+}
+
+// ReltabToolTip returns the tooltip of the column of ReltabHeader, which is the mangled name of the target.
+func (f *File) ReltabToolTip(s *macho.Section, r RelocRow, col int) string {
+	if col != 2 {
+		return ""
+	}
+	if r.Pair != nil && f.isSubtractor(r.Reloc) {
+		a, b := f.relocMangledName(*r.Pair), f.relocMangledName(r.Reloc)
+		if a == "" && b == "" {
+			return ""
+		}
+		if a == "" {
+			a = f.relocValueString(*r.Pair)
+		}
+		if b == "" {
+			b = f.relocValueString(r.Reloc)
+		}
+		return fmt.Sprintf("%s - %s", a, b)
+	}
+	return f.relocMangledName(r.Reloc)
+}
+
+// relocMangledName returns the mangled name of the symbol of r, or "" if it isn't mangled.
+func (f *File) relocMangledName(r macho.Reloc) string {
+	if r.Scattered || !r.Extern {
+		return ""
+	}
+	if sym := f.symIndex(r.Value); sym != nil && f.symName(sym.Name) != sym.Name {
+		return sym.Name
+	}
+	return ""
+}
+
+func (f *File) relocValueString(r macho.Reloc) string {
+	suffix := " (?)"
+
+	switch {
+	case r.Scattered:
+		addr := uint64(r.Value)
+		if s := f.symAddrString(addr, false); s != "" {
+			suffix = fmt.Sprintf(` (%s)`, s)
+		}
+		return fmt.Sprintf("%#016x%s", r.Value, suffix)
+	case r.Extern:
+		if s := f.symIndexString(r.Value); s != "" {
+			suffix = fmt.Sprintf(` (%s)`, s)
+		} else {
+			// TODO warning
+		}
+		return fmt.Sprintf("%d%s", r.Value, suffix)
+	default:
+		if s := f.sectNumString(r.Value); s != "" {
+			suffix = fmt.Sprintf(` (%s)`, s)
+		} else {
+			// TODO warning
+		}
+		return fmt.Sprintf("%d%s", r.Value, suffix)
+	}
+}
+
+func (f *File) relocTypeString(typ uint8) string {
+	switch f.Cpu {
+	case macho.Cpu386:
+		return fmt.Sprintf("%d (%s)", typ, macho.RelocTypeGeneric(typ))
+	case macho.CpuAmd64:
+		return fmt.Sprintf("%d (%s)", typ, macho.RelocTypeX86_64(typ))
+	case macho.CpuArm:
+		return fmt.Sprintf("%d (%s)", typ, macho.RelocTypeARM(typ))
+	case macho.CpuArm | 0x01000000:
+		return fmt.Sprintf("%d (%s)", typ, macho.RelocTypeARM64(typ))
+	default:
+		// TODO warning
+		return fmt.Sprintf("%d (?)", typ)
+	}
+}
+
+func (f *File) relocLenString(len uint8) string {
+	switch len {
+	case 0:
+		return "0 (byte)"
+	case 1:
+		return "1 (word)"
+	case 2:
+		return "2 (long)"
+	case 3:
+		return "3 (quad)"
+	default:
+		panic("unreachable")
+	}
+}
+
+type RelocTarget struct {
+	Symnum  int
+	Symaddr uint64 // exist if Symnum != -1
+	Addend  int64
+	Size    uint8
+
+	Sub *RelocTarget // the target subtracted from this, e.g. the symbol of X86_64_RELOC_SUBTRACTOR
+}
+
+// relocDataString returns the bytes to be relocated by the i-th relocation of s, indented by off, and the target.
+func (f *File) relocDataString(s *macho.Section, i int, off uint64, data []byte) (string, *RelocTarget) {
+	r := s.Relocs[i]
+
+	var uval uint64
+	var ival int64
+
+	switch len(data) {
+	case 1:
+		val := data[0]
+		uval = uint64(val)
+		ival = int64(int8(val))
+	case 2:
+		val := f.ByteOrder.Uint16(data)
+		uval = uint64(val)
+		ival = int64(int16(val))
+	case 4:
+		val := f.ByteOrder.Uint32(data)
+		uval = uint64(val)
+		ival = int64(int32(val))
+	case 8:
+		val := f.ByteOrder.Uint64(data)
+		uval = val
+		ival = int64(val)
+	default:
+		panic("unreachable")
+	}
+
+	var target *RelocTarget
+
+	suffix := " (?)"
+
+	switch f.Cpu {
+	case macho.Cpu386:
+		switch macho.RelocTypeGeneric(r.Type) {
+		case macho.GENERIC_RELOC_VANILLA:
+			pc := s.Addr + uint64(r.Addr) + uint64(1<<r.Len)
+			suffix, target = f.vanillaRelocTarget(r, uval, ival, pc, "%eip")
+		case macho.GENERIC_RELOC_PAIR:
+		case macho.GENERIC_RELOC_SECTDIFF, macho.GENERIC_RELOC_LOCAL_SECTDIFF:
+			suffix, target = f.sectdiffRelocTarget(s, i, ival)
+		case macho.GENERIC_RELOC_PB_LA_PTR:
+			suffix, target = pbLaPtrRelocTarget(r, uval)
+		case macho.GENERIC_RELOC_TLV:
+			suffix = fmt.Sprintf(" (addend: %+d)", ival)
+			target = &RelocTarget{
+				Symnum: int(r.Value),
+				Addend: ival,
+				Size:   1 << r.Len,
+			}
+		}
+	case macho.CpuAmd64:
+		if macho.RelocTypeX86_64(r.Type) == macho.X86_64_RELOC_SUBTRACTOR {
+			suffix, target = f.subtractorRelocTarget(s, i, ival)
+		} else if r.Extern {
+			switch macho.RelocTypeX86_64(r.Type) {
+			default:
+				suffix = fmt.Sprintf(" (addend: %+d)", ival)
+				target = &RelocTarget{
+					Symnum: int(r.Value),
+					Addend: ival,
+					Size:   1 << r.Len,
+				}
+			case macho.X86_64_RELOC_SIGNED_1:
+				suffix = fmt.Sprintf(" (addend: %d+1 = %+d)", ival, ival+1)
+				target = &RelocTarget{
+					Symnum: int(r.Value),
+					Addend: ival + 1,
+					Size:   1 << r.Len,
+				}
+			case macho.X86_64_RELOC_SIGNED_2:
+				suffix = fmt.Sprintf(" (addend: %d+2 = %+d)", ival, ival+2)
+				target = &RelocTarget{
+					Symnum: int(r.Value),
+					Addend: ival + 2,
+					Size:   1 << r.Len,
+				}
+			case macho.X86_64_RELOC_SIGNED_4:
+				suffix = fmt.Sprintf(" (addend: %d+4 = %+d)", ival, ival+4)
+				target = &RelocTarget{
+					Symnum: int(r.Value),
+					Addend: ival + 4,
+					Size:   1 << r.Len,
+				}
+			}
+		} else if !r.Pcrel {
+			suffix = fmt.Sprintf(" (addr: %#x)", uval)
+			target = &RelocTarget{
+				Symnum:  -1,
+				Symaddr: uval,
+				Size:    1 << r.Len,
+			}
+		} else {
+			pc := s.Addr + uint64(r.Addr) + uint64(1<<r.Len)
+
+			switch macho.RelocTypeX86_64(r.Type) {
+			default:
+				suffix = fmt.Sprintf(" (addr: %#x(%%rip) = %#x)", uval, uval+pc)
+				target = &RelocTarget{
+					Symnum:  -1,
+					Symaddr: uval + pc,
+					Size:    1 << r.Len,
+				}
+			case macho.X86_64_RELOC_SIGNED_1:
+				suffix = fmt.Sprintf(" (addr: %#x(%%rip)+1 = %#x)", uval, uval+pc+1)
+				target = &RelocTarget{
+					Symnum:  -1,
+					Symaddr: uval + pc + 1,
+					Size:    1 << r.Len,
+				}
+			case macho.X86_64_RELOC_SIGNED_2:
+				suffix = fmt.Sprintf(" (addr: %#x(%%rip)+2 = %#x)", uval, uval+pc+2)
+				target = &RelocTarget{
+					Symnum:  -1,
+					Symaddr: uval + pc + 2,
+					Size:    1 << r.Len,
+				}
+			case macho.X86_64_RELOC_SIGNED_4:
+				suffix = fmt.Sprintf(" (addr: %#x(%%rip)+4 = %#x)", uval, uval+pc+4)
+				target = &RelocTarget{
+					Symnum:  -1,
+					Symaddr: uval + pc + 4,
+					Size:    1 << r.Len,
+				}
+			}
+		}
+	case macho.CpuArm:
+		suffix, target = f.armRelocTarget(s, i, data, uval, ival)
+	case macho.CpuArm | 0x01000000:
+		suffix, target = f.arm64RelocTarget(s, i, uval, ival)
+	}
+
+	return fmt.Sprintf(fmt.Sprintf("%% %dx%%s", (uint64(len(data))+off)*3-1), data, suffix), target
+}
+
+// vanillaRelocTarget returns the target of GENERIC_RELOC_VANILLA or ARM_RELOC_VANILLA.
+// pc is the value of reg, which pc-relative relocations are relative to.
+func (f *File) vanillaRelocTarget(r macho.Reloc, uval uint64, ival int64, pc uint64, reg string) (string, *RelocTarget) {
+	switch {
+	case r.Scattered:
+		// the bytes have the address of the target, which is in the symbol at r.Value
+		rs := f.symAddrString(uint64(r.Value), true)
+		if r.Pcrel {
+			addend := ival + int64(pc) - int64(r.Value)
+			return fmt.Sprintf(" (addr: %#x(%s) = %s%+d)", uval, reg, rs, addend), &RelocTarget{
+				Symnum:  -1,
+				Symaddr: uint64(r.Value),
+				Addend:  addend,
+				Size:    1 << r.Len,
+			}
+		}
+		addend := int64(uval) - int64(r.Value)
+		return fmt.Sprintf(" (addr: %s%+d)", rs, addend), &RelocTarget{
+			Symnum:  -1,
+			Symaddr: uint64(r.Value),
+			Addend:  addend,
+			Size:    1 << r.Len,
+		}
+	case r.Extern:
+		if r.Pcrel {
+			return fmt.Sprintf(" (addend: %#+x(%s) = %+d)", ival, reg, ival+int64(pc)), &RelocTarget{
+				Symnum: int(r.Value),
+				Addend: ival + int64(pc),
+				Size:   1 << r.Len,
+			}
+		}
+		return fmt.Sprintf(" (addend: %+d)", ival), &RelocTarget{
+			Symnum: int(r.Value),
+			Addend: ival,
+			Size:   1 << r.Len,
+		}
+	default:
+		if r.Pcrel {
+			return fmt.Sprintf(" (addr: %#x(%s) = %#x)", uval, reg, uval+pc), &RelocTarget{
+				Symnum:  -1,
+				Symaddr: uval + pc,
+				Size:    1 << r.Len,
+			}
+		}
+		return fmt.Sprintf(" (addr: %#x)", uval), &RelocTarget{
+			Symnum:  -1,
+			Symaddr: uval,
+			Size:    1 << r.Len,
+		}
+	}
+}
+
+// sectdiffRelocTarget returns the target of the difference of the i-th relocation of s and the following pair,
+// i.e. GENERIC_RELOC_SECTDIFF, GENERIC_RELOC_LOCAL_SECTDIFF, ARM_RELOC_SECTDIFF and ARM_RELOC_LOCAL_SECTDIFF.
+func (f *File) sectdiffRelocTarget(s *macho.Section, i int, ival int64) (string, *RelocTarget) {
+	r := s.Relocs[i]
+	n := nextReloc(s, i)
+	// GENERIC_RELOC_PAIR and ARM_RELOC_PAIR are the same
+	if n == nil || !n.Scattered || macho.RelocTypeGeneric(n.Type) != macho.GENERIC_RELOC_PAIR {
+		// TODO warning
+		return " (?)", nil
+	}
+	ns := f.symAddrString(uint64(n.Value), true)
+	rs := f.symAddrString(uint64(r.Value), true)
+	addend := ival + int64(n.Value) - int64(r.Value)
+	return fmt.Sprintf(" (addend: %#x+%s-%s = %+d)", ival, ns, rs, addend), &RelocTarget{
+		Symnum:  -1,
+		Symaddr: uint64(r.Value),
+		Addend:  addend,
+		Size:    1 << r.Len,
+	}
+}
+
+// subtractorRelocTarget returns the target of the i-th relocation of s, which is SUBTRACTOR, and the following UNSIGNED.
+// The target is A - B + addend, where A is the symbol of UNSIGNED and B is the symbol of SUBTRACTOR.
+func (f *File) subtractorRelocTarget(s *macho.Section, i int, ival int64) (string, *RelocTarget) {
+	r := s.Relocs[i]
+	n := nextReloc(s, i)
+	if n == nil || !isRelocPair(f.Cpu, r, *n) || !r.Extern {
+		// TODO warning
+		return " (?)", nil
+	}
+
+	sub := &RelocTarget{
+		Symnum: int(r.Value),
+		Size:   1 << r.Len,
+	}
+
+	if n.Extern {
+		return fmt.Sprintf(" (addend: %+d)", ival), &RelocTarget{
+			Symnum: int(n.Value),
+			Addend: ival,
+			Size:   1 << r.Len,
+			Sub:    sub,
+		}
+	}
+
+	// the bytes have the difference of the addresses
+	b := f.symIndex(r.Value)
+	if b == nil {
+		// TODO warning
+		return " (?)", nil
+	}
+	addr := addAddend(b.Value, ival)
+	return fmt.Sprintf(" (addr: %#x+%s = %#x)", ival, f.symName(b.Name), addr), &RelocTarget{
+		Symnum:  -1,
+		Symaddr: addr,
+		Size:    1 << r.Len,
+		Sub:     sub,
+	}
+}
+
+// pbLaPtrRelocTarget returns the target of GENERIC_RELOC_PB_LA_PTR or ARM_RELOC_PB_LA_PTR.
+// The bytes have the prebound address of the lazy pointer, and r.Value has the address used until it's bound.
+func pbLaPtrRelocTarget(r macho.Reloc, uval uint64) (string, *RelocTarget) {
+	return fmt.Sprintf(" (prebound: %#x, lazy: %#x)", uval, r.Value), &RelocTarget{
+		Symnum:  -1,
+		Symaddr: uint64(r.Value),
+		Size:    1 << r.Len,
+	}
+}
+
+// nextReloc returns the relocation following the i-th relocation of s, which is the pair if it has a pair.
+func nextReloc(s *macho.Section, i int) *macho.Reloc {
+	if i+1 < len(s.Relocs) {
+		return &s.Relocs[i+1]
+	}
+	return nil
+}
+
+// prevReloc returns the relocation preceding the i-th relocation of s, e.g. ARM64_RELOC_ADDEND.
+func prevReloc(s *macho.Section, i int) *macho.Reloc {
+	if i > 0 {
+		return &s.Relocs[i-1]
+	}
+	return nil
+}
+
+// relocSize returns the size of the bytes relocated by r.
+func relocSize(cpu macho.Cpu, r macho.Reloc) uint64 {
+	if cpu == macho.CpuArm {
+		switch macho.RelocTypeARM(r.Type) {
+		case macho.ARM_RELOC_HALF, macho.ARM_RELOC_HALF_SECTDIFF:
+			// r.Len tells which half of the address is in the instruction, and whether it's thumb
+			return 4
+		}
+	}
+	return 1 << r.Len
+}
+
+func (f *File) relocTargetValue(t *RelocTarget) Value {
+	if t == nil {
+		return Text("?")
+	}
+	if t.Sub != nil {
+		a := *t
+		a.Sub = nil
+		return append(f.relocTargetValue(&a).appendText(" - "), f.relocTargetValue(t.Sub)...)
+	}
+	size := uint64(t.Size)
+	if t.Symnum != -1 {
+		if t.Symnum < 0 || t.Symnum >= len(f.Syms) {
+			if t.Addend == 0 {
+				return Text("?")
+			}
+			return Text(fmt.Sprintf("?%+d", t.Addend))
+		}
+		sym := &f.Syms[t.Symnum]
+		if t.Addend == 0 {
+			return Value{}.appendLink(f.symName(sym.Name), symbolLink(t.Symnum, 0, size))
+		}
+		return Value{}.appendLink(fmt.Sprintf("%s%+d", f.symName(sym.Name), t.Addend), symbolLink(t.Symnum, t.Addend, size))
+	}
+	addr := t.Symaddr
+	if t.Addend < 0 {
+		addr -= uint64(-t.Addend)
+	} else {
+		addr += uint64(t.Addend)
+	}
+	if v := f.symValue(addr, size); v != nil {
+		return v
+	}
+	return Value{}.appendLink(f.symAddrString(addr, true), addressLink(addr, size))
+}
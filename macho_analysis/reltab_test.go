@@ -0,0 +1,39 @@
+package macho_analysis
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"testing"
+)
+
+func TestRelocPair(t *testing.T) {
+	f := NewFile(&macho.File{
+		FileHeader: macho.FileHeader{Cpu: macho.CpuAmd64},
+		ByteOrder:  binary.LittleEndian,
+		Symtab:     &macho.Symtab{Syms: []macho.Symbol{{Name: "_a"}, {Name: "_b"}, {Name: "_c"}}},
+	})
+
+	sub := macho.Reloc{Type: uint8(macho.X86_64_RELOC_SUBTRACTOR), Len: 3, Extern: true, Value: 0}
+	s := &macho.Section{Relocs: []macho.Reloc{
+		sub,
+		{Type: uint8(macho.X86_64_RELOC_UNSIGNED), Len: 3, Extern: true, Value: 1},
+		sub, // the same as the first, but paired with another symbol
+		{Type: uint8(macho.X86_64_RELOC_UNSIGNED), Len: 3, Extern: true, Value: 2},
+	}}
+
+	rows := f.RelocRows(s)
+	if len(rows) != 2 {
+		t.Fatalf("RelocRows returned %d rows; want 2", len(rows))
+	}
+
+	data := make([]byte, 8)
+	for i, want := range map[int]string{0: "_b - _a", 2: "_c - _a"} {
+		_, target := f.relocDataString(s, i, 0, data)
+		if got := f.relocTargetValue(target).String(); got != want {
+			t.Errorf("target of relocation %d = %q; want %q", i, got, want)
+		}
+		if row := f.relocRow(s, i); row.Pair != &s.Relocs[i+1] {
+			t.Errorf("pair of relocation %d = %v; want relocation %d", i, row.Pair, i+1)
+		}
+	}
+}
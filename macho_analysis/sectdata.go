@@ -0,0 +1,281 @@
+package macho_analysis
+
+import (
+	"bytes"
+	"debug/macho"
+	"fmt"
+	"strconv"
+)
+
+var (
+	dataHeader      = []string{"Address", "Data", "Value"}
+	relocDataHeader = []string{"Address", "Data", "Value", "Type", "PC Relative", "Extern", "Scattered", "Relocatable"}
+)
+
+// SectionData decodes sect as typ, which is one of "Code", "Source", "CString", "Float32", "Float64",
+// "Float128", "Pointer32", "Pointer64", "EHFrame", "UnwindInfo", "ExceptTable" and "Data".
+func (f *File) SectionData(typ string, sect *macho.Section) *DataTree {
+	switch typ {
+	case "":
+		return nil
+	case "Code":
+		return f.newCodeSectionData(sect, false)
+	case "Source":
+		return f.newCodeSectionData(sect, true)
+	case "CString":
+		return f.newCStringSectionData(sect)
+	case "Float32":
+		return f.newFloat32SectionData(sect)
+	case "Float64":
+		return f.newFloat64SectionData(sect)
+	case "Float128":
+		return f.newFloat128SectionData(sect)
+	case "Pointer32":
+		return f.newPointer32SectionData(sect)
+	case "Pointer64":
+		return f.newPointer64SectionData(sect)
+	case "EHFrame":
+		return f.EHFrame(sect)
+	case "UnwindInfo":
+		return f.UnwindInfo(sect)
+	case "ExceptTable":
+		return f.ExceptTable(sect)
+	case "Data":
+		return f.newDataSectionData(sect)
+	default:
+		panic("unreachable")
+	}
+}
+
+// GuessSectType returns the type of SectionData suitable for sect.
+// TODO support more section types
+func (f *File) GuessSectType(sect *macho.Section) string {
+	if sect == nil {
+		return ""
+	}
+
+	switch SectionType(sect.Flags & SECTION_TYPE) {
+	case S_CSTRING_LITERALS:
+		return "CString"
+	case S_4BYTE_LITERALS:
+		return "Float32"
+	case S_8BYTE_LITERALS:
+		return "Float64"
+	case S_LITERAL_POINTERS:
+		return f.pointerType()
+	case S_NON_LAZY_SYMBOL_POINTERS:
+		return f.pointerType()
+	case S_LAZY_SYMBOL_POINTERS:
+		return f.pointerType()
+	case S_SYMBOL_STUBS:
+		return "Code"
+	case S_MOD_INIT_FUNC_POINTERS:
+		return f.pointerType()
+	case S_MOD_TERM_FUNC_POINTERS:
+		return f.pointerType()
+	case S_16BYTE_LITERALS:
+		return "Float128"
+	}
+
+	if sect.Flags&S_ATTR_SOME_INSTRUCTIONS != 0 || sect.Flags&S_ATTR_PURE_INSTRUCTIONS != 0 {
+		return "Code"
+	}
+
+	switch sect.Seg {
+	case "__TEXT":
+		switch sect.Name {
+		case "__text":
+			return "Code"
+		case "__cstring":
+			return "CString"
+		case "__literal4":
+			return "Float32"
+		case "__literal8":
+			return "Float64"
+		case "__literal16":
+			return "Float128"
+		case "__eh_frame":
+			return "EHFrame"
+		case "__unwind_info":
+			return "UnwindInfo"
+		case "__gcc_except_tab":
+			return "ExceptTable"
+		case "__swift5_reflstr":
+			return "CString"
+		}
+	case "__DWARF":
+		switch sect.Name {
+		case "__debug_str":
+			return "CString"
+		}
+	}
+
+	return "Data"
+}
+
+// newCodeSectionData disassembles sect. If source is true, source lines are interleaved.
+func (f *File) newCodeSectionData(sect *macho.Section, source bool) *DataTree {
+	disasm := f.disasmFunc()
+	if disasm == nil {
+		// TODO warning
+		return nil
+	}
+
+	return f.newSectionData(sect, disasm, true).withLines(source)
+}
+
+func (f *File) newDataSectionData(sect *macho.Section) *DataTree {
+	if f.isZeroSect(sect) {
+		return f.newSectionData(sect, func(data []byte, addr uint64) (string, int) {
+			size := 8
+			if len(data) < 8 {
+				size = len(data)
+			}
+			return "zero-fill", size
+		}, false)
+	}
+
+	return f.newSectionData(sect, func(data []byte, addr uint64) (string, int) {
+		if fx := f.Fixups.Lookup(addr); fx != nil && fx.Size <= len(data) {
+			return f.fixupString(f.Fixups, fx), fx.Size
+		}
+		size := 8
+		if len(data) < 8 {
+			size = len(data)
+		}
+		return f.toASCII(data[:size]), size
+	}, true)
+}
+
+func (f *File) newCStringSectionData(sect *macho.Section) *DataTree {
+	return f.newSectionData(sect, func(data []byte, addr uint64) (string, int) {
+		var size int
+		if c := bytes.IndexByte(data, 0); c != -1 {
+			size = c + 1
+			val := strconv.Quote(string(data[:size-1]))
+			return val, size
+		} else {
+			size = len(data)
+			val := strconv.Quote(string(data[:size]))
+			return val[:len(val)-1], size
+		}
+	}, false)
+}
+
+func (f *File) newFloat32SectionData(sect *macho.Section) *DataTree {
+	return f.newSectionData(sect, func(data []byte, addr uint64) (string, int) {
+		size := 4
+		if len(data) < 4 {
+			size = len(data)
+		}
+		return f.toFloat32(data[:size]), size
+	}, false)
+}
+
+func (f *File) newFloat64SectionData(sect *macho.Section) *DataTree {
+	return f.newSectionData(sect, func(data []byte, addr uint64) (string, int) {
+		size := 8
+		if len(data) < 8 {
+			size = len(data)
+		}
+		return f.toFloat64(data[:size]), size
+	}, false)
+}
+
+func (f *File) newFloat128SectionData(sect *macho.Section) *DataTree {
+	return f.newSectionData(sect, func(data []byte, addr uint64) (string, int) {
+		size := 16
+		if len(data) < 16 {
+			size = len(data)
+		}
+		return f.toFloat128(data[:size]), size
+	}, false)
+}
+
+func (f *File) newPointer32SectionData(sect *macho.Section) *DataTree {
+	return f.newSectionData(sect, func(data []byte, addr uint64) (string, int) {
+		size := 4
+		if len(data) < 4 {
+			size = len(data)
+		}
+		return f.toPointer32(data[:size], addr), size
+	}, false)
+}
+
+func (f *File) newPointer64SectionData(sect *macho.Section) *DataTree {
+	return f.newSectionData(sect, func(data []byte, addr uint64) (string, int) {
+		size := 8
+		if len(data) < 8 {
+			size = len(data)
+		}
+		return f.toPointer64(data[:size], addr), size
+	}, false)
+}
+
+// pointerType returns the type of SectionData suitable for pointers.
+func (f *File) pointerType() string {
+	if f.pointerSize() == 8 {
+		return "Pointer64"
+	}
+	return "Pointer32"
+}
+
+func (f *File) newSectionData(sect *macho.Section, valueFunc func(data []byte, addr uint64) (string, int), hasRel bool) *DataTree {
+	hasRel = hasRel && f.Type == macho.TypeObj
+
+	t := new(DataTree)
+	if hasRel {
+		t.Header = relocDataHeader
+	} else {
+		t.Header = dataHeader
+	}
+
+	var data []byte
+	if f.isZeroSect(sect) {
+		data = make([]byte, sect.Size)
+	} else {
+		var err error
+		data, err = sect.Data()
+		if err != nil {
+			// TODO warning
+			return t
+		}
+	}
+
+	t.idx = &dataIndex{
+		f:         f,
+		data:      data,
+		addr:      sect.Addr,
+		valueFunc: valueFunc,
+		hasRel:    hasRel,
+		track:     true,
+	}
+
+	return t
+}
+
+// appendRelocRows appends relocations in data, which is located at addr.
+func (f *File) appendRelocRows(n *DataNode, info *SymInfo, data []byte, addr uint64) {
+	for i := range info.Relocs {
+		r := info.Relocs[i]
+		s := info.RelocSections[i]
+		j := info.RelocIndices[i]
+		raddr := s.Addr + uint64(r.Addr)
+		rsize := relocSize(f.Cpu, r)
+		if addr <= raddr && raddr+rsize <= addr+uint64(len(data)) {
+			rdata := data[raddr-addr : raddr-addr+rsize]
+			rdataString, rtarget := f.relocDataString(s, j, raddr-addr, rdata)
+			row := f.relocRow(s, j)
+			n.appendRow(
+				Text(fmt.Sprintf("%#016x", raddr)),
+				Text(rdataString),
+				Text(f.ReltabCell(s, row, 2)),
+				Text(f.ReltabCell(s, row, 3)),
+				Text(fmt.Sprintf("%t", r.Pcrel)),
+				Text(fmt.Sprintf("%t", r.Extern)),
+				Text(fmt.Sprintf("%t", r.Scattered)),
+				f.relocTargetValue(rtarget),
+			)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+package macho_analysis
+
+import (
+	"debug/macho"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var fieldHeader = []string{"Field", "Value"}
+
+// StructNode is a node of the structure tree.
+// The tree is shared by StructModel and Dump.
+type StructNode struct {
+	Name     string
+	Header   []string
+	Children []*StructNode
+
+	// Section is set for the data of the section, which is shown by SectionData instead of rows.
+	Section *macho.Section
+
+	rowsFunc func() [][]Value
+	rows     [][]Value
+	done     bool
+}
+
+func newStructNode(name string, fields [][]string) *StructNode {
+	rows := make([][]Value, len(fields))
+	for i, field := range fields {
+		rows[i] = texts(field)
+	}
+	return &StructNode{
+		Name:   name,
+		Header: fieldHeader,
+		rows:   rows,
+		done:   true,
+	}
+}
+
+// newTableNode is like newStructNode, but rows are computed lazily by rowsFunc.
+func newTableNode(name string, header []string, rowsFunc func() [][]Value) *StructNode {
+	return &StructNode{
+		Name:     name,
+		Header:   header,
+		rowsFunc: rowsFunc,
+	}
+}
+
+func (n *StructNode) HasRows() bool {
+	return n.Header != nil
+}
+
+func (n *StructNode) Rows() [][]Value {
+	if !n.done {
+		n.done = true
+		n.rows = n.rowsFunc()
+	}
+	return n.rows
+}
+
+func (n *StructNode) appendChild(c *StructNode) {
+	n.Children = append(n.Children, c)
+}
+
+// StructTree returns the mach header and load commands.
+func (f *File) StructTree() *StructNode {
+	file := newStructNode(f.fileString(), [][]string{
+		{"magic", fmt.Sprintf("%#08x (%s)", f.Magic, Magic(f.Magic))},
+		{"cputype", fmt.Sprintf("%#08x (%s)", uint32(f.Cpu), CpuType(f.Cpu))},
+		{"cpusubtype", f.cpusubString()},
+		{"filetype", fmt.Sprintf("%#08x (%s)", uint32(f.Type), FileType(f.Type))},
+		{"ncmds", fmt.Sprint(f.Ncmd)},
+		{"sizeofcmds", fmt.Sprintf("%#08x", f.Cmdsz)},
+		{"flags", f.flagsString(f.Flags, fileFlagStrings[:])},
+	})
+
+	sectDone := make(map[*macho.Section]bool)
+
+	loads := &StructNode{Name: fmt.Sprintf("Load Commands (%d)", len(f.Loads))}
+	for _, lc := range f.Loads {
+		raw := lc.Raw()
+		cmd := f.ByteOrder.Uint32(raw[0:4])
+		cmdsize := f.ByteOrder.Uint32(raw[4:8])
+
+		switch lc := lc.(type) {
+		case *macho.Rpath:
+			loads.appendChild(newStructNode("LC_RPATH", [][]string{
+				{"cmd", fmt.Sprintf("%#08x (%s)", cmd, LoadCommand(cmd))},
+				{"cmdsize", fmt.Sprintf("%#08x", cmdsize)},
+				{"path", lc.Path},
+			}))
+		case *macho.Dylib:
+			loads.appendChild(newStructNode(fmt.Sprintf("LC_LOAD_DYLIB (%s)", lc.Name), [][]string{
+				{"cmd", fmt.Sprintf("%#08x (%s)", cmd, LoadCommand(cmd))},
+				{"cmdsize", fmt.Sprintf("%#08x", cmdsize)},
+				{"name", lc.Name},
+				{"timestamp", time.Unix(int64(lc.Time), 0).String()},
+				{"current_version", f.versionString(lc.CurrentVersion)},
+				{"compatibility_version", f.versionString(lc.CompatVersion)},
+			}))
+		case *macho.Symtab:
+			loads.appendChild(newStructNode("LC_SYMTAB", [][]string{
+				{"cmd", fmt.Sprintf("%#08x (%s)", cmd, LoadCommand(cmd))},
+				{"cmdsize", fmt.Sprintf("%#08x", cmdsize)},
+				{"symoff", fmt.Sprintf("%#08x", lc.SymtabCmd.Symoff)},
+				{"nsyms", fmt.Sprint(lc.SymtabCmd.Nsyms)},
+				{"stroff", fmt.Sprintf("%#08x", lc.SymtabCmd.Stroff)},
+				{"strsize", fmt.Sprintf("%#08x", lc.SymtabCmd.Strsize)},
+			}))
+		case *macho.Dysymtab:
+			loads.appendChild(newStructNode("LC_DYSYMTAB", [][]string{
+				{"cmd", fmt.Sprintf("%#08x (%s)", cmd, LoadCommand(cmd))},
+				{"cmdsize", fmt.Sprintf("%#08x", cmdsize)},
+				{"ilocalsym", fmt.Sprint(lc.DysymtabCmd.Ilocalsym)},
+				{"nlocalsym", fmt.Sprint(lc.DysymtabCmd.Nlocalsym)},
+				{"iextdefsym", fmt.Sprint(lc.DysymtabCmd.Iextdefsym)},
+				{"nextdefsym", fmt.Sprint(lc.DysymtabCmd.Nextdefsym)},
+				{"iundefsym", fmt.Sprint(lc.DysymtabCmd.Iundefsym)},
+				{"nundefsym", fmt.Sprint(lc.DysymtabCmd.Nundefsym)},
+				{"tocoff", fmt.Sprintf("%#08x", lc.DysymtabCmd.Tocoffset)},
+				{"ntoc", fmt.Sprint(lc.DysymtabCmd.Ntoc)},
+				{"modtaboff", fmt.Sprintf("%#08x", lc.DysymtabCmd.Modtaboff)},
+				{"nmodtab", fmt.Sprint(lc.DysymtabCmd.Modtaboff)},
+				{"extrefsymoff", fmt.Sprintf("%#08x", lc.DysymtabCmd.Extrefsymoff)},
+				{"nextrefsyms", fmt.Sprint(lc.DysymtabCmd.Nextrefsyms)},
+				{"indirectsymoff", fmt.Sprintf("%#08x", lc.DysymtabCmd.Indirectsymoff)},
+				{"nindirectsyms", fmt.Sprint(lc.DysymtabCmd.Nindirectsyms)},
+				{"extreloff", fmt.Sprintf("%#08x", lc.DysymtabCmd.Extreloff)},
+				{"nextrel", fmt.Sprint(lc.DysymtabCmd.Nextrel)},
+				{"locreloff", fmt.Sprintf("%#08x", lc.DysymtabCmd.Locreloff)},
+				{"nlocrel", fmt.Sprintf("%d", lc.DysymtabCmd.Nlocrel)},
+			}))
+		case *macho.Segment:
+			var name string
+			switch lc.Cmd {
+			case macho.LoadCmdSegment:
+				name = fmt.Sprintf("LC_SEGMENT (%s) (%d)", lc.Name, lc.Nsect)
+			case macho.LoadCmdSegment64:
+				name = fmt.Sprintf("LC_SEGMENT_64 (%s) (%d)", lc.Name, lc.Nsect)
+			default:
+				panic("unreachable")
+			}
+
+			seg := newStructNode(name, [][]string{
+				{"cmd", fmt.Sprintf("%#08x (%s)", cmd, LoadCommand(cmd))},
+				{"cmdsize", fmt.Sprintf("%#08x", cmdsize)},
+				{"segname", lc.Name},
+				{"vmaddr", fmt.Sprintf("%#016x", lc.Addr)},
+				{"vmsize", fmt.Sprintf("%#016x", lc.Memsz)},
+				{"fileoff", fmt.Sprintf("%#016x", lc.Offset)},
+				{"filesize", fmt.Sprintf("%#016x", lc.Filesz)},
+				{"maxprot", f.vmprotString(lc.Maxprot)},
+				{"initprot", f.vmprotString(lc.Prot)},
+				{"nsects", fmt.Sprint(lc.Nsect)},
+				{"flags", f.flagsString(lc.Flag, segmentFlagStrings[:])},
+			})
+
+			nsect := lc.Nsect
+
+			for i, sect := range f.Sections {
+				if lc.Addr <= sect.Addr && sect.Addr+sect.Size <= lc.Addr+lc.Memsz {
+					if lc.Name == sect.Seg {
+						nsect--
+					} else {
+						// TODO warning
+					}
+					if sectDone[sect] {
+						// TODO warning
+					} else {
+						sectDone[sect] = true
+					}
+
+					sn := newStructNode(fmt.Sprintf("Section %d (%s,%s)", i+1, sect.Seg, sect.Name), [][]string{
+						{"sectname", sect.Name},
+						{"segname", sect.Seg},
+						{"addr", fmt.Sprintf("%#016x", sect.Addr)},
+						{"size", fmt.Sprintf("%#016x", sect.Size)},
+						{"offset", fmt.Sprintf("%#016x", sect.Offset)},
+						{"align", fmt.Sprintf("%d (%d)", sect.Align, 1<<sect.Align)},
+						{"reloff", fmt.Sprintf("%#016x", sect.Reloff)},
+						{"nreloc", fmt.Sprint(sect.Nreloc)},
+						{"flags", f.SectionFlagsString(sect.Flags)},
+					})
+					sn.appendChild(&StructNode{Name: "Data", Section: sect})
+					seg.appendChild(sn)
+				}
+			}
+
+			if nsect != 0 {
+				// TODO warning
+			}
+
+			loads.appendChild(seg)
+		default:
+			switch LoadCommand(cmd) {
+			case LC_DYLD_INFO, LC_DYLD_INFO_ONLY:
+				loads.appendChild(f.newDyldInfoNode())
+			case LC_DYLD_CHAINED_FIXUPS:
+				loads.appendChild(f.newChainedFixupsNode())
+			case LC_CODE_SIGNATURE:
+				loads.appendChild(f.newCodeSignatureNode())
+			case LC_UUID:
+				uuid, _ := f.UUID()
+				loads.appendChild(newStructNode("LC_UUID", [][]string{
+					{"cmd", fmt.Sprintf("%#08x (%s)", cmd, LoadCommand(cmd))},
+					{"cmdsize", fmt.Sprintf("%#08x", cmdsize)},
+					{"uuid", uuidString(uuid)},
+				}))
+			default:
+				loads.appendChild(newStructNode(fmt.Sprintf("%s (?)", LoadCommand(cmd)), [][]string{
+					{"cmd", fmt.Sprintf("%#08x (%s)", cmd, LoadCommand(cmd))},
+					{"cmdsize", fmt.Sprintf("%#08x", cmdsize)},
+				}))
+			}
+		}
+	}
+
+	for _, sect := range f.Sections {
+		if !sectDone[sect] {
+			// TODO warning
+		}
+	}
+
+	file.appendChild(loads)
+
+	return file
+}
+
+// FatStructTree returns the fat header. Children are ordered by architectures.
+func FatStructTree(ff *FatFile) *StructNode {
+	hdr := newStructNode(fmt.Sprintf("Fat Header (%d)", len(ff.Arches)), [][]string{
+		{"magic", fmt.Sprintf("%#08x (%s)", ff.Magic, Magic(ff.Magic))},
+		{"nfat_arch", fmt.Sprint(len(ff.Arches))},
+	})
+
+	for i, arch := range ff.Arches {
+		offsetFormat := "%#08x"
+		if Magic(ff.Magic) == FAT_MAGIC_64 {
+			offsetFormat = "%#016x"
+		}
+
+		h := arch.FatArchHeader
+
+		fields := [][]string{
+			{"cputype", fmt.Sprintf("%#08x (%s)", uint32(h.Cpu), CpuType(h.Cpu))},
+			{"cpusubtype", cpusubString(h.Cpu, h.SubCpu)},
+			{"offset", fmt.Sprintf(offsetFormat, h.Offset)},
+			{"size", fmt.Sprintf(offsetFormat, h.Size)},
+			{"align", fmt.Sprintf("%d (%d)", h.Align, 1<<h.Align)},
+		}
+		if arch.Err != nil {
+			fields = append(fields, []string{"error", arch.Err.Error()})
+		}
+
+		hdr.appendChild(newStructNode(fmt.Sprintf("Fat Arch %d (%s)", i, ArchString(h.Cpu, h.SubCpu)), fields))
+	}
+
+	return hdr
+}
+
+func (f *File) fileString() string {
+	typeString := func(typ macho.Type) string {
+		switch typ {
+		case macho.TypeObj:
+			return "Object"
+		case macho.TypeExec:
+			return "Executable"
+		case macho.TypeDylib:
+			return "Dynamic Library"
+		case macho.TypeBundle:
+			return "Bundle"
+		default:
+			return "?"
+		}
+	}
+	cpuString := func(cpu macho.Cpu) string {
+		switch cpu {
+		case macho.Cpu386:
+			return "386"
+		case macho.CpuAmd64:
+			return "AMD64"
+		case macho.CpuArm:
+			return "ARM"
+		case macho.CpuArm | 0x01000000:
+			return "ARM64"
+		case macho.CpuPpc:
+			return "PPC"
+		case macho.CpuPpc64:
+			return "PPC64"
+		default:
+			return "?"
+		}
+	}
+	return fmt.Sprintf("%s (%s)", typeString(f.Type), cpuString(f.Cpu))
+}
+
+func (f *File) cpusubString() string {
+	return cpusubString(f.Cpu, f.SubCpu)
+}
+
+func cpusubString(cpu macho.Cpu, cpusub uint32) string {
+	var s string
+
+	switch cpu {
+	case macho.Cpu386:
+		s = fmt.Sprintf("%#08x (%s)", cpusub, CpuSubtypeX86(cpusub))
+	case macho.CpuAmd64:
+		if cpusub&CPU_SUBTYPE_LIB64 != 0 {
+			s = "0x80000000 (CPU_SUBTYPE_LIB64)\n"
+			cpusub ^= CPU_SUBTYPE_LIB64
+		}
+		s += fmt.Sprintf("%#08x (%s)", cpusub, CpuSubtypeX86_64(cpusub))
+	case macho.CpuArm:
+		s = fmt.Sprintf("%#08x (%s)", cpusub, CpuSubtypeARM(cpusub))
+	case macho.CpuArm | 0x01000000:
+		if cpusub&CPU_SUBTYPE_LIB64 != 0 {
+			s = "0x80000000 (CPU_SUBTYPE_LIB64)\n"
+			cpusub ^= CPU_SUBTYPE_LIB64
+		}
+		s += fmt.Sprintf("%#08x (%s)", cpusub, CpuSubtypeARM64(cpusub))
+	case macho.CpuPpc:
+		s = fmt.Sprintf("%#08x (%s)", cpusub, CpuSubtypePPC(cpusub))
+	case macho.CpuPpc64:
+		if cpusub&CPU_SUBTYPE_LIB64 != 0 {
+			s = "0x80000000 (CPU_SUBTYPE_LIB64)\n"
+			cpusub ^= CPU_SUBTYPE_LIB64
+		}
+		s += fmt.Sprintf("%#08x (%s)", cpusub, CpuSubtypePPC(cpusub))
+	default:
+		if cpusub&CPU_SUBTYPE_LIB64 != 0 {
+			s = "0x80000000 (CPU_SUBTYPE_LIB64)\n"
+			cpusub ^= CPU_SUBTYPE_LIB64
+		}
+		s += fmt.Sprintf("%#08x (?)", cpusub)
+	}
+	return s
+}
+
+func (_ *File) flagsString(f uint32, strtab []string) string {
+	var flags []string
+	for i := 0; f != 0; i++ {
+		if f&1 != 0 {
+			if i < len(strtab) {
+				flags = append(flags, fmt.Sprintf("%#08x (%s)", 1<<uint(i), strtab[i]))
+			} else {
+				// TODO warning
+				flags = append(flags, fmt.Sprintf("%#08x (?)", 1<<uint(i)))
+			}
+		}
+		f >>= 1
+	}
+	if len(flags) == 0 {
+		return "0x00000000"
+	}
+	return strings.Join(flags, "\n")
+}
+
+func (f *File) versionString(v uint32) string {
+	return fmt.Sprintf("%#08x (%d.%d.%d)", v, v>>16, (v>>8)&0xff, v&0xff)
+}
+
+func (f *File) vmprotString(prot uint32) string {
+	s := ""
+	if prot&4 != 0 {
+		s += "r"
+	} else {
+		s += "-"
+	}
+	if prot&2 != 0 {
+		s += "w"
+	} else {
+		s += "-"
+	}
+	if prot&1 != 0 {
+		s += "x"
+	} else {
+		s += "-"
+	}
+	return fmt.Sprintf("%#o (%s)", prot, s)
+}
+
+func (_ *File) SectionFlagsString(f uint32) string {
+	var flags []string
+
+	flags = append(flags, fmt.Sprintf("%#08x (%s)", f&SECTION_TYPE, SectionType(f&SECTION_TYPE)))
+
+	if f&SECTION_ATTRIBUTES_SYS != 0 {
+		if f&S_ATTR_LOC_RELOC != 0 {
+			flags = append(flags, "0x00000100 (S_ATTR_LOC_RELOC)")
+		}
+		if f&S_ATTR_EXT_RELOC != 0 {
+			flags = append(flags, "0x00000200 (S_ATTR_EXT_RELOC)")
+		}
+		if f&S_ATTR_SOME_INSTRUCTIONS != 0 {
+			flags = append(flags, "0x00000400 (S_ATTR_SOME_INSTRUCTIONS)")
+		}
+	}
+
+	if f&SECTION_ATTRIBUTES_USR != 0 {
+		if f&S_ATTR_DEBUG != 0 {
+			flags = append(flags, "0x02000000 (S_ATTR_DEBUG)")
+		}
+		if f&S_ATTR_SELF_MODIFYING_CODE != 0 {
+			flags = append(flags, "0x04000000 (S_ATTR_SELF_MODIFYING_CODE)")
+		}
+		if f&S_ATTR_LIVE_SUPPORT != 0 {
+			flags = append(flags, "0x08000000 (S_ATTR_LIVE_SUPPORT)")
+		}
+		if f&S_ATTR_NO_DEAD_STRIP != 0 {
+			flags = append(flags, "0x10000000 (S_ATTR_NO_DEAD_STRIP)")
+		}
+		if f&S_ATTR_STRIP_STATIC_SYMS != 0 {
+			flags = append(flags, "0x20000000 (S_ATTR_STRIP_STATIC_SYMS)")
+		}
+		if f&S_ATTR_NO_TOC != 0 {
+			flags = append(flags, "0x40000000 (S_ATTR_NO_TOC)")
+		}
+		if f&S_ATTR_PURE_INSTRUCTIONS != 0 {
+			flags = append(flags, "0x80000000 (S_ATTR_PURE_INSTRUCTIONS)")
+		}
+	}
+
+	return strings.Join(flags, "\n")
+}
@@ -0,0 +1,605 @@
+package macho_analysis
+
+// reference:
+// https://github.com/apple/swift/blob/main/include/swift/ABI/Metadata.h
+// https://github.com/apple/swift/blob/main/include/swift/ABI/MetadataValues.h
+// https://github.com/apple/swift/blob/main/include/swift/RemoteInspection/Records.h
+
+import (
+	"debug/macho"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var swiftContextKinds = map[uint32]string{
+	0:  "Module",
+	1:  "Extension",
+	2:  "Anonymous",
+	3:  "Protocol",
+	4:  "OpaqueType",
+	16: "Class",
+	17: "Struct",
+	18: "Enum",
+}
+
+var swiftFieldDescriptorKinds = map[uint16]string{
+	0: "Struct",
+	1: "Class",
+	2: "Enum",
+	3: "MultiPayloadEnum",
+	4: "Protocol",
+	5: "ClassProtocol",
+	6: "ObjCProtocol",
+	7: "ObjCClass",
+}
+
+const (
+	// field record flags
+
+	swiftFieldIsIndirectCase = 0x1
+	swiftFieldIsVar          = 0x2
+	swiftFieldIsArtificial   = 0x4
+)
+
+var swiftHeader = []string{"Address", "Kind", "Name", "Value"}
+
+// HasSwift reports whether f has reflection metadata of Swift 5.
+func (f *File) HasSwift() bool {
+	for _, sect := range f.Sections {
+		if strings.HasPrefix(sect.Name, "__swift5_") {
+			return true
+		}
+	}
+	return false
+}
+
+// Swift returns nominal types, protocols, conformances and field descriptors of the Swift runtime.
+// Fields and conformances of types are decoded when their children are requested.
+func (f *File) Swift() *DataTree {
+	t := &DataTree{Header: swiftHeader}
+
+	p := &swiftParser{
+		objcParser: &objcParser{
+			f:       f,
+			ptrSize: f.pointerSize(),
+			data:    make(map[*macho.Section][]byte),
+			externs: make(map[*macho.Section]map[uint64]*macho.Symbol),
+		},
+		conformances: make(map[uint64][]uint64),
+	}
+
+	// conformances are listed under their types
+	var confs []uint64
+	if sect := f.Section("__swift5_proto"); sect != nil {
+		for addr := sect.Addr; addr+4 <= sect.Addr+sect.Size; addr += 4 {
+			conf, ok := p.rel(addr)
+			if !ok {
+				// TODO warning
+				break
+			}
+			confs = append(confs, conf)
+			if desc, _, ok := p.conformanceType(conf); ok && desc != 0 {
+				p.conformances[desc] = append(p.conformances[desc], conf)
+			}
+		}
+	}
+
+	if sect := f.Section("__swift5_types"); sect != nil {
+		n := t.appendRow(Text("Types"), nil, nil, Text(fmt.Sprintf("%d", sect.Size/4)))
+		for addr := sect.Addr; addr+4 <= sect.Addr+sect.Size; addr += 4 {
+			desc, sym, ok := p.relIndirect(addr, 3)
+			if !ok {
+				// TODO warning
+				break
+			}
+			if sym != "" {
+				n.appendRow(p.addrValue(addr), nil, Text(f.symName(sym)), nil)
+				continue
+			}
+			n.Children = append(n.Children, p.typeNode(desc))
+		}
+	}
+
+	if sect := f.Section("__swift5_protos"); sect != nil {
+		n := t.appendRow(Text("Protocols"), nil, nil, Text(fmt.Sprintf("%d", sect.Size/4)))
+		for addr := sect.Addr; addr+4 <= sect.Addr+sect.Size; addr += 4 {
+			desc, sym, ok := p.relIndirect(addr, 3)
+			if !ok {
+				// TODO warning
+				break
+			}
+			if sym != "" {
+				n.appendRow(p.addrValue(addr), nil, Text(f.symName(sym)), nil)
+				continue
+			}
+			n.Children = append(n.Children, p.protocolNode(desc))
+		}
+	}
+
+	if len(confs) != 0 {
+		n := t.appendRow(Text("Conformances"), nil, nil, Text(fmt.Sprintf("%d", len(confs))))
+		for _, conf := range confs {
+			n.Children = append(n.Children, p.conformanceNode(conf))
+		}
+	}
+
+	if sect := f.Section("__swift5_fieldmd"); sect != nil {
+		n := t.appendRow(Text("Field Descriptors"), nil, nil, nil)
+		n.childrenFunc = func() []*DataNode {
+			var nodes []*DataNode
+			for addr := sect.Addr; addr+16 <= sect.Addr+sect.Size; {
+				node, size := p.fieldDescriptorNode(addr)
+				if node == nil {
+					// TODO warning
+					break
+				}
+				nodes = append(nodes, node)
+				addr += size
+			}
+			return nodes
+		}
+	}
+
+	if sect := f.Section("__swift5_typeref"); sect != nil {
+		n := t.appendRow(Text("Type References"), nil, nil, nil)
+		n.childrenFunc = func() []*DataNode {
+			var nodes []*DataNode
+			for addr := sect.Addr; addr < sect.Addr+sect.Size; {
+				name := p.mangledName(addr)
+				if name == "" {
+					addr++
+					continue
+				}
+				node := &DataNode{Values: []Value{p.addrValue(addr), Text("TypeRef"), Text(p.demangleType(addr, name)), Text(strconv.Quote(name))}}
+				nodes = append(nodes, node)
+				addr += uint64(len(name)) + 1
+			}
+			return nodes
+		}
+	}
+
+	if sect := f.Section("__swift5_reflstr"); sect != nil {
+		n := t.appendRow(Text("Reflection Strings"), nil, nil, nil)
+		n.childrenFunc = func() []*DataNode {
+			var nodes []*DataNode
+			for addr := sect.Addr; addr < sect.Addr+sect.Size; {
+				s := p.cstring(addr)
+				if s == "" {
+					addr++
+					continue
+				}
+				nodes = append(nodes, &DataNode{Values: []Value{p.addrValue(addr), Text("String"), Text(s), nil}})
+				addr += uint64(len(s)) + 1
+			}
+			return nodes
+		}
+	}
+
+	return t
+}
+
+type swiftParser struct {
+	*objcParser
+	conformances map[uint64][]uint64 // type context descriptor -> conformance descriptors
+}
+
+// rel returns the target of the relative direct pointer at addr.
+func (p *swiftParser) rel(addr uint64) (uint64, bool) {
+	off, ok := p.int32(addr)
+	if !ok {
+		return 0, false
+	}
+	return addr + uint64(int64(off)), true
+}
+
+// relIndirect returns the target of the relative pointer at addr, whose low bit tells that the target is a pointer.
+// Bits of mask are flags.
+func (p *swiftParser) relIndirect(addr uint64, mask int32) (target uint64, sym string, ok bool) {
+	off, ok := p.int32(addr)
+	if !ok {
+		return 0, "", false
+	}
+	target = addr + uint64(int64(off&^mask))
+	if off&1 == 0 {
+		return target, "", true
+	}
+	return p.pointer(target)
+}
+
+// relString returns the string pointed by the relative pointer at addr.
+func (p *swiftParser) relString(addr uint64) string {
+	off, ok := p.int32(addr)
+	if !ok || off == 0 {
+		return ""
+	}
+	return p.cstring(addr + uint64(int64(off)))
+}
+
+// mangledName returns the mangled name at addr, which may have symbolic references.
+func (p *swiftParser) mangledName(addr uint64) string {
+	sect := p.section(addr)
+	if sect == nil {
+		return ""
+	}
+	b := p.read(addr, sect.Addr+sect.Size-addr)
+	i := 0
+	for i < len(b) && b[i] != 0 {
+		switch c := b[i]; {
+		case 0x01 <= c && c <= 0x17:
+			i += 5
+		case 0x18 <= c && c <= 0x1f:
+			i += 1 + int(p.ptrSize)
+		default:
+			i++
+		}
+	}
+	if i > len(b) {
+		return ""
+	}
+	return string(b[:i])
+}
+
+// demangleType demangles the mangled name at addr.
+func (p *swiftParser) demangleType(addr uint64, name string) string {
+	s, ok := swiftDemangleType(name, func(kind byte, pos int) *swiftNode {
+		return p.symbolicNode(kind, addr+uint64(pos))
+	})
+	if !ok {
+		return strconv.Quote(name)
+	}
+	return s
+}
+
+// relTypeName demangles the mangled name pointed by the relative pointer at addr.
+func (p *swiftParser) relTypeName(addr uint64) string {
+	off, ok := p.int32(addr)
+	if !ok || off == 0 {
+		return ""
+	}
+	target := addr + uint64(int64(off))
+	return p.demangleType(target, p.mangledName(target))
+}
+
+// symbolicNode resolves the symbolic reference at addr.
+func (p *swiftParser) symbolicNode(kind byte, addr uint64) *swiftNode {
+	switch kind {
+	case 0x01, 0x02: // context descriptor
+		var desc uint64
+		var sym string
+		var ok bool
+		if kind == 0x01 {
+			desc, ok = p.rel(addr)
+		} else {
+			var target uint64
+			target, ok = p.rel(addr)
+			if ok {
+				desc, sym, ok = p.pointer(target)
+			}
+		}
+		if !ok {
+			return nil
+		}
+		if sym != "" {
+			if n := swiftDescriptorSymbolNode(sym); n != nil {
+				return n
+			}
+			return &swiftNode{kind: swiftSymbolic, text: p.f.symName(sym)}
+		}
+		return &swiftNode{kind: swiftSymbolic, text: p.contextName(desc)}
+	}
+	// TODO accessor functions and unique extended existential shapes
+	return &swiftNode{kind: swiftSymbolic, text: fmt.Sprintf("symbolic reference %#x to %#x", kind, addr)}
+}
+
+// swiftDescriptorSymbolNode returns the type of the descriptor symbol, or nil.
+func swiftDescriptorSymbolNode(sym string) *swiftNode {
+	n := swiftDemangleSymbol(sym)
+	if n == nil || n.kind != swiftPrefixed {
+		return nil
+	}
+	switch n.text {
+	case "nominal type descriptor for ", "protocol descriptor for ":
+		return n.children[0]
+	}
+	return nil
+}
+
+// contextName returns the qualified name of the context descriptor at addr.
+func (p *swiftParser) contextName(addr uint64) string {
+	var names []string
+	for depth := 0; addr != 0 && depth < 16; depth++ {
+		flags, ok := p.uint32(addr)
+		if !ok {
+			break
+		}
+		var name string
+		switch flags & 0x1f {
+		case 1: // extension
+			name = p.relTypeName(addr + 8)
+		case 2: // anonymous
+		default:
+			name = p.relString(addr + 8)
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+		if flags&0x1f == 0 {
+			// modules are the root
+			break
+		}
+		parent, sym, ok := p.relIndirect(addr+4, 1)
+		if !ok || parent == addr+4 || parent == addr {
+			break
+		}
+		if sym != "" {
+			if n := swiftDescriptorSymbolNode(sym); n != nil {
+				names = append(names, swiftString(n))
+			} else {
+				names = append(names, p.f.symName(sym))
+			}
+			break
+		}
+		if flags&0x1f == 1 {
+			// the extended type is qualified
+			break
+		}
+		addr = parent
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, ".")
+}
+
+// contextValue returns the link to the context descriptor pointed by the relative pointer at addr.
+func (p *swiftParser) contextValue(addr uint64, mask int32) Value {
+	desc, sym, ok := p.relIndirect(addr, mask)
+	switch {
+	case !ok:
+		return nil
+	case sym != "":
+		return Text(p.f.symName(sym))
+	}
+	return Value{}.appendText(p.contextName(desc)+" (").appendLink(fmt.Sprintf("%#x", desc), addressLink(desc, 0)).appendText(")")
+}
+
+func (p *swiftParser) flagsRow(n *DataNode, addr uint64) (flags uint32) {
+	flags, _ = p.uint32(addr)
+	kind := swiftContextKinds[flags&0x1f]
+	if kind == "" {
+		kind = "?"
+	}
+	var opts []string
+	if flags&0x80 != 0 {
+		opts = append(opts, "generic")
+	}
+	if flags&0x40 != 0 {
+		opts = append(opts, "unique")
+	}
+	s := fmt.Sprintf("%#08x (%s", flags, kind)
+	if len(opts) != 0 {
+		s += ", " + strings.Join(opts, ", ")
+	}
+	n.appendRow(p.addrValue(addr), Text("Flags"), nil, Text(s+")"))
+	return flags
+}
+
+// typeNode decodes the type context descriptor at addr.
+// Class is {flags, parent, name, accessFunction, fields, superclassType, metadataNegativeSizeInWords, metadataPositiveSizeInWords, numImmediateMembers, numFields, fieldOffsetVectorOffset}
+// Struct is {flags, parent, name, accessFunction, fields, numFields, fieldOffsetVectorOffset}
+// Enum is {flags, parent, name, accessFunction, fields, numPayloadCasesAndPayloadSizeOffset, numEmptyCases}
+func (p *swiftParser) typeNode(addr uint64) *DataNode {
+	flags, _ := p.uint32(addr)
+	kind := swiftContextKinds[flags&0x1f]
+	if kind == "" {
+		kind = "?"
+	}
+
+	var value Value
+	switch flags & 0x1f {
+	case 16:
+		if s := p.relTypeName(addr + 20); s != "" {
+			value = Text(": " + s)
+		}
+	case 17:
+		if num, ok := p.uint32(addr + 20); ok {
+			value = Text(fmt.Sprintf("%d fields", num))
+		}
+	case 18:
+		payload, ok1 := p.uint32(addr + 20)
+		empty, ok2 := p.uint32(addr + 24)
+		if ok1 && ok2 {
+			value = Text(fmt.Sprintf("%d cases", payload&0xffffff+empty))
+		}
+	}
+
+	n := &DataNode{Values: []Value{p.addrValue(addr), Text(kind), Text(p.contextName(addr)), value}}
+	n.childrenFunc = func() []*DataNode {
+		n := &DataNode{}
+
+		flags := p.flagsRow(n, addr)
+		n.appendRow(p.addrValue(addr+4), Text("Parent"), nil, p.contextValue(addr+4, 1))
+		n.appendRow(p.addrValue(addr+8), Text("Name"), Text(p.relString(addr+8)), nil)
+		if access, ok := p.rel(addr + 12); ok && access != addr+12 {
+			n.appendRow(p.addrValue(addr+12), Text("Access Function"), nil, p.f.addrSymValue(access, 0))
+		}
+
+		switch flags & 0x1f {
+		case 16:
+			if s := p.relTypeName(addr + 20); s != "" {
+				n.appendRow(p.addrValue(addr+20), Text("Superclass"), Text(s), nil)
+			}
+		case 18:
+			payload, _ := p.uint32(addr + 20)
+			empty, _ := p.uint32(addr + 24)
+			n.appendRow(p.addrValue(addr+20), Text("Payload Cases"), nil, Text(fmt.Sprintf("%d", payload&0xffffff)))
+			n.appendRow(p.addrValue(addr+24), Text("Empty Cases"), nil, Text(fmt.Sprintf("%d", empty)))
+		}
+
+		if fields, ok := p.rel(addr + 16); ok && fields != addr+16 {
+			if fd, _ := p.fieldDescriptorNode(fields); fd != nil {
+				fd.Values[1] = Text("Fields")
+				n.Children = append(n.Children, fd)
+			}
+		}
+
+		if confs := p.conformances[addr]; len(confs) != 0 {
+			c := n.appendRow(nil, Text("Conformances"), nil, Text(fmt.Sprintf("%d", len(confs))))
+			for _, conf := range confs {
+				c.Children = append(c.Children, p.conformanceNode(conf))
+			}
+		}
+
+		return n.Children
+	}
+	return n
+}
+
+// protocolNode decodes the protocol descriptor at addr.
+// Protocol is {flags, parent, name, numRequirementsInSignature, numRequirements, associatedTypeNames}
+func (p *swiftParser) protocolNode(addr uint64) *DataNode {
+	num, _ := p.uint32(addr + 16)
+	n := &DataNode{Values: []Value{p.addrValue(addr), Text("Protocol"), Text(p.contextName(addr)), Text(fmt.Sprintf("%d requirements", num))}}
+	n.childrenFunc = func() []*DataNode {
+		n := &DataNode{}
+		p.flagsRow(n, addr)
+		n.appendRow(p.addrValue(addr+4), Text("Parent"), nil, p.contextValue(addr+4, 1))
+		n.appendRow(p.addrValue(addr+8), Text("Name"), Text(p.relString(addr+8)), nil)
+		if assoc := p.relString(addr + 20); assoc != "" {
+			n.appendRow(p.addrValue(addr+20), Text("Associated Types"), Text(assoc), nil)
+		}
+		return n.Children
+	}
+	return n
+}
+
+// conformanceType returns the type of the conformance descriptor at addr.
+// If the type is an Objective-C class, name is the class name.
+func (p *swiftParser) conformanceType(addr uint64) (desc uint64, name string, ok bool) {
+	flags, ok := p.uint32(addr + 12)
+	if !ok {
+		return 0, "", false
+	}
+	target, ok := p.rel(addr + 4)
+	if !ok {
+		return 0, "", false
+	}
+	switch (flags >> 3) & 7 {
+	case 0: // direct type descriptor
+		return target, "", true
+	case 1: // indirect type descriptor
+		desc, sym, ok := p.pointer(target)
+		if sym != "" {
+			if n := swiftDescriptorSymbolNode(sym); n != nil {
+				return 0, swiftString(n), ok
+			}
+			return 0, p.f.symName(sym), ok
+		}
+		return desc, "", ok
+	case 2: // direct ObjC class name
+		return 0, p.cstring(target), true
+	case 3: // indirect ObjC class
+		return 0, p.classRefName(target), true
+	}
+	return 0, "", false
+}
+
+// conformanceNode decodes the protocol conformance descriptor at addr.
+// ProtocolConformance is {protocol, typeRef, witnessTablePattern, flags}
+func (p *swiftParser) conformanceNode(addr uint64) *DataNode {
+	typ := "?"
+	if desc, name, ok := p.conformanceType(addr); ok {
+		if desc != 0 {
+			typ = p.contextName(desc)
+		} else {
+			typ = name
+		}
+	}
+
+	proto := "?"
+	if desc, sym, ok := p.relIndirect(addr, 1); ok {
+		if sym != "" {
+			if n := swiftDescriptorSymbolNode(sym); n != nil {
+				proto = swiftString(n)
+			} else {
+				proto = p.f.symName(sym)
+			}
+		} else {
+			proto = p.contextName(desc)
+		}
+	}
+
+	var witness Value
+	if wt, ok := p.rel(addr + 8); ok && wt != addr+8 {
+		witness = p.f.addrSymValue(wt, 0)
+	}
+
+	n := &DataNode{Values: []Value{p.addrValue(addr), Text("Conformance"), Text(typ + " : " + proto), witness}}
+	n.childrenFunc = func() []*DataNode {
+		n := &DataNode{}
+		n.appendRow(p.addrValue(addr), Text("Protocol"), nil, p.contextValue(addr, 1))
+		if desc, _, ok := p.conformanceType(addr); ok && desc != 0 {
+			n.appendRow(p.addrValue(addr+4), Text("Type"), nil, Value{}.appendText(typ+" (").appendLink(fmt.Sprintf("%#x", desc), addressLink(desc, 0)).appendText(")"))
+		} else {
+			n.appendRow(p.addrValue(addr+4), Text("Type"), Text(typ), nil)
+		}
+		n.appendRow(p.addrValue(addr+8), Text("Witness Table"), nil, witness)
+		flags, _ := p.uint32(addr + 12)
+		n.appendRow(p.addrValue(addr+12), Text("Flags"), nil, Text(fmt.Sprintf("%#08x", flags)))
+		return n.Children
+	}
+	return n
+}
+
+// fieldDescriptorNode decodes the field descriptor at addr, and returns the node and the size of the descriptor.
+// FieldDescriptor is {mangledTypeName, superclass, kind, fieldRecordSize, numFields}
+// FieldRecord is {flags, mangledTypeName, fieldName}
+func (p *swiftParser) fieldDescriptorNode(addr uint64) (*DataNode, uint64) {
+	b := p.read(addr, 16)
+	if b == nil {
+		return nil, 0
+	}
+	kind := p.f.ByteOrder.Uint16(b[8:])
+	recSize := uint64(p.f.ByteOrder.Uint16(b[10:]))
+	num := uint64(p.f.ByteOrder.Uint32(b[12:]))
+	if recSize < 12 {
+		recSize = 12
+	}
+
+	kindName := swiftFieldDescriptorKinds[kind]
+	if kindName == "" {
+		kindName = "?"
+	}
+	n := &DataNode{Values: []Value{p.addrValue(addr), Text(kindName), Text(p.relTypeName(addr)), Text(fmt.Sprintf("%d", num))}}
+	if s := p.relTypeName(addr + 4); s != "" {
+		n.appendRow(p.addrValue(addr+4), Text("Superclass"), Text(s), nil)
+	}
+
+	isEnum := kind == 2 || kind == 3
+	for i := uint64(0); i < num; i++ {
+		rec := addr + 16 + i*recSize
+		flags, ok := p.uint32(rec)
+		if !ok {
+			// TODO warning
+			break
+		}
+		var k string
+		switch {
+		case isEnum && flags&swiftFieldIsIndirectCase != 0:
+			k = "indirect case"
+		case isEnum:
+			k = "case"
+		case flags&swiftFieldIsVar != 0:
+			k = "var"
+		default:
+			k = "let"
+		}
+		if flags&swiftFieldIsArtificial != 0 {
+			k += " (artificial)"
+		}
+		n.appendRow(p.addrValue(rec), Text(k), Text(p.relString(rec+8)), Text(p.relTypeName(rec+4)))
+	}
+
+	return n, 16 + num*recSize
+}
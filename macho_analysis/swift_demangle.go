@@ -0,0 +1,1465 @@
+package macho_analysis
+
+// reference:
+// https://github.com/apple/swift/blob/main/docs/ABI/Mangling.rst
+// https://github.com/apple/swift/blob/main/lib/Demangling/Demangler.cpp
+// https://github.com/apple/swift/blob/main/lib/Demangling/NodePrinter.cpp
+//
+// This is a subset of the Swift demangler, which covers symbols typically found in binaries.
+// Names which aren't understood are left mangled, e.g. punycode identifiers, opaque types other than 'Qr',
+// sized layout constraints, parameter packs and most thunks.
+
+import (
+	"fmt"
+	"strings"
+)
+
+type swiftKind int
+
+const (
+	swiftIdentifier swiftKind = iota
+	swiftLocalName
+	swiftPrivateName
+	swiftEmptyList
+	swiftFirstElement
+	swiftThrows
+	swiftAsync
+	swiftGenericSignature
+	swiftRequirement
+
+	// contexts
+
+	swiftModule
+	swiftExtension
+
+	// types
+
+	swiftClass
+	swiftStruct
+	swiftEnum
+	swiftProtocol
+	swiftTypeAlias
+	swiftBoundGeneric
+	swiftOptional
+	swiftTuple
+	swiftFunctionType
+	swiftMetatype
+	swiftExistential
+	swiftGenericParam
+	swiftBuiltin
+	swiftInOut
+	swiftDependentMember
+	swiftOpaqueReturn
+	swiftSymbolic
+
+	// entities
+
+	swiftFunction
+	swiftVariable
+	swiftSubscript
+	swiftAllocator
+	swiftConstructor
+	swiftDeallocator
+	swiftDestructor
+	swiftClosure
+	swiftAccessor
+	swiftStatic
+
+	// globals
+
+	swiftPrefixed // "<text> <child>", e.g. type metadata for T
+	swiftConformance
+	swiftWitness
+)
+
+type swiftNode struct {
+	kind     swiftKind
+	text     string
+	index    int
+	children []*swiftNode
+}
+
+func (n *swiftNode) isType() bool {
+	return swiftClass <= n.kind && n.kind <= swiftSymbolic
+}
+
+func (n *swiftNode) isContext() bool {
+	return n.kind == swiftModule || n.kind == swiftExtension || n.isType() || n.isEntity()
+}
+
+func (n *swiftNode) isEntity() bool {
+	return swiftFunction <= n.kind && n.kind <= swiftStatic
+}
+
+func (n *swiftNode) isName() bool {
+	return n.kind == swiftIdentifier || n.kind == swiftLocalName || n.kind == swiftPrivateName
+}
+
+type swiftDemangler struct {
+	text  string
+	pos   int
+	stack []*swiftNode
+	subst []*swiftNode
+	words []string
+
+	// symbolic resolves a symbolic reference of the kind at pos, which is followed by the reference.
+	symbolic func(kind byte, pos int) *swiftNode
+}
+
+// SwiftDemangle returns the demangled name of the Swift symbol, or false if the name isn't understood.
+func SwiftDemangle(name string) (string, bool) {
+	n := swiftDemangleSymbol(name)
+	if n == nil {
+		return name, false
+	}
+	return swiftString(n), true
+}
+
+func swiftDemangleSymbol(name string) *swiftNode {
+	text, ok := swiftManglingBody(name)
+	if !ok {
+		return nil
+	}
+	d := &swiftDemangler{text: text}
+	return d.demangle()
+}
+
+// swiftDemangleType demangles the type mangling of metadata, which has no prefix and may have symbolic references.
+func swiftDemangleType(name string, symbolic func(kind byte, pos int) *swiftNode) (string, bool) {
+	d := &swiftDemangler{text: name, symbolic: symbolic}
+	n := d.demangle()
+	if n == nil || !n.isType() {
+		return name, false
+	}
+	return swiftString(n), true
+}
+
+// swiftManglingBody strips the mangling prefix.
+func swiftManglingBody(name string) (string, bool) {
+	for _, prefix := range []string{"_$s", "$s", "_$S", "$S", "_T0"} {
+		if strings.HasPrefix(name, prefix) {
+			return name[len(prefix):], true
+		}
+	}
+	return "", false
+}
+
+// demangle returns the only node of the text, or nil.
+func (d *swiftDemangler) demangle() (n *swiftNode) {
+	defer func() {
+		if recover() != nil {
+			n = nil
+		}
+	}()
+
+	for d.pos < len(d.text) {
+		n := d.operator()
+		if n == nil {
+			return nil
+		}
+		d.push(n)
+	}
+
+	if len(d.stack) != 1 {
+		return nil
+	}
+	n = d.stack[0]
+	if n.kind == swiftIdentifier || n.kind == swiftEmptyList || n.kind == swiftFirstElement {
+		return nil
+	}
+	return n
+}
+
+func (d *swiftDemangler) push(n *swiftNode) {
+	d.stack = append(d.stack, n)
+}
+
+func (d *swiftDemangler) top() *swiftNode {
+	if len(d.stack) == 0 {
+		return nil
+	}
+	return d.stack[len(d.stack)-1]
+}
+
+func (d *swiftDemangler) pop() *swiftNode {
+	n := d.top()
+	if n != nil {
+		d.stack = d.stack[:len(d.stack)-1]
+	}
+	return n
+}
+
+// popIf pops the top node if it matches pred.
+func (d *swiftDemangler) popIf(pred func(n *swiftNode) bool) *swiftNode {
+	if n := d.top(); n != nil && pred(n) {
+		return d.pop()
+	}
+	return nil
+}
+
+func (d *swiftDemangler) popKind(kind swiftKind) *swiftNode {
+	return d.popIf(func(n *swiftNode) bool { return n.kind == kind })
+}
+
+func (d *swiftDemangler) popType() *swiftNode {
+	return d.popIf((*swiftNode).isType)
+}
+
+// popContext pops a context. An identifier is a module.
+func (d *swiftDemangler) popContext() *swiftNode {
+	if n := d.popKind(swiftIdentifier); n != nil {
+		return &swiftNode{kind: swiftModule, text: n.text}
+	}
+	return d.popIf((*swiftNode).isContext)
+}
+
+func (d *swiftDemangler) peek() byte {
+	if d.pos < len(d.text) {
+		return d.text[d.pos]
+	}
+	return 0
+}
+
+func (d *swiftDemangler) next() byte {
+	c := d.peek()
+	d.pos++
+	return c
+}
+
+func (d *swiftDemangler) nextIf(c byte) bool {
+	if d.peek() == c {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isLower(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
+
+func isUpper(c byte) bool {
+	return 'A' <= c && c <= 'Z'
+}
+
+// natural returns a decimal number, or -1 if there is no digit.
+func (d *swiftDemangler) natural() int {
+	if !isDigit(d.peek()) {
+		return -1
+	}
+	n := 0
+	for isDigit(d.peek()) {
+		n = n*10 + int(d.next()-'0')
+		if n > 1<<20 {
+			return -1
+		}
+	}
+	return n
+}
+
+// index returns '_' as 0, and N '_' as N+1.
+func (d *swiftDemangler) index() int {
+	if d.nextIf('_') {
+		return 0
+	}
+	n := d.natural()
+	if n < 0 || !d.nextIf('_') {
+		return -1
+	}
+	return n + 1
+}
+
+func (d *swiftDemangler) operator() *swiftNode {
+	c := d.next()
+
+	switch {
+	case isDigit(c):
+		d.pos--
+		return d.identifier()
+	case 0x01 <= c && c <= 0x1f:
+		if d.symbolic == nil {
+			return nil
+		}
+		n := d.symbolic(c, d.pos)
+		if c <= 0x17 {
+			d.pos += 4
+		} else {
+			d.pos += 8
+		}
+		if n != nil {
+			d.subst = append(d.subst, n)
+		}
+		return n
+	}
+
+	switch c {
+	case 'A':
+		return d.multiSubstitution()
+	case 'B':
+		return d.builtin()
+	case 'C':
+		return d.nominal(swiftClass)
+	case 'D':
+		// type mangling
+		if t := d.top(); t == nil || !t.isType() {
+			return nil
+		}
+		return d.pop()
+	case 'E':
+		d.popKind(swiftGenericSignature)
+		typ := d.popType()
+		mod := d.popContext()
+		if typ == nil || mod == nil {
+			return nil
+		}
+		return &swiftNode{kind: swiftExtension, children: []*swiftNode{mod, typ}}
+	case 'F':
+		return d.entity(swiftFunction, true)
+	case 'G':
+		return d.boundGeneric()
+	case 'K':
+		return &swiftNode{kind: swiftThrows}
+	case 'L':
+		return d.localName()
+	case 'M':
+		return d.metadata()
+	case 'N':
+		return d.prefixed("type metadata for ", d.popType())
+	case 'O':
+		return d.nominal(swiftEnum)
+	case 'P':
+		return d.nominal(swiftProtocol)
+	case 'Q':
+		return d.archetype()
+	case 'R':
+		return d.requirement()
+	case 'S':
+		return d.standardSubstitution()
+	case 'T':
+		return d.thunk()
+	case 'V':
+		return d.nominal(swiftStruct)
+	case 'W':
+		return d.witness()
+	case 'Y':
+		if d.nextIf('a') {
+			return &swiftNode{kind: swiftAsync}
+		}
+		return nil
+	case 'Z':
+		e := d.popIf((*swiftNode).isEntity)
+		if e == nil {
+			return nil
+		}
+		return &swiftNode{kind: swiftStatic, children: []*swiftNode{e}}
+	case 'a':
+		return d.nominal(swiftTypeAlias)
+	case 'c':
+		return d.signature()
+	case 'f':
+		return d.functionEntity()
+	case 'i':
+		e := d.entity(swiftSubscript, true)
+		if e == nil {
+			return nil
+		}
+		return d.accessor(e)
+	case 'l':
+		return d.genericSignature(false)
+	case 'm':
+		t := d.popType()
+		if t == nil {
+			return nil
+		}
+		return &swiftNode{kind: swiftMetatype, children: []*swiftNode{t}}
+	case 'o':
+		name := d.popKind(swiftIdentifier)
+		if name == nil {
+			return nil
+		}
+		switch d.next() {
+		case 'i', 'p', 'P':
+		default:
+			return nil
+		}
+		return name
+	case 'p':
+		return d.existential()
+	case 'q':
+		return d.genericParamIndex()
+	case 'r':
+		return d.genericSignature(true)
+	case 's':
+		return &swiftNode{kind: swiftModule, text: "Swift"}
+	case 't':
+		return d.tuple()
+	case 'u':
+		// the generic signature of the type is omitted
+		t := d.popType()
+		if d.popKind(swiftGenericSignature) == nil || t == nil {
+			return nil
+		}
+		return t
+	case 'v':
+		e := d.entity(swiftVariable, false)
+		if e == nil {
+			return nil
+		}
+		return d.accessor(e)
+	case 'w':
+		return d.valueWitness()
+	case 'x':
+		return d.genericParam(0, 0)
+	case 'y':
+		return &swiftNode{kind: swiftEmptyList}
+	case 'z':
+		t := d.popType()
+		if t == nil {
+			return nil
+		}
+		return &swiftNode{kind: swiftInOut, children: []*swiftNode{t}}
+	case '_':
+		return &swiftNode{kind: swiftFirstElement}
+	}
+
+	return nil
+}
+
+func (d *swiftDemangler) identifier() *swiftNode {
+	words := false
+	if d.nextIf('0') {
+		if d.peek() == '0' {
+			return nil // TODO punycode
+		}
+		words = true
+	}
+
+	var ident string
+	for {
+		for words && (isLower(d.peek()) || isUpper(d.peek())) {
+			c := d.next()
+			var i int
+			if isLower(c) {
+				i = int(c - 'a')
+			} else {
+				i = int(c - 'A')
+				words = false
+			}
+			if i >= len(d.words) {
+				return nil
+			}
+			ident += d.words[i]
+		}
+		if d.nextIf('0') {
+			break
+		}
+		n := d.natural()
+		if n <= 0 || len(d.text) < d.pos+n {
+			return nil
+		}
+		s := d.text[d.pos : d.pos+n]
+		d.pos += n
+		ident += s
+		d.addWords(s)
+		if !words {
+			break
+		}
+	}
+
+	if ident == "" {
+		return nil
+	}
+
+	n := &swiftNode{kind: swiftIdentifier, text: ident}
+	d.subst = append(d.subst, n)
+	return n
+}
+
+// addWords records words of s, which are referred by later identifiers.
+func (d *swiftDemangler) addWords(s string) {
+	isStart := func(c byte) bool { return !isDigit(c) && c != '_' && c != 0 }
+	isEnd := func(c, prev byte) bool { return c == '_' || c == 0 || !isUpper(prev) && isUpper(c) }
+
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		var c byte
+		if i < len(s) {
+			c = s[i]
+		}
+		if start >= 0 && isEnd(c, s[i-1]) {
+			if i-start >= 2 && len(d.words) < 26 {
+				d.words = append(d.words, s[start:i])
+			}
+			start = -1
+		}
+		if start < 0 && isStart(c) {
+			start = i
+		}
+	}
+}
+
+func (d *swiftDemangler) multiSubstitution() *swiftNode {
+	repeat := -1
+	for {
+		c := d.next()
+		switch {
+		case c == 0:
+			return nil
+		case isLower(c):
+			n := d.substitution(int(c - 'a'))
+			if n == nil {
+				return nil
+			}
+			for ; repeat > 1; repeat-- {
+				d.push(n)
+			}
+			d.push(n)
+			repeat = -1
+		case isUpper(c):
+			n := d.substitution(int(c - 'A'))
+			if n == nil {
+				return nil
+			}
+			for ; repeat > 1; repeat-- {
+				d.push(n)
+			}
+			return n
+		case c == '_':
+			return d.substitution(repeat + 27)
+		default:
+			d.pos--
+			repeat = d.natural()
+			if repeat < 0 {
+				return nil
+			}
+		}
+	}
+}
+
+func (d *swiftDemangler) substitution(i int) *swiftNode {
+	if i < 0 || len(d.subst) <= i {
+		return nil
+	}
+	return d.subst[i]
+}
+
+var swiftStandardTypes = map[byte]struct {
+	kind swiftKind
+	name string
+}{
+	'A': {swiftStruct, "AutoreleasingUnsafeMutablePointer"},
+	'a': {swiftStruct, "Array"},
+	'b': {swiftStruct, "Bool"},
+	'D': {swiftStruct, "Dictionary"},
+	'd': {swiftStruct, "Double"},
+	'f': {swiftStruct, "Float"},
+	'h': {swiftStruct, "Set"},
+	'I': {swiftStruct, "DefaultIndices"},
+	'i': {swiftStruct, "Int"},
+	'J': {swiftStruct, "Character"},
+	'N': {swiftStruct, "ClosedRange"},
+	'n': {swiftStruct, "Range"},
+	'O': {swiftStruct, "ObjectIdentifier"},
+	'P': {swiftStruct, "UnsafePointer"},
+	'p': {swiftStruct, "UnsafeMutablePointer"},
+	'R': {swiftStruct, "UnsafeBufferPointer"},
+	'r': {swiftStruct, "UnsafeMutableBufferPointer"},
+	'S': {swiftStruct, "String"},
+	's': {swiftStruct, "Substring"},
+	'u': {swiftStruct, "UInt"},
+	'V': {swiftStruct, "UnsafeRawPointer"},
+	'v': {swiftStruct, "UnsafeMutableRawPointer"},
+	'W': {swiftStruct, "UnsafeRawBufferPointer"},
+	'w': {swiftStruct, "UnsafeMutableRawBufferPointer"},
+	'q': {swiftEnum, "Optional"},
+	'B': {swiftProtocol, "BinaryFloatingPoint"},
+	'E': {swiftProtocol, "Encodable"},
+	'e': {swiftProtocol, "Decodable"},
+	'F': {swiftProtocol, "FloatingPoint"},
+	'G': {swiftProtocol, "RandomNumberGenerator"},
+	'H': {swiftProtocol, "Hashable"},
+	'j': {swiftProtocol, "Numeric"},
+	'K': {swiftProtocol, "BidirectionalCollection"},
+	'k': {swiftProtocol, "RandomAccessCollection"},
+	'L': {swiftProtocol, "Comparable"},
+	'l': {swiftProtocol, "Collection"},
+	'M': {swiftProtocol, "MutableCollection"},
+	'm': {swiftProtocol, "RangeReplaceableCollection"},
+	'Q': {swiftProtocol, "Equatable"},
+	'T': {swiftProtocol, "Sequence"},
+	't': {swiftProtocol, "IteratorProtocol"},
+	'U': {swiftProtocol, "UnsignedInteger"},
+	'X': {swiftProtocol, "RangeExpression"},
+	'x': {swiftProtocol, "Strideable"},
+	'Y': {swiftProtocol, "RawRepresentable"},
+	'y': {swiftProtocol, "StringProtocol"},
+	'Z': {swiftProtocol, "SignedInteger"},
+	'z': {swiftProtocol, "BinaryInteger"},
+}
+
+func (d *swiftDemangler) standardSubstitution() *swiftNode {
+	switch d.peek() {
+	case 'o':
+		d.pos++
+		return &swiftNode{kind: swiftModule, text: "__C"}
+	case 'C':
+		d.pos++
+		return &swiftNode{kind: swiftModule, text: "__C_Synthesized"}
+	case 'g':
+		d.pos++
+		t := d.popType()
+		if t == nil {
+			return nil
+		}
+		n := &swiftNode{kind: swiftOptional, children: []*swiftNode{t}}
+		d.subst = append(d.subst, n)
+		return n
+	}
+
+	repeat := 1
+	if isDigit(d.peek()) {
+		repeat = d.natural()
+	}
+	std, ok := swiftStandardTypes[d.next()]
+	if !ok {
+		return nil
+	}
+	n := &swiftNode{kind: std.kind, text: std.name, children: []*swiftNode{{kind: swiftModule, text: "Swift"}}}
+	for ; repeat > 1; repeat-- {
+		d.push(n)
+	}
+	return n
+}
+
+func (d *swiftDemangler) builtin() *swiftNode {
+	var name string
+	switch d.next() {
+	case 'b':
+		name = "Builtin.BridgeObject"
+	case 'B':
+		name = "Builtin.UnsafeValueBuffer"
+	case 'O':
+		name = "Builtin.UnknownObject"
+	case 'o':
+		name = "Builtin.NativeObject"
+	case 'p':
+		name = "Builtin.RawPointer"
+	case 't':
+		name = "Builtin.SILToken"
+	case 'w':
+		name = "Builtin.Word"
+	case 'f':
+		n := d.index()
+		if n <= 0 {
+			return nil
+		}
+		name = fmt.Sprintf("Builtin.FPIEEE%d", n-1)
+	case 'i':
+		n := d.index()
+		if n <= 0 {
+			return nil
+		}
+		name = fmt.Sprintf("Builtin.Int%d", n-1)
+	default:
+		return nil
+	}
+	return &swiftNode{kind: swiftBuiltin, text: name}
+}
+
+func (d *swiftDemangler) nominal(kind swiftKind) *swiftNode {
+	name := d.popIf((*swiftNode).isName)
+	ctx := d.popContext()
+	if name == nil || ctx == nil {
+		return nil
+	}
+	n := &swiftNode{kind: kind, text: swiftNameString(name), children: []*swiftNode{ctx}}
+	d.subst = append(d.subst, n)
+	return n
+}
+
+func (d *swiftDemangler) localName() *swiftNode {
+	if d.nextIf('L') {
+		// private discriminator
+		disc := d.popKind(swiftIdentifier)
+		name := d.popKind(swiftIdentifier)
+		if disc == nil || name == nil {
+			return nil
+		}
+		return &swiftNode{kind: swiftPrivateName, text: name.text}
+	}
+	i := d.index()
+	name := d.popKind(swiftIdentifier)
+	if i < 0 || name == nil {
+		return nil
+	}
+	return &swiftNode{kind: swiftLocalName, text: name.text, index: i}
+}
+
+func (d *swiftDemangler) boundGeneric() *swiftNode {
+	var args []*swiftNode
+	for {
+		var list []*swiftNode
+		for t := d.popType(); t != nil; t = d.popType() {
+			list = append([]*swiftNode{t}, list...)
+		}
+		args = append(list, args...)
+		if d.popKind(swiftEmptyList) != nil {
+			break
+		}
+		if d.popKind(swiftFirstElement) == nil {
+			return nil
+		}
+	}
+	nominal := d.popType()
+	if nominal == nil {
+		return nil
+	}
+	n := &swiftNode{kind: swiftBoundGeneric, children: append([]*swiftNode{nominal}, args...)}
+	if nominal.kind == swiftEnum && nominal.text == "Optional" && len(args) == 1 && swiftIsSwiftModule(nominal) {
+		n = &swiftNode{kind: swiftOptional, children: args}
+	}
+	d.subst = append(d.subst, n)
+	return n
+}
+
+func swiftIsSwiftModule(n *swiftNode) bool {
+	return len(n.children) == 1 && n.children[0].kind == swiftModule && n.children[0].text == "Swift"
+}
+
+// tuple pops elements of "type '_' type* 't'", or the empty list.
+func (d *swiftDemangler) tuple() *swiftNode {
+	n := &swiftNode{kind: swiftTuple}
+	if d.popKind(swiftEmptyList) != nil {
+		return n
+	}
+	for {
+		first := d.popKind(swiftFirstElement) != nil
+		label := d.popKind(swiftIdentifier)
+		t := d.popType()
+		if t == nil {
+			return nil
+		}
+		elem := &swiftNode{kind: swiftTuple, children: []*swiftNode{t}} // tuple element
+		if label != nil {
+			elem.text = label.text
+		}
+		n.children = append([]*swiftNode{elem}, n.children...)
+		if first {
+			return n
+		}
+	}
+}
+
+func (d *swiftDemangler) existential() *swiftNode {
+	n := &swiftNode{kind: swiftExistential}
+	if d.popKind(swiftEmptyList) != nil {
+		return n
+	}
+	for {
+		first := d.popKind(swiftFirstElement) != nil
+		t := d.popIf(func(n *swiftNode) bool { return n.kind == swiftProtocol || n.kind == swiftSymbolic })
+		if t == nil {
+			return nil
+		}
+		n.children = append([]*swiftNode{t}, n.children...)
+		if first || d.top() == nil || d.top().kind != swiftProtocol {
+			return n
+		}
+	}
+}
+
+func (d *swiftDemangler) genericParam(depth, i int) *swiftNode {
+	if depth < 0 || i < 0 {
+		return nil
+	}
+	return &swiftNode{kind: swiftGenericParam, text: swiftGenericParamString(depth, i)}
+}
+
+// genericParamIndex returns the generic parameter of "'z'", "'d' index index" or "index".
+func (d *swiftDemangler) genericParamIndex() *swiftNode {
+	if d.nextIf('d') {
+		depth := d.index()
+		return d.genericParam(depth+1, d.index())
+	}
+	if d.nextIf('z') {
+		return d.genericParam(0, 0)
+	}
+	i := d.index()
+	if i < 0 {
+		return nil
+	}
+	return d.genericParam(0, i+1)
+}
+
+// genericSignature pops requirements. The parameter counts follow 'r', otherwise there is a parameter.
+func (d *swiftDemangler) genericSignature(counts bool) *swiftNode {
+	var depths []string
+	if counts {
+		for !d.nextIf('l') {
+			n := 0
+			if !d.nextIf('z') {
+				if n = d.index(); n < 0 || n > 128 {
+					return nil
+				}
+				n++
+			}
+			params := make([]string, n)
+			for i := range params {
+				params[i] = swiftGenericParamString(len(depths), i)
+			}
+			depths = append(depths, strings.Join(params, ", "))
+		}
+	} else {
+		depths = []string{swiftGenericParamString(0, 0)}
+	}
+
+	n := &swiftNode{kind: swiftGenericSignature, text: strings.Join(depths, "><")}
+	for r := d.popKind(swiftRequirement); r != nil; r = d.popKind(swiftRequirement) {
+		n.children = append([]*swiftNode{r}, n.children...)
+	}
+	return n
+}
+
+var swiftLayouts = map[byte]string{
+	'U': "_UnknownLayout",
+	'R': "_RefCountedObject",
+	'N': "_NativeRefCountedObject",
+	'C': "AnyObject",
+	'D': "_NativeClass",
+	'T': "_Trivial",
+}
+
+// requirement pops the constraint of the generic parameter or the associated type.
+func (d *swiftDemangler) requirement() *swiftNode {
+	c := d.next()
+
+	var subject *swiftNode
+	switch c {
+	case 'b', 's', 'l':
+		subject = d.genericParamIndex()
+	case 'c', 'm', 'p':
+		if p := d.genericParamIndex(); p != nil {
+			subject = d.associatedType(p)
+		}
+	case 'B', 'L', 'Q', 'S':
+		subject = d.popType()
+	default:
+		d.pos--
+		c = 0
+		subject = d.genericParamIndex()
+	}
+	if subject == nil {
+		return nil
+	}
+
+	n := &swiftNode{kind: swiftRequirement, children: []*swiftNode{subject}}
+	switch c {
+	case 0, 'p', 'Q':
+		proto := d.popProtocol()
+		if proto == nil {
+			return nil
+		}
+		n.text = ": "
+		n.children = append(n.children, proto)
+	case 'b', 'c', 'B', 's', 'S':
+		t := d.popType()
+		if t == nil {
+			return nil
+		}
+		n.text = ": "
+		if c == 's' || c == 'S' {
+			n.text = " == "
+		}
+		n.children = append(n.children, t)
+	case 'l', 'm', 'L':
+		layout, ok := swiftLayouts[d.next()]
+		if !ok {
+			return nil
+		}
+		n.text = ": " + layout
+	}
+	return n
+}
+
+// associatedType pops the name of the associated type of base, or of the type below the name if base is nil.
+func (d *swiftDemangler) associatedType(base *swiftNode) *swiftNode {
+	d.popKind(swiftProtocol)
+	name := d.popKind(swiftIdentifier)
+	if base == nil {
+		base = d.popType()
+	}
+	if name == nil || base == nil {
+		return nil
+	}
+	n := &swiftNode{kind: swiftDependentMember, text: name.text, children: []*swiftNode{base}}
+	d.subst = append(d.subst, n)
+	return n
+}
+
+func (d *swiftDemangler) archetype() *swiftNode {
+	switch d.next() {
+	case 'r':
+		return &swiftNode{kind: swiftOpaqueReturn}
+	case 'x':
+		return d.associatedType(nil)
+	case 'y':
+		if p := d.genericParamIndex(); p != nil {
+			return d.associatedType(p)
+		}
+	case 'z':
+		return d.associatedType(d.genericParam(0, 0))
+	}
+	return nil
+}
+
+// signature pops "result params async? throws?" of a function.
+func (d *swiftDemangler) signature() *swiftNode {
+	n := &swiftNode{kind: swiftFunctionType}
+	if d.popKind(swiftThrows) != nil {
+		n.text = "throws"
+	}
+	if d.popKind(swiftAsync) != nil {
+		n.text = strings.TrimSpace("async " + n.text)
+	}
+	params := d.popIf(func(n *swiftNode) bool { return n.kind == swiftEmptyList || n.isType() })
+	result := d.popIf(func(n *swiftNode) bool { return n.kind == swiftEmptyList || n.isType() })
+	if params == nil || result == nil {
+		return nil
+	}
+	if params.kind == swiftEmptyList {
+		params = &swiftNode{kind: swiftTuple}
+	}
+	if result.kind == swiftEmptyList {
+		result = &swiftNode{kind: swiftTuple}
+	}
+	n.children = []*swiftNode{params, result}
+	return n
+}
+
+// entity pops "context name label* type".
+func (d *swiftDemangler) entity(kind swiftKind, function bool) *swiftNode {
+	sig := d.popKind(swiftGenericSignature)
+
+	var t *swiftNode
+	if function {
+		t = d.signature()
+	} else {
+		t = d.popType()
+	}
+	if t == nil {
+		return nil
+	}
+
+	labels, name, ctx := d.labelsAndName(t, kind != swiftSubscript)
+	if ctx == nil || kind != swiftSubscript && name == nil {
+		return nil
+	}
+
+	n := &swiftNode{kind: kind, children: []*swiftNode{ctx, t}}
+	if name != nil {
+		n.text = swiftNameString(name)
+	}
+	if sig != nil {
+		n.children = append(n.children, sig)
+	}
+	swiftApplyLabels(t, labels)
+	return n
+}
+
+// labelsAndName pops labels, the name if hasName, and the context.
+func (d *swiftDemangler) labelsAndName(t *swiftNode, hasName bool) (labels []*swiftNode, name, ctx *swiftNode) {
+	// 'y' is the absence of labels
+	d.popKind(swiftEmptyList)
+
+	// names and labels above the context
+	i := len(d.stack)
+	for i > 0 && (d.stack[i-1].isName() || d.stack[i-1].kind == swiftFirstElement) {
+		i--
+	}
+	names := append([]*swiftNode(nil), d.stack[i:]...)
+	d.stack = d.stack[:i]
+
+	// a module is an identifier
+	if d.top() == nil || !d.top().isContext() {
+		if len(names) == 0 || names[0].kind != swiftIdentifier {
+			return nil, nil, nil
+		}
+		ctx = &swiftNode{kind: swiftModule, text: names[0].text}
+		names = names[1:]
+	} else {
+		ctx = d.pop()
+	}
+
+	if hasName {
+		if len(names) == 0 {
+			return nil, nil, ctx
+		}
+		name, names = names[0], names[1:]
+	}
+
+	if len(names) != 0 && len(names) != swiftParamCount(t) {
+		return nil, nil, nil
+	}
+
+	return names, name, ctx
+}
+
+func swiftParamCount(t *swiftNode) int {
+	if t.kind != swiftFunctionType {
+		return -1
+	}
+	params := t.children[0]
+	if params.kind == swiftTuple {
+		return len(params.children)
+	}
+	return 1
+}
+
+// swiftApplyLabels names parameters of the function type.
+func swiftApplyLabels(t *swiftNode, labels []*swiftNode) {
+	if len(labels) == 0 || t.kind != swiftFunctionType {
+		return
+	}
+	params := t.children[0]
+	if params.kind != swiftTuple {
+		params = &swiftNode{kind: swiftTuple, children: []*swiftNode{{kind: swiftTuple, children: []*swiftNode{params}}}}
+		t.children[0] = params
+	}
+	for i, l := range labels {
+		if l.kind == swiftFirstElement {
+			params.children[i].text = "_"
+		} else {
+			params.children[i].text = l.text
+		}
+	}
+}
+
+func (d *swiftDemangler) functionEntity() *swiftNode {
+	c := d.next()
+	switch c {
+	case 'C', 'c':
+		sig := d.popKind(swiftGenericSignature)
+		t := d.popType()
+		if t == nil || t.kind != swiftFunctionType {
+			return nil
+		}
+		if sig == nil {
+			sig = d.popKind(swiftGenericSignature)
+		}
+		labels, _, ctx := d.labelsAndName(t, false)
+		if ctx == nil {
+			return nil
+		}
+		swiftApplyLabels(t, labels)
+		kind := swiftAllocator
+		if c == 'c' {
+			kind = swiftConstructor
+		}
+		n := &swiftNode{kind: kind, children: []*swiftNode{ctx, t}}
+		if sig != nil {
+			n.children = append(n.children, sig)
+		}
+		return n
+	case 'D', 'd':
+		ctx := d.popContext()
+		if ctx == nil {
+			return nil
+		}
+		kind := swiftDeallocator
+		if c == 'd' {
+			kind = swiftDestructor
+		}
+		return &swiftNode{kind: kind, children: []*swiftNode{ctx}}
+	case 'E', 'e':
+		ctx := d.popContext()
+		if ctx == nil {
+			return nil
+		}
+		text := "__ivar_destroyer"
+		if c == 'e' {
+			text = "__ivar_initializer"
+		}
+		return &swiftNode{kind: swiftFunction, text: text, children: []*swiftNode{ctx}}
+	case 'U', 'u':
+		d.popType()
+		ctx := d.popContext()
+		i := d.index()
+		if ctx == nil || i < 0 {
+			return nil
+		}
+		text := "closure"
+		if c == 'u' {
+			text = "implicit closure"
+		}
+		return &swiftNode{kind: swiftClosure, text: text, index: i + 1, children: []*swiftNode{ctx}}
+	case 'A':
+		i := d.index()
+		ctx := d.popContext()
+		if ctx == nil || i < 0 {
+			return nil
+		}
+		return &swiftNode{kind: swiftPrefixed, text: fmt.Sprintf("default argument %d of ", i), children: []*swiftNode{ctx}}
+	case 'i':
+		return d.prefixed("variable initialization expression of ", d.popIf((*swiftNode).isEntity))
+	}
+	return nil
+}
+
+var swiftAccessors = map[byte]string{
+	'g': "getter",
+	's': "setter",
+	'G': "global getter",
+	'w': "willset",
+	'W': "didset",
+	'r': "read",
+	'M': "modify",
+	'm': "materializeForSet",
+	'i': "init",
+}
+
+func (d *swiftDemangler) accessor(e *swiftNode) *swiftNode {
+	c := d.next()
+	if c == 'p' {
+		return e
+	}
+	if c == 'a' || c == 'l' {
+		// addressors
+		switch d.next() {
+		case 'O', 'o', 'p', 'u':
+		default:
+			return nil
+		}
+		text := "unsafeAddressor"
+		if c == 'a' {
+			text = "unsafeMutableAddressor"
+		}
+		return &swiftNode{kind: swiftAccessor, text: text, children: []*swiftNode{e}}
+	}
+	text, ok := swiftAccessors[c]
+	if !ok {
+		return nil
+	}
+	return &swiftNode{kind: swiftAccessor, text: text, children: []*swiftNode{e}}
+}
+
+func (d *swiftDemangler) prefixed(prefix string, n *swiftNode) *swiftNode {
+	if n == nil {
+		return nil
+	}
+	return &swiftNode{kind: swiftPrefixed, text: prefix, children: []*swiftNode{n}}
+}
+
+// popProtocol pops a protocol, which may be "context name" without 'P'.
+// Unlike nominal, the protocol isn't a substitution candidate.
+func (d *swiftDemangler) popProtocol() *swiftNode {
+	if p := d.popIf(func(n *swiftNode) bool { return n.kind == swiftProtocol || n.kind == swiftSymbolic }); p != nil {
+		return p
+	}
+	name := d.popIf((*swiftNode).isName)
+	ctx := d.popContext()
+	if name == nil || ctx == nil {
+		return nil
+	}
+	return &swiftNode{kind: swiftProtocol, text: swiftNameString(name), children: []*swiftNode{ctx}}
+}
+
+// conformance pops "type protocol module".
+func (d *swiftDemangler) conformance() *swiftNode {
+	mod := d.popContext()
+	proto := d.popProtocol()
+	t := d.popType()
+	if mod == nil || proto == nil || t == nil {
+		return nil
+	}
+	return &swiftNode{kind: swiftConformance, children: []*swiftNode{t, proto, mod}}
+}
+
+func (d *swiftDemangler) metadata() *swiftNode {
+	switch d.next() {
+	case 'a':
+		return d.prefixed("type metadata accessor for ", d.popType())
+	case 'n':
+		return d.prefixed("nominal type descriptor for ", d.popType())
+	case 'f':
+		return d.prefixed("full type metadata for ", d.popType())
+	case 'm':
+		return d.prefixed("metaclass for ", d.popType())
+	case 'p':
+		return d.prefixed("protocol descriptor for ", d.popProtocol())
+	case 'L':
+		return d.prefixed("type metadata lazy cache for ", d.popType())
+	case 'D':
+		return d.prefixed("demangling cache variable for type metadata for ", d.popType())
+	case 'o':
+		return d.prefixed("class metadata base offset for ", d.popType())
+	case 'u':
+		return d.prefixed("method lookup function for ", d.popType())
+	case 'V':
+		return d.prefixed("property descriptor for ", d.popIf((*swiftNode).isEntity))
+	case 'c':
+		return d.prefixed("protocol conformance descriptor for ", d.conformance())
+	case 'X':
+		switch d.next() {
+		case 'M':
+			return d.prefixed("module descriptor ", d.popContext())
+		case 'E':
+			return d.prefixed("extension descriptor ", d.popKind(swiftExtension))
+		}
+	}
+	return nil
+}
+
+func (d *swiftDemangler) thunk() *swiftNode {
+	c := d.next()
+	switch c {
+	case 'W':
+		e := d.popIf((*swiftNode).isEntity)
+		conf := d.conformance()
+		if e == nil || conf == nil {
+			return nil
+		}
+		return &swiftNode{kind: swiftWitness, children: []*swiftNode{e, conf}}
+	case 'o':
+		return d.prefixed("@objc ", d.popIf((*swiftNode).isEntity))
+	case 'O':
+		return d.prefixed("@nonobjc ", d.popIf((*swiftNode).isEntity))
+	case 'D':
+		return d.prefixed("dynamic ", d.popIf((*swiftNode).isEntity))
+	case 'q':
+		return d.prefixed("method descriptor for ", d.popIf((*swiftNode).isEntity))
+	case 'j':
+		return d.prefixed("dispatch thunk of ", d.popIf((*swiftNode).isEntity))
+	case 'A':
+		return d.prefixed("partial apply forwarder for ", d.popIf((*swiftNode).isEntity))
+	case 'm':
+		return d.prefixed("merged ", d.popIf((*swiftNode).isEntity))
+	}
+	return nil
+}
+
+var swiftValueWitnesses = map[string]string{
+	"al": "allocateBuffer",
+	"ca": "assignWithCopy",
+	"ta": "assignWithTake",
+	"de": "deallocateBuffer",
+	"xx": "destroy",
+	"XX": "destroyBuffer",
+	"Xx": "destroyArray",
+	"CP": "initializeBufferWithCopyOfBuffer",
+	"Cp": "initializeBufferWithCopy",
+	"cp": "initializeWithCopy",
+	"Tk": "initializeBufferWithTake",
+	"tk": "initializeWithTake",
+	"pr": "projectBuffer",
+	"TK": "initializeBufferWithTakeOfBuffer",
+	"Cc": "initializeArrayWithCopy",
+	"Tt": "initializeArrayWithTakeFrontToBack",
+	"tT": "initializeArrayWithTakeBackToFront",
+	"xs": "storeExtraInhabitant",
+	"xg": "getExtraInhabitantIndex",
+	"ug": "getEnumTag",
+	"up": "destructiveProjectEnumData",
+	"ui": "destructiveInjectEnumTag",
+	"et": "getEnumTagSinglePayload",
+	"st": "storeEnumTagSinglePayload",
+}
+
+func (d *swiftDemangler) valueWitness() *swiftNode {
+	if len(d.text) < d.pos+2 {
+		return nil
+	}
+	kind, ok := swiftValueWitnesses[d.text[d.pos:d.pos+2]]
+	if !ok {
+		return nil
+	}
+	d.pos += 2
+	return d.prefixed(kind+" value witness for ", d.popType())
+}
+
+func (d *swiftDemangler) witness() *swiftNode {
+	switch d.next() {
+	case 'P':
+		return d.prefixed("protocol witness table for ", d.conformance())
+	case 'p':
+		return d.prefixed("protocol witness table pattern for ", d.conformance())
+	case 'l':
+		return d.prefixed("lazy protocol witness table accessor for ", d.conformance())
+	case 'L':
+		return d.prefixed("lazy protocol witness table cache variable for ", d.conformance())
+	case 'v':
+		switch d.next() {
+		case 'd':
+			return d.prefixed("direct field offset for ", d.popIf((*swiftNode).isEntity))
+		case 'i':
+			return d.prefixed("indirect field offset for ", d.popIf((*swiftNode).isEntity))
+		}
+	}
+	return nil
+}
+
+func swiftNameString(n *swiftNode) string {
+	switch n.kind {
+	case swiftLocalName:
+		return fmt.Sprintf("(%s #%d)", n.text, n.index+1)
+	}
+	return n.text
+}
+
+func swiftGenericParamString(depth, i int) string {
+	var s string
+	for {
+		s += string(rune('A' + i%26))
+		if i /= 26; i == 0 {
+			break
+		}
+	}
+	if depth != 0 {
+		s += fmt.Sprint(depth)
+	}
+	return s
+}
+
+func swiftGenericSignatureString(n *swiftNode) string {
+	if len(n.children) == 0 {
+		return "<" + n.text + ">"
+	}
+	reqs := make([]string, len(n.children))
+	for i, r := range n.children {
+		reqs[i] = swiftString(r)
+	}
+	return "<" + n.text + " where " + strings.Join(reqs, ", ") + ">"
+}
+
+// swiftString prints the node like swift-demangle.
+func swiftString(n *swiftNode) string {
+	switch n.kind {
+	case swiftIdentifier, swiftLocalName, swiftPrivateName:
+		return swiftNameString(n)
+	case swiftModule, swiftBuiltin:
+		return n.text
+	case swiftSymbolic:
+		return n.text
+	case swiftExtension:
+		return fmt.Sprintf("(extension in %s):%s", swiftString(n.children[0]), swiftString(n.children[1]))
+	case swiftClass, swiftStruct, swiftEnum, swiftProtocol, swiftTypeAlias:
+		return swiftString(n.children[0]) + "." + n.text
+	case swiftBoundGeneric:
+		args := make([]string, len(n.children)-1)
+		for i, c := range n.children[1:] {
+			args[i] = swiftString(c)
+		}
+		return fmt.Sprintf("%s<%s>", swiftString(n.children[0]), strings.Join(args, ", "))
+	case swiftOptional:
+		return swiftString(n.children[0]) + "?"
+	case swiftTuple:
+		elems := make([]string, len(n.children))
+		for i, e := range n.children {
+			elems[i] = swiftString(e.children[0])
+			if e.text != "" {
+				elems[i] = e.text + ": " + elems[i]
+			}
+		}
+		return "(" + strings.Join(elems, ", ") + ")"
+	case swiftFunctionType:
+		params := swiftString(n.children[0])
+		if n.children[0].kind != swiftTuple {
+			params = "(" + params + ")"
+		}
+		if n.text != "" {
+			return fmt.Sprintf("%s %s -> %s", params, n.text, swiftString(n.children[1]))
+		}
+		return fmt.Sprintf("%s -> %s", params, swiftString(n.children[1]))
+	case swiftMetatype:
+		return swiftString(n.children[0]) + ".Type"
+	case swiftExistential:
+		if len(n.children) == 0 {
+			return "Any"
+		}
+		ps := make([]string, len(n.children))
+		for i, c := range n.children {
+			ps[i] = swiftString(c)
+		}
+		return strings.Join(ps, " & ")
+	case swiftGenericParam:
+		return n.text
+	case swiftInOut:
+		return "inout " + swiftString(n.children[0])
+	case swiftDependentMember:
+		return swiftString(n.children[0]) + "." + n.text
+	case swiftOpaqueReturn:
+		return "some"
+	case swiftRequirement:
+		if len(n.children) == 1 {
+			return swiftString(n.children[0]) + n.text
+		}
+		return swiftString(n.children[0]) + n.text + swiftString(n.children[1])
+	case swiftFunction, swiftSubscript:
+		name := n.text
+		if n.kind == swiftSubscript {
+			name = "subscript"
+		}
+		s := swiftString(n.children[0]) + "." + name
+		if len(n.children) == 1 {
+			return s
+		}
+		if len(n.children) > 2 {
+			s += swiftGenericSignatureString(n.children[2])
+		}
+		t := n.children[1]
+		if t.kind == swiftFunctionType {
+			return s + swiftString(t)
+		}
+		return s + " : " + swiftString(t)
+	case swiftVariable:
+		return swiftString(n.children[0]) + "." + n.text + " : " + swiftString(n.children[1])
+	case swiftAllocator, swiftConstructor:
+		// only classes have allocating initializers distinct from initializers
+		name := "init"
+		if n.kind == swiftAllocator && n.children[0].kind == swiftClass {
+			name = "__allocating_init"
+		}
+		s := swiftString(n.children[0]) + "." + name
+		if len(n.children) > 2 {
+			s += swiftGenericSignatureString(n.children[2])
+		}
+		return s + swiftString(n.children[1])
+	case swiftDeallocator:
+		return swiftString(n.children[0]) + ".__deallocating_deinit"
+	case swiftDestructor:
+		return swiftString(n.children[0]) + ".deinit"
+	case swiftClosure:
+		return fmt.Sprintf("%s #%d in %s", n.text, n.index, swiftString(n.children[0]))
+	case swiftAccessor:
+		e := n.children[0]
+		var t *swiftNode
+		if len(e.children) > 1 {
+			t = e.children[1]
+		}
+		var s string
+		switch e.kind {
+		case swiftVariable:
+			s = swiftString(e.children[0]) + "." + e.text + "." + n.text
+		case swiftSubscript:
+			s = swiftString(e.children[0]) + ".subscript." + n.text
+		default:
+			s = swiftString(e) + "." + n.text
+		}
+		if t != nil {
+			s += " : " + swiftString(t)
+		}
+		return s
+	case swiftStatic:
+		return "static " + swiftString(n.children[0])
+	case swiftPrefixed:
+		return n.text + swiftString(n.children[0])
+	case swiftConformance:
+		return fmt.Sprintf("%s : %s in %s", swiftString(n.children[0]), swiftString(n.children[1]), swiftString(n.children[2]))
+	case swiftWitness:
+		return fmt.Sprintf("protocol witness for %s in conformance %s", swiftString(n.children[0]), swiftString(n.children[1]))
+	}
+	return "?"
+}
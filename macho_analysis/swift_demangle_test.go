@@ -0,0 +1,45 @@
+package macho_analysis
+
+import "testing"
+
+func TestSwiftDemangle(t *testing.T) {
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{"$s4main3fooyyF", "main.foo() -> ()"},
+		{"_$s4main3fooyyF", "main.foo() -> ()"},
+		{"$s4main3FooVN", "type metadata for main.Foo"},
+		{"$s4main3FooVMn", "nominal type descriptor for main.Foo"},
+		{"$s4main3FooCMa", "type metadata accessor for main.Foo"},
+		{"$sSo8NSObjectCMa", "type metadata accessor for __C.NSObject"},
+		{"$s4main3FooVMD", "demangling cache variable for type metadata for main.Foo"},
+		{"$s4main1PMp", "protocol descriptor for main.P"},
+		{"$s4main3FooV3barSivg", "main.Foo.bar.getter : Swift.Int"},
+		{"$s4main3FooV1xSivpfi", "variable initialization expression of main.Foo.x : Swift.Int"},
+		{"$s4main3FooCACycfC", "main.Foo.__allocating_init() -> main.Foo"},
+		{"$s4main3FooVACycfC", "main.Foo.init() -> main.Foo"},
+		{"$s4main3FooV1xACSi_tcfC", "main.Foo.init(x: Swift.Int) -> main.Foo"},
+		{"$s4main3FooCfD", "main.Foo.__deallocating_deinit"},
+		{"$s4main3FooVwxx", "destroy value witness for main.Foo"},
+		{"$s4main3FooVwcp", "initializeWithCopy value witness for main.Foo"},
+		{"$s4main3FooVAA1PAAMc", "protocol conformance descriptor for main.Foo : main.P in main"},
+		{"$sSiSQsWP", "protocol witness table for Swift.Int : Swift.Equatable in Swift"},
+		{"$s4main3fooyxxlF", "main.foo<A>(A) -> A"},
+		{"$s4main1fyyx_q_tr0_lF", "main.f<A, B>(A, B) -> ()"},
+		{"$s4main1fyyxAA1PRzlF", "main.f<A where A: main.P>(A) -> ()"},
+		{"$s4main1fyyxRlzClF", "main.f<A where A: AnyObject>(A) -> ()"},
+		{"$s4main1fy7ElementQzxSTRzlF", "main.f<A where A: Swift.Sequence>(A) -> A.Element"},
+		{"$s4main3fooQryF", "main.foo() -> some"},
+	} {
+		out, ok := SwiftDemangle(tt.in)
+		if !ok || out != tt.out {
+			t.Errorf("SwiftDemangle(%q) = %q, %v; want %q", tt.in, out, ok, tt.out)
+		}
+	}
+
+	for _, in := range []string{"_main", "$s4main", "$s4main3FooVwzz"} {
+		if out, ok := SwiftDemangle(in); ok {
+			t.Errorf("SwiftDemangle(%q) = %q; want failure", in, out)
+		}
+	}
+}
@@ -1,5 +1,5 @@
 //go:generate stringer -type=CpuType,CpuSubtypeX86,CpuSubtypeX86_64,CpuSubtypePPC,CpuSubtypeARM,CpuSubtypeARM64,Magic,FileType,SectionType,LoadCommand,SymbolType,StabType,ReferenceType -output types_string.go
-package macho_widgets
+package macho_analysis
 
 type CpuType uint32
 
@@ -67,6 +67,7 @@ const (
 
 	CPU_SUBTYPE_ARM64_ALL CpuSubtypeARM64 = 0x0
 	CPU_SUBTYPE_ARM64_V8  CpuSubtypeARM64 = 0x1
+	CPU_SUBTYPE_ARM64E    CpuSubtypeARM64 = 0x2
 )
 
 type Magic uint32
@@ -172,6 +173,7 @@ var segmentFlagStrings = [...]string{
 	"SG_FVMLIB",
 	"SG_NORELOC",
 	"SG_PROTECTED_VERSION_1",
+	"SG_READ_ONLY",
 }
 
 const (
@@ -280,6 +282,11 @@ const (
 	LC_LINKER_OPTIMIZATION_HINT LoadCommand = 0x2e
 	LC_VERSION_MIN_TVOS         LoadCommand = 0x2f
 	LC_VERSION_MIN_WATCHOS      LoadCommand = 0x30
+	LC_NOTE                     LoadCommand = 0x31
+	LC_BUILD_VERSION            LoadCommand = 0x32
+	LC_DYLD_EXPORTS_TRIE        LoadCommand = 0x80000033
+	LC_DYLD_CHAINED_FIXUPS      LoadCommand = 0x80000034
+	LC_FILESET_ENTRY            LoadCommand = 0x80000035
 )
 
 const (
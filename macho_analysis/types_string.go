@@ -1,8 +1,28 @@
 // Code generated by "stringer -type=CpuType,CpuSubtypeX86,CpuSubtypeX86_64,CpuSubtypePPC,CpuSubtypeARM,CpuSubtypeARM64,Magic,FileType,SectionType,LoadCommand,SymbolType,StabType,ReferenceType -output types_string.go"; DO NOT EDIT.
 
-package macho_widgets
-
-import "fmt"
+package macho_analysis
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[CPU_TYPE_VAX-1]
+	_ = x[CPU_TYPE_MC680x0-6]
+	_ = x[CPU_TYPE_X86-7]
+	_ = x[CPU_TYPE_I386-7]
+	_ = x[CPU_TYPE_X86_64-16777223]
+	_ = x[CPU_TYPE_MC98000-10]
+	_ = x[CPU_TYPE_HPPA-11]
+	_ = x[CPU_TYPE_ARM-12]
+	_ = x[CPU_TYPE_ARM64-16777228]
+	_ = x[CPU_TYPE_MC88000-13]
+	_ = x[CPU_TYPE_SPARC-14]
+	_ = x[CPU_TYPE_I860-15]
+	_ = x[CPU_TYPE_POWERPC-18]
+	_ = x[CPU_TYPE_POWERPC64-16777234]
+}
 
 const (
 	_CpuType_name_0 = "CPU_TYPE_VAX"
@@ -15,13 +35,8 @@ const (
 )
 
 var (
-	_CpuType_index_0 = [...]uint8{0, 12}
 	_CpuType_index_1 = [...]uint8{0, 16, 28}
 	_CpuType_index_2 = [...]uint8{0, 16, 29, 41, 57, 71, 84}
-	_CpuType_index_3 = [...]uint8{0, 16}
-	_CpuType_index_4 = [...]uint8{0, 15}
-	_CpuType_index_5 = [...]uint8{0, 14}
-	_CpuType_index_6 = [...]uint8{0, 18}
 )
 
 func (i CpuType) String() string {
@@ -43,9 +58,16 @@ func (i CpuType) String() string {
 	case i == 16777234:
 		return _CpuType_name_6
 	default:
-		return fmt.Sprintf("CpuType(%d)", i)
+		return "CpuType(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[CPU_SUBTYPE_X86_ALL-3]
+	_ = x[CPU_SUBTYPE_X86_ARCH1-4]
+}
 
 const _CpuSubtypeX86_name = "CPU_SUBTYPE_X86_ALLCPU_SUBTYPE_X86_ARCH1"
 
@@ -54,21 +76,23 @@ var _CpuSubtypeX86_index = [...]uint8{0, 19, 40}
 func (i CpuSubtypeX86) String() string {
 	i -= 3
 	if i >= CpuSubtypeX86(len(_CpuSubtypeX86_index)-1) {
-		return fmt.Sprintf("CpuSubtypeX86(%d)", i+3)
+		return "CpuSubtypeX86(" + strconv.FormatInt(int64(i+3), 10) + ")"
 	}
 	return _CpuSubtypeX86_name[_CpuSubtypeX86_index[i]:_CpuSubtypeX86_index[i+1]]
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[CPU_SUBTYPE_X86_64_ALL-3]
+	_ = x[CPU_SUBTYPE_X86_64_H-8]
+}
 
 const (
 	_CpuSubtypeX86_64_name_0 = "CPU_SUBTYPE_X86_64_ALL"
 	_CpuSubtypeX86_64_name_1 = "CPU_SUBTYPE_X86_64_H"
 )
 
-var (
-	_CpuSubtypeX86_64_index_0 = [...]uint8{0, 22}
-	_CpuSubtypeX86_64_index_1 = [...]uint8{0, 20}
-)
-
 func (i CpuSubtypeX86_64) String() string {
 	switch {
 	case i == 3:
@@ -76,9 +100,27 @@ func (i CpuSubtypeX86_64) String() string {
 	case i == 8:
 		return _CpuSubtypeX86_64_name_1
 	default:
-		return fmt.Sprintf("CpuSubtypeX86_64(%d)", i)
+		return "CpuSubtypeX86_64(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[CPU_SUBTYPE_POWERPC_ALL-0]
+	_ = x[CPU_SUBTYPE_POWERPC_601-1]
+	_ = x[CPU_SUBTYPE_POWERPC_602-2]
+	_ = x[CPU_SUBTYPE_POWERPC_603-3]
+	_ = x[CPU_SUBTYPE_POWERPC_603e-4]
+	_ = x[CPU_SUBTYPE_POWERPC_603ev-5]
+	_ = x[CPU_SUBTYPE_POWERPC_604-6]
+	_ = x[CPU_SUBTYPE_POWERPC_604e-7]
+	_ = x[CPU_SUBTYPE_POWERPC_620-8]
+	_ = x[CPU_SUBTYPE_POWERPC_750-9]
+	_ = x[CPU_SUBTYPE_POWERPC_7400-10]
+	_ = x[CPU_SUBTYPE_POWERPC_7450-11]
+	_ = x[CPU_SUBTYPE_POWERPC_970-100]
+}
 
 const (
 	_CpuSubtypePPC_name_0 = "CPU_SUBTYPE_POWERPC_ALLCPU_SUBTYPE_POWERPC_601CPU_SUBTYPE_POWERPC_602CPU_SUBTYPE_POWERPC_603CPU_SUBTYPE_POWERPC_603eCPU_SUBTYPE_POWERPC_603evCPU_SUBTYPE_POWERPC_604CPU_SUBTYPE_POWERPC_604eCPU_SUBTYPE_POWERPC_620CPU_SUBTYPE_POWERPC_750CPU_SUBTYPE_POWERPC_7400CPU_SUBTYPE_POWERPC_7450"
@@ -87,19 +129,36 @@ const (
 
 var (
 	_CpuSubtypePPC_index_0 = [...]uint16{0, 23, 46, 69, 92, 116, 141, 164, 188, 211, 234, 258, 282}
-	_CpuSubtypePPC_index_1 = [...]uint8{0, 23}
 )
 
 func (i CpuSubtypePPC) String() string {
 	switch {
-	case 0 <= i && i <= 11:
+	case i <= 11:
 		return _CpuSubtypePPC_name_0[_CpuSubtypePPC_index_0[i]:_CpuSubtypePPC_index_0[i+1]]
 	case i == 100:
 		return _CpuSubtypePPC_name_1
 	default:
-		return fmt.Sprintf("CpuSubtypePPC(%d)", i)
+		return "CpuSubtypePPC(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[CPU_SUBTYPE_ARM_ALL-0]
+	_ = x[CPU_SUBTYPE_ARM_V4T-5]
+	_ = x[CPU_SUBTYPE_ARM_V6-6]
+	_ = x[CPU_SUBTYPE_ARM_V5TEJ-7]
+	_ = x[CPU_SUBTYPE_ARM_XSCALE-8]
+	_ = x[CPU_SUBTYPE_ARM_V7-9]
+	_ = x[CPU_SUBTYPE_ARM_V7F-10]
+	_ = x[CPU_SUBTYPE_ARM_V7S-11]
+	_ = x[CPU_SUBTYPE_ARM_V7K-12]
+	_ = x[CPU_SUBTYPE_ARM_V6M-14]
+	_ = x[CPU_SUBTYPE_ARM_V7M-15]
+	_ = x[CPU_SUBTYPE_ARM_V7EM-16]
+	_ = x[CPU_SUBTYPE_ARM_V8-13]
+}
 
 const (
 	_CpuSubtypeARM_name_0 = "CPU_SUBTYPE_ARM_ALL"
@@ -107,7 +166,6 @@ const (
 )
 
 var (
-	_CpuSubtypeARM_index_0 = [...]uint8{0, 19}
 	_CpuSubtypeARM_index_1 = [...]uint8{0, 19, 37, 58, 80, 98, 117, 136, 155, 173, 192, 211, 231}
 )
 
@@ -119,20 +177,41 @@ func (i CpuSubtypeARM) String() string {
 		i -= 5
 		return _CpuSubtypeARM_name_1[_CpuSubtypeARM_index_1[i]:_CpuSubtypeARM_index_1[i+1]]
 	default:
-		return fmt.Sprintf("CpuSubtypeARM(%d)", i)
+		return "CpuSubtypeARM(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[CPU_SUBTYPE_ARM64_ALL-0]
+	_ = x[CPU_SUBTYPE_ARM64_V8-1]
+	_ = x[CPU_SUBTYPE_ARM64E-2]
+}
 
-const _CpuSubtypeARM64_name = "CPU_SUBTYPE_ARM64_ALLCPU_SUBTYPE_ARM64_V8"
+const _CpuSubtypeARM64_name = "CPU_SUBTYPE_ARM64_ALLCPU_SUBTYPE_ARM64_V8CPU_SUBTYPE_ARM64E"
 
-var _CpuSubtypeARM64_index = [...]uint8{0, 21, 41}
+var _CpuSubtypeARM64_index = [...]uint8{0, 21, 41, 59}
 
 func (i CpuSubtypeARM64) String() string {
 	if i >= CpuSubtypeARM64(len(_CpuSubtypeARM64_index)-1) {
-		return fmt.Sprintf("CpuSubtypeARM64(%d)", i)
+		return "CpuSubtypeARM64(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
 	return _CpuSubtypeARM64_name[_CpuSubtypeARM64_index[i]:_CpuSubtypeARM64_index[i+1]]
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[MH_MAGIC-4277009102]
+	_ = x[MH_CIGAM-3472551422]
+	_ = x[MH_MAGIC_64-4277009103]
+	_ = x[MH_CIGAM_64-3489328638]
+	_ = x[FAT_MAGIC-3405691582]
+	_ = x[FAT_CIGAM-3199925962]
+	_ = x[FAT_MAGIC_64-3405691583]
+	_ = x[FAT_CIGAM_64-3216703178]
+}
 
 const (
 	_Magic_name_0 = "FAT_CIGAM"
@@ -144,11 +223,7 @@ const (
 )
 
 var (
-	_Magic_index_0 = [...]uint8{0, 9}
-	_Magic_index_1 = [...]uint8{0, 12}
 	_Magic_index_2 = [...]uint8{0, 9, 21}
-	_Magic_index_3 = [...]uint8{0, 8}
-	_Magic_index_4 = [...]uint8{0, 11}
 	_Magic_index_5 = [...]uint8{0, 8, 19}
 )
 
@@ -169,9 +244,25 @@ func (i Magic) String() string {
 		i -= 4277009102
 		return _Magic_name_5[_Magic_index_5[i]:_Magic_index_5[i+1]]
 	default:
-		return fmt.Sprintf("Magic(%d)", i)
+		return "Magic(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[MH_OBJECT-1]
+	_ = x[MH_EXECUTE-2]
+	_ = x[MH_FVMLIB-3]
+	_ = x[MH_CORE-4]
+	_ = x[MH_PRELOAD-5]
+	_ = x[MH_DYLIB-6]
+	_ = x[MH_DYLINKER-7]
+	_ = x[MH_BUNDLE-8]
+	_ = x[MH_DYLIB_STUB-9]
+	_ = x[MH_DSYM-10]
+	_ = x[MH_KEXT_BUNDLE-11]
+}
 
 const _FileType_name = "MH_OBJECTMH_EXECUTEMH_FVMLIBMH_COREMH_PRELOADMH_DYLIBMH_DYLINKERMH_BUNDLEMH_DYLIB_STUBMH_DSYMMH_KEXT_BUNDLE"
 
@@ -180,10 +271,37 @@ var _FileType_index = [...]uint8{0, 9, 19, 28, 35, 45, 53, 64, 73, 86, 93, 107}
 func (i FileType) String() string {
 	i -= 1
 	if i >= FileType(len(_FileType_index)-1) {
-		return fmt.Sprintf("FileType(%d)", i+1)
+		return "FileType(" + strconv.FormatInt(int64(i+1), 10) + ")"
 	}
 	return _FileType_name[_FileType_index[i]:_FileType_index[i+1]]
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[S_REGULAR-0]
+	_ = x[S_ZEROFILL-1]
+	_ = x[S_CSTRING_LITERALS-2]
+	_ = x[S_4BYTE_LITERALS-3]
+	_ = x[S_8BYTE_LITERALS-4]
+	_ = x[S_LITERAL_POINTERS-5]
+	_ = x[S_NON_LAZY_SYMBOL_POINTERS-6]
+	_ = x[S_LAZY_SYMBOL_POINTERS-7]
+	_ = x[S_SYMBOL_STUBS-8]
+	_ = x[S_MOD_INIT_FUNC_POINTERS-9]
+	_ = x[S_MOD_TERM_FUNC_POINTERS-10]
+	_ = x[S_COALESCED-11]
+	_ = x[S_GB_ZEROFILL-12]
+	_ = x[S_INTERPOSING-13]
+	_ = x[S_16BYTE_LITERALS-14]
+	_ = x[S_DTRACE_DOF-15]
+	_ = x[S_LAZY_DYLIB_SYMBOL_POINTERS-16]
+	_ = x[S_THREAD_LOCAL_REGULAR-17]
+	_ = x[S_THREAD_LOCAL_ZEROFILL-18]
+	_ = x[S_THREAD_LOCAL_VARIABLES-19]
+	_ = x[S_THREAD_LOCAL_VARIABLE_POINTERS-20]
+	_ = x[S_THREAD_LOCAL_INIT_FUNCTION_POINTERS-21]
+}
 
 const _SectionType_name = "S_REGULARS_ZEROFILLS_CSTRING_LITERALSS_4BYTE_LITERALSS_8BYTE_LITERALSS_LITERAL_POINTERSS_NON_LAZY_SYMBOL_POINTERSS_LAZY_SYMBOL_POINTERSS_SYMBOL_STUBSS_MOD_INIT_FUNC_POINTERSS_MOD_TERM_FUNC_POINTERSS_COALESCEDS_GB_ZEROFILLS_INTERPOSINGS_16BYTE_LITERALSS_DTRACE_DOFS_LAZY_DYLIB_SYMBOL_POINTERSS_THREAD_LOCAL_REGULARS_THREAD_LOCAL_ZEROFILLS_THREAD_LOCAL_VARIABLESS_THREAD_LOCAL_VARIABLE_POINTERSS_THREAD_LOCAL_INIT_FUNCTION_POINTERS"
 
@@ -191,12 +309,72 @@ var _SectionType_index = [...]uint16{0, 9, 19, 37, 53, 69, 87, 113, 135, 149, 17
 
 func (i SectionType) String() string {
 	if i >= SectionType(len(_SectionType_index)-1) {
-		return fmt.Sprintf("SectionType(%d)", i)
+		return "SectionType(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
 	return _SectionType_name[_SectionType_index[i]:_SectionType_index[i+1]]
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[LC_REQ_DYLD-2147483648]
+	_ = x[LC_SEGMENT-1]
+	_ = x[LC_SYMTAB-2]
+	_ = x[LC_SYMSEG-3]
+	_ = x[LC_THREAD-4]
+	_ = x[LC_UNIXTHREAD-5]
+	_ = x[LC_LOADFVMLIB-6]
+	_ = x[LC_IDFVMLIB-7]
+	_ = x[LC_IDENT-8]
+	_ = x[LC_FVMFILE-9]
+	_ = x[LC_PREPAGE-10]
+	_ = x[LC_DYSYMTAB-11]
+	_ = x[LC_LOAD_DYLIB-12]
+	_ = x[LC_ID_DYLIB-13]
+	_ = x[LC_LOAD_DYLINKER-14]
+	_ = x[LC_ID_DYLINKER-15]
+	_ = x[LC_PREBOUND_DYLIB-16]
+	_ = x[LC_ROUTINES-17]
+	_ = x[LC_SUB_FRAMEWORK-18]
+	_ = x[LC_SUB_UMBRELLA-19]
+	_ = x[LC_SUB_CLIENT-20]
+	_ = x[LC_SUB_LIBRARY-21]
+	_ = x[LC_TWOLEVEL_HINTS-22]
+	_ = x[LC_PREBIND_CKSUM-23]
+	_ = x[LC_LOAD_WEAK_DYLIB-2147483672]
+	_ = x[LC_SEGMENT_64-25]
+	_ = x[LC_ROUTINES_64-26]
+	_ = x[LC_UUID-27]
+	_ = x[LC_RPATH-2147483676]
+	_ = x[LC_CODE_SIGNATURE-29]
+	_ = x[LC_SEGMENT_SPLIT_INFO-30]
+	_ = x[LC_REEXPORT_DYLIB-2147483679]
+	_ = x[LC_LAZY_LOAD_DYLIB-32]
+	_ = x[LC_ENCRYPTION_INFO-33]
+	_ = x[LC_DYLD_INFO-34]
+	_ = x[LC_DYLD_INFO_ONLY-2147483682]
+	_ = x[LC_LOAD_UPWARD_DYLIB-2147483683]
+	_ = x[LC_VERSION_MIN_MACOSX-36]
+	_ = x[LC_VERSION_MIN_IPHONEOS-37]
+	_ = x[LC_FUNCTION_STARTS-38]
+	_ = x[LC_DYLD_ENVIRONMENT-39]
+	_ = x[LC_MAIN-2147483688]
+	_ = x[LC_DATA_IN_CODE-41]
+	_ = x[LC_SOURCE_VERSION-42]
+	_ = x[LC_DYLIB_CODE_SIGN_DRS-43]
+	_ = x[LC_ENCRYPTION_INFO_64-44]
+	_ = x[LC_LINKER_OPTION-45]
+	_ = x[LC_LINKER_OPTIMIZATION_HINT-46]
+	_ = x[LC_VERSION_MIN_TVOS-47]
+	_ = x[LC_VERSION_MIN_WATCHOS-48]
+	_ = x[LC_NOTE-49]
+	_ = x[LC_BUILD_VERSION-50]
+	_ = x[LC_DYLD_EXPORTS_TRIE-2147483699]
+	_ = x[LC_DYLD_CHAINED_FIXUPS-2147483700]
+	_ = x[LC_FILESET_ENTRY-2147483701]
+}
 
-const _LoadCommand_name = "LC_SEGMENTLC_SYMTABLC_SYMSEGLC_THREADLC_UNIXTHREADLC_LOADFVMLIBLC_IDFVMLIBLC_IDENTLC_FVMFILELC_PREPAGELC_DYSYMTABLC_LOAD_DYLIBLC_ID_DYLIBLC_LOAD_DYLINKERLC_ID_DYLINKERLC_PREBOUND_DYLIBLC_ROUTINESLC_SUB_FRAMEWORKLC_SUB_UMBRELLALC_SUB_CLIENTLC_SUB_LIBRARYLC_TWOLEVEL_HINTSLC_PREBIND_CKSUMLC_SEGMENT_64LC_ROUTINES_64LC_UUIDLC_CODE_SIGNATURELC_SEGMENT_SPLIT_INFOLC_LAZY_LOAD_DYLIBLC_ENCRYPTION_INFOLC_DYLD_INFOLC_VERSION_MIN_MACOSXLC_VERSION_MIN_IPHONEOSLC_FUNCTION_STARTSLC_DYLD_ENVIRONMENTLC_DATA_IN_CODELC_SOURCE_VERSIONLC_DYLIB_CODE_SIGN_DRSLC_ENCRYPTION_INFO_64LC_LINKER_OPTIONLC_LINKER_OPTIMIZATION_HINTLC_VERSION_MIN_TVOSLC_VERSION_MIN_WATCHOSLC_REQ_DYLDLC_LOAD_WEAK_DYLIBLC_RPATHLC_REEXPORT_DYLIBLC_DYLD_INFO_ONLYLC_LOAD_UPWARD_DYLIBLC_MAIN"
+const _LoadCommand_name = "LC_SEGMENTLC_SYMTABLC_SYMSEGLC_THREADLC_UNIXTHREADLC_LOADFVMLIBLC_IDFVMLIBLC_IDENTLC_FVMFILELC_PREPAGELC_DYSYMTABLC_LOAD_DYLIBLC_ID_DYLIBLC_LOAD_DYLINKERLC_ID_DYLINKERLC_PREBOUND_DYLIBLC_ROUTINESLC_SUB_FRAMEWORKLC_SUB_UMBRELLALC_SUB_CLIENTLC_SUB_LIBRARYLC_TWOLEVEL_HINTSLC_PREBIND_CKSUMLC_SEGMENT_64LC_ROUTINES_64LC_UUIDLC_CODE_SIGNATURELC_SEGMENT_SPLIT_INFOLC_LAZY_LOAD_DYLIBLC_ENCRYPTION_INFOLC_DYLD_INFOLC_VERSION_MIN_MACOSXLC_VERSION_MIN_IPHONEOSLC_FUNCTION_STARTSLC_DYLD_ENVIRONMENTLC_DATA_IN_CODELC_SOURCE_VERSIONLC_DYLIB_CODE_SIGN_DRSLC_ENCRYPTION_INFO_64LC_LINKER_OPTIONLC_LINKER_OPTIMIZATION_HINTLC_VERSION_MIN_TVOSLC_VERSION_MIN_WATCHOSLC_NOTELC_BUILD_VERSIONLC_REQ_DYLDLC_LOAD_WEAK_DYLIBLC_RPATHLC_REEXPORT_DYLIBLC_DYLD_INFO_ONLYLC_LOAD_UPWARD_DYLIBLC_MAINLC_DYLD_EXPORTS_TRIELC_DYLD_CHAINED_FIXUPSLC_FILESET_ENTRY"
 
 var _LoadCommand_map = map[LoadCommand]string{
 	1:          _LoadCommand_name[0:10],
@@ -242,20 +420,35 @@ var _LoadCommand_map = map[LoadCommand]string{
 	46:         _LoadCommand_name[578:605],
 	47:         _LoadCommand_name[605:624],
 	48:         _LoadCommand_name[624:646],
-	2147483648: _LoadCommand_name[646:657],
-	2147483672: _LoadCommand_name[657:675],
-	2147483676: _LoadCommand_name[675:683],
-	2147483679: _LoadCommand_name[683:700],
-	2147483682: _LoadCommand_name[700:717],
-	2147483683: _LoadCommand_name[717:737],
-	2147483688: _LoadCommand_name[737:744],
+	49:         _LoadCommand_name[646:653],
+	50:         _LoadCommand_name[653:669],
+	2147483648: _LoadCommand_name[669:680],
+	2147483672: _LoadCommand_name[680:698],
+	2147483676: _LoadCommand_name[698:706],
+	2147483679: _LoadCommand_name[706:723],
+	2147483682: _LoadCommand_name[723:740],
+	2147483683: _LoadCommand_name[740:760],
+	2147483688: _LoadCommand_name[760:767],
+	2147483699: _LoadCommand_name[767:787],
+	2147483700: _LoadCommand_name[787:809],
+	2147483701: _LoadCommand_name[809:825],
 }
 
 func (i LoadCommand) String() string {
 	if str, ok := _LoadCommand_map[i]; ok {
 		return str
 	}
-	return fmt.Sprintf("LoadCommand(%d)", i)
+	return "LoadCommand(" + strconv.FormatInt(int64(i), 10) + ")"
+}
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[N_UNDF-0]
+	_ = x[N_ABS-2]
+	_ = x[N_SECT-14]
+	_ = x[N_PBUD-12]
+	_ = x[N_INDR-10]
 }
 
 const (
@@ -266,14 +459,6 @@ const (
 	_SymbolType_name_4 = "N_SECT"
 )
 
-var (
-	_SymbolType_index_0 = [...]uint8{0, 6}
-	_SymbolType_index_1 = [...]uint8{0, 5}
-	_SymbolType_index_2 = [...]uint8{0, 6}
-	_SymbolType_index_3 = [...]uint8{0, 6}
-	_SymbolType_index_4 = [...]uint8{0, 6}
-)
-
 func (i SymbolType) String() string {
 	switch {
 	case i == 0:
@@ -287,9 +472,44 @@ func (i SymbolType) String() string {
 	case i == 14:
 		return _SymbolType_name_4
 	default:
-		return fmt.Sprintf("SymbolType(%d)", i)
+		return "SymbolType(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
 }
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[N_GSYM-32]
+	_ = x[N_FNAME-34]
+	_ = x[N_FUN-36]
+	_ = x[N_STSYM-38]
+	_ = x[N_LCSYM-40]
+	_ = x[N_BNSYM-46]
+	_ = x[N_AST-50]
+	_ = x[N_OPT-60]
+	_ = x[N_RSYM-64]
+	_ = x[N_SLINE-68]
+	_ = x[N_ENSYM-78]
+	_ = x[N_SSYM-96]
+	_ = x[N_SO-100]
+	_ = x[N_OSO-102]
+	_ = x[N_LSYM-128]
+	_ = x[N_BINCL-130]
+	_ = x[N_SOL-132]
+	_ = x[N_PARAMS-134]
+	_ = x[N_VERSION-136]
+	_ = x[N_OLEVEL-138]
+	_ = x[N_PSYM-160]
+	_ = x[N_EINCL-162]
+	_ = x[N_ENTRY-164]
+	_ = x[N_LBRAC-192]
+	_ = x[N_EXCL-194]
+	_ = x[N_RBRAC-224]
+	_ = x[N_BCOMM-226]
+	_ = x[N_ECOMM-228]
+	_ = x[N_ECOML-232]
+	_ = x[N_LENG-254]
+}
 
 const _StabType_name = "N_GSYMN_FNAMEN_FUNN_STSYMN_LCSYMN_BNSYMN_ASTN_OPTN_RSYMN_SLINEN_ENSYMN_SSYMN_SON_OSON_LSYMN_BINCLN_SOLN_PARAMSN_VERSIONN_OLEVELN_PSYMN_EINCLN_ENTRYN_LBRACN_EXCLN_RBRACN_BCOMMN_ECOMMN_ECOMLN_LENG"
 
@@ -330,7 +550,18 @@ func (i StabType) String() string {
 	if str, ok := _StabType_map[i]; ok {
 		return str
 	}
-	return fmt.Sprintf("StabType(%d)", i)
+	return "StabType(" + strconv.FormatInt(int64(i), 10) + ")"
+}
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[REFERENCE_FLAG_UNDEFINED_NON_LAZY-0]
+	_ = x[REFERENCE_FLAG_UNDEFINED_LAZY-1]
+	_ = x[REFERENCE_FLAG_DEFINED-2]
+	_ = x[REFERENCE_FLAG_PRIVATE_DEFINED-3]
+	_ = x[REFERENCE_FLAG_PRIVATE_UNDEFINED_NON_LAZY-4]
+	_ = x[REFERENCE_FLAG_PRIVATE_UNDEFINED_LAZY-5]
 }
 
 const _ReferenceType_name = "REFERENCE_FLAG_UNDEFINED_NON_LAZYREFERENCE_FLAG_UNDEFINED_LAZYREFERENCE_FLAG_DEFINEDREFERENCE_FLAG_PRIVATE_DEFINEDREFERENCE_FLAG_PRIVATE_UNDEFINED_NON_LAZYREFERENCE_FLAG_PRIVATE_UNDEFINED_LAZY"
@@ -339,7 +570,7 @@ var _ReferenceType_index = [...]uint8{0, 33, 62, 84, 114, 155, 192}
 
 func (i ReferenceType) String() string {
 	if i >= ReferenceType(len(_ReferenceType_index)-1) {
-		return fmt.Sprintf("ReferenceType(%d)", i)
+		return "ReferenceType(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
 	return _ReferenceType_name[_ReferenceType_index[i]:_ReferenceType_index[i+1]]
 }
@@ -0,0 +1,590 @@
+//go:generate stringer -type=UNWIND_SECOND_LEVEL,UNWIND_X86_MODE,UNWIND_X86_64_MODE,UNWIND_ARM64_MODE -output unwind_info_string.go
+
+package macho_analysis
+
+// reference:
+// https://opensource.apple.com/source/xnu/xnu-4570.41.2/EXTERNAL_HEADERS/mach-o/compact_unwind_encoding.h
+// https://faultlore.com/blah/compact-unwinding/
+
+import (
+	"debug/macho"
+	"fmt"
+	"strings"
+)
+
+type UNWIND_SECOND_LEVEL uint32
+
+const (
+	UNWIND_SECOND_LEVEL_REGULAR    UNWIND_SECOND_LEVEL = 2
+	UNWIND_SECOND_LEVEL_COMPRESSED UNWIND_SECOND_LEVEL = 3
+)
+
+type UNWIND_X86_MODE uint32
+
+const (
+	UNWIND_X86_MODE_EBP_FRAME  UNWIND_X86_MODE = 0x01000000
+	UNWIND_X86_MODE_STACK_IMMD UNWIND_X86_MODE = 0x02000000
+	UNWIND_X86_MODE_STACK_IND  UNWIND_X86_MODE = 0x03000000
+	UNWIND_X86_MODE_DWARF      UNWIND_X86_MODE = 0x04000000
+)
+
+type UNWIND_X86_64_MODE uint32
+
+const (
+	UNWIND_X86_64_MODE_RBP_FRAME  UNWIND_X86_64_MODE = 0x01000000
+	UNWIND_X86_64_MODE_STACK_IMMD UNWIND_X86_64_MODE = 0x02000000
+	UNWIND_X86_64_MODE_STACK_IND  UNWIND_X86_64_MODE = 0x03000000
+	UNWIND_X86_64_MODE_DWARF      UNWIND_X86_64_MODE = 0x04000000
+)
+
+type UNWIND_ARM64_MODE uint32
+
+const (
+	UNWIND_ARM64_MODE_FRAMELESS UNWIND_ARM64_MODE = 0x02000000
+	UNWIND_ARM64_MODE_DWARF     UNWIND_ARM64_MODE = 0x03000000
+	UNWIND_ARM64_MODE_FRAME     UNWIND_ARM64_MODE = 0x04000000
+)
+
+const (
+	// common bits
+
+	UNWIND_IS_NOT_FUNCTION_START uint32 = 0x80000000
+	UNWIND_HAS_LSDA              uint32 = 0x40000000
+	UNWIND_PERSONALITY_MASK      uint32 = 0x30000000
+	UNWIND_MODE_MASK             uint32 = 0x0F000000
+
+	// x86 and x86_64
+
+	UNWIND_X86_FRAME_OFFSET                    uint32 = 0x00FF0000
+	UNWIND_X86_FRAME_REGISTERS                 uint32 = 0x00007FFF
+	UNWIND_X86_FRAMELESS_STACK_SIZE            uint32 = 0x00FF0000
+	UNWIND_X86_FRAMELESS_STACK_ADJUST          uint32 = 0x0000E000
+	UNWIND_X86_FRAMELESS_STACK_REG_COUNT       uint32 = 0x00001C00
+	UNWIND_X86_FRAMELESS_STACK_REG_PERMUTATION uint32 = 0x000003FF
+	UNWIND_X86_DWARF_SECTION_OFFSET            uint32 = 0x00FFFFFF
+
+	// arm64
+
+	UNWIND_ARM64_FRAMELESS_STACK_SIZE_MASK uint32 = 0x00FFF000
+	UNWIND_ARM64_DWARF_SECTION_OFFSET      uint32 = 0x00FFFFFF
+)
+
+var unwindX86Registers = [...]string{"", "%ebx", "%ecx", "%edx", "%edi", "%esi", "%ebp"}
+
+var unwindX86_64Registers = [...]string{"", "%rbx", "%r12", "%r13", "%r14", "%r15", "%rbp"}
+
+var unwindARM64Pairs = []struct {
+	bit  uint32
+	name string
+}{
+	{0x001, "x19/x20"},
+	{0x002, "x21/x22"},
+	{0x004, "x23/x24"},
+	{0x008, "x25/x26"},
+	{0x010, "x27/x28"},
+	{0x100, "d8/d9"},
+	{0x200, "d10/d11"},
+	{0x400, "d12/d13"},
+	{0x800, "d14/d15"},
+}
+
+type unwindInfo struct {
+	f       *File
+	sect    *macho.Section
+	data    []byte
+	base    uint64 // address of the mach header, which function offsets are relative to
+	ehFrame *macho.Section
+	commons []uint32
+	lsdas   map[uint64]uint64 // LSDA address -> function address
+}
+
+// UnwindInfo returns compact unwind encodings of the __unwind_info section.
+func (f *File) UnwindInfo(sect *macho.Section) *DataTree {
+	t, _ := f.decodeUnwindInfo(sect)
+	return t
+}
+
+// decodeUnwindInfo is like UnwindInfo, but also returns the decoder, which knows LSDAs of the LSDA index.
+func (f *File) decodeUnwindInfo(sect *macho.Section) (*DataTree, *unwindInfo) {
+	t := &DataTree{Header: ehFrameHeader}
+
+	u := &unwindInfo{
+		f:       f,
+		sect:    sect,
+		ehFrame: f.Section("__eh_frame"),
+		lsdas:   make(map[uint64]uint64),
+	}
+
+	data, err := sect.Data()
+	if err != nil {
+		// TODO warning
+		return nil, u
+	}
+	u.data = data
+
+	if seg := f.Segment("__TEXT"); seg != nil {
+		u.base = seg.Addr
+	}
+
+	if len(data) < 7*4 {
+		// TODO warning
+		return nil, u
+	}
+
+	hdr := t.appendRow(Text("Header"))
+	for i, name := range []string{
+		"Version",
+		"Common Encodings Array Section Offset",
+		"Common Encodings Array Count",
+		"Personality Array Section Offset",
+		"Personality Array Count",
+		"Index Section Offset",
+		"Index Count",
+	} {
+		u.appendUint32Row(hdr, uint32(i*4), Text(name), Text(fmt.Sprintf("%d", u.uint32(uint32(i*4)))))
+	}
+
+	commonOff, commonCount := u.uint32(4), u.uint32(8)
+	persOff, persCount := u.uint32(12), u.uint32(16)
+	indexOff, indexCount := u.uint32(20), u.uint32(24)
+
+	if !u.has(commonOff, commonCount, 4) || !u.has(persOff, persCount, 4) || !u.has(indexOff, indexCount, 12) {
+		// TODO warning
+		return t, u
+	}
+
+	var commons, pers *DataNode
+	if commonCount != 0 {
+		commons = t.appendRow(Text("Common Encodings"))
+	}
+	for i := uint32(0); i < commonCount; i++ {
+		off := commonOff + i*4
+		enc := u.uint32(off)
+		u.commons = append(u.commons, enc)
+		u.appendUint32Row(commons, off, Text(fmt.Sprintf("Encoding %d", i)), u.encodingValue(enc, 0))
+	}
+
+	if persCount != 0 {
+		pers = t.appendRow(Text("Personalities"))
+	}
+	for i := uint32(0); i < persCount; i++ {
+		off := persOff + i*4
+		u.appendUint32Row(pers, off, Text(fmt.Sprintf("Personality %d", i+1)), u.personalityValue(u.uint32(off)))
+	}
+
+	var lsdas *DataNode
+
+	for i := uint32(0); i < indexCount; i++ {
+		off := indexOff + i*12
+		funcOff := u.uint32(off)
+		pageOff := u.uint32(off + 4)
+		lsdaOff := u.uint32(off + 8)
+
+		// the next entry ends the last function of the page and the LSDA index
+		next, lsdaEnd := funcOff, lsdaOff
+		if i+1 < indexCount {
+			next, lsdaEnd = u.uint32(off+12), u.uint32(off+12+8)
+		}
+
+		label := fmt.Sprintf("Index %d", i)
+		if i == indexCount-1 {
+			label += " (End)"
+		}
+		idx := t.appendRow(Text(label))
+		u.appendUint32Row(idx, off, Text("Function Offset"), f.addrSymValue(u.base+uint64(funcOff), 0))
+		u.appendUint32Row(idx, off+4, Text("Second Level Page Section Offset"), Text(fmt.Sprintf("%#x", pageOff)))
+		u.appendUint32Row(idx, off+8, Text("LSDA Index Array Section Offset"), Text(fmt.Sprintf("%#x", lsdaOff)))
+
+		if pageOff != 0 {
+			if ok := u.populatePage(idx, pageOff, funcOff, next); !ok {
+				// TODO warning
+				return t, u
+			}
+		}
+
+		if lsdaOff < lsdaEnd {
+			if !u.has(lsdaOff, (lsdaEnd-lsdaOff)/8, 8) {
+				// TODO warning
+				return t, u
+			}
+			if lsdas == nil {
+				lsdas = &DataNode{Values: []Value{Text("LSDA Index")}}
+			}
+			for off := lsdaOff; off+8 <= lsdaEnd; off += 8 {
+				fn, lsda := u.base+uint64(u.uint32(off)), u.base+uint64(u.uint32(off+4))
+				u.lsdas[lsda] = fn
+				lsdas.appendRow(
+					Text(fmt.Sprintf("%#016x", u.addr(off))),
+					Text(fmt.Sprintf("% x", u.data[off:off+8])),
+					f.addrSymValue(fn, 0),
+					Value{}.appendText("LSDA at ").appendLink(fmt.Sprintf("%#x", lsda), addressLink(lsda, 0)),
+				)
+			}
+		}
+	}
+
+	if lsdas != nil {
+		t.rows = append(t.rows, lsdas)
+	}
+
+	return t, u
+}
+
+func (u *unwindInfo) populatePage(idx *DataNode, off, funcBase, funcEnd uint32) (ok bool) {
+	if !u.has(off, 1, 8) {
+		return false
+	}
+
+	kind := UNWIND_SECOND_LEVEL(u.uint32(off))
+	entryOff := uint32(u.uint16(off + 4))
+	entryCount := uint32(u.uint16(off + 6))
+
+	page := idx.appendRow(Text(fmt.Sprintf("Second Level Page (%s)", kind)))
+	u.appendUint32Row(page, off, Text("Kind"), Text(kind.String()))
+	u.appendUint16Row(page, off+4, Text("Entry Page Offset"), Text(fmt.Sprintf("%#x", entryOff)))
+	u.appendUint16Row(page, off+6, Text("Entry Count"), Text(fmt.Sprintf("%d", entryCount)))
+
+	switch kind {
+	case UNWIND_SECOND_LEVEL_REGULAR:
+		if !u.has(off+entryOff, entryCount, 8) {
+			return false
+		}
+		for i := uint32(0); i < entryCount; i++ {
+			e := off + entryOff + i*8
+			funcOff := u.uint32(e)
+			end := funcEnd
+			if i+1 < entryCount {
+				end = u.uint32(e + 8)
+			}
+			page.appendRow(
+				Text(fmt.Sprintf("%#016x", u.addr(e))),
+				Text(fmt.Sprintf("% x", u.data[e:e+8])),
+				u.funcValue(funcOff, end),
+				u.encodingValue(u.uint32(e+4), funcOff),
+			)
+		}
+	case UNWIND_SECOND_LEVEL_COMPRESSED:
+		if !u.has(off, 1, 12) {
+			return false
+		}
+		encOff := uint32(u.uint16(off + 8))
+		encCount := uint32(u.uint16(off + 10))
+		u.appendUint16Row(page, off+8, Text("Encodings Page Offset"), Text(fmt.Sprintf("%#x", encOff)))
+		u.appendUint16Row(page, off+10, Text("Encodings Count"), Text(fmt.Sprintf("%d", encCount)))
+
+		if !u.has(off+encOff, encCount, 4) || !u.has(off+entryOff, entryCount, 4) {
+			return false
+		}
+
+		var encs []uint32
+		if encCount != 0 {
+			n := page.appendRow(Text("Encodings"))
+			for i := uint32(0); i < encCount; i++ {
+				e := off + encOff + i*4
+				enc := u.uint32(e)
+				encs = append(encs, enc)
+				u.appendUint32Row(n, e, Text(fmt.Sprintf("Encoding %d", uint32(len(u.commons))+i)), u.encodingValue(enc, 0))
+			}
+		}
+
+		for i := uint32(0); i < entryCount; i++ {
+			e := off + entryOff + i*4
+			entry := u.uint32(e)
+			funcOff := funcBase + entry&0x00FFFFFF
+			end := funcEnd
+			if i+1 < entryCount {
+				end = funcBase + u.uint32(e+4)&0x00FFFFFF
+			}
+
+			var val Value
+			switch j := entry >> 24; {
+			case j < uint32(len(u.commons)):
+				val = append(Text(fmt.Sprintf("[%d] ", j)), u.encodingValue(u.commons[j], funcOff)...)
+			case j-uint32(len(u.commons)) < uint32(len(encs)):
+				val = append(Text(fmt.Sprintf("[%d] ", j)), u.encodingValue(encs[j-uint32(len(u.commons))], funcOff)...)
+			default:
+				val = Text(fmt.Sprintf("[%d] ?", j))
+			}
+
+			page.appendRow(
+				Text(fmt.Sprintf("%#016x", u.addr(e))),
+				Text(fmt.Sprintf("% x", u.data[e:e+4])),
+				u.funcValue(funcOff, end),
+				val,
+			)
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (u *unwindInfo) funcValue(off, end uint32) Value {
+	var size uint64
+	if off < end {
+		size = uint64(end - off)
+	}
+	return u.f.addrSymValue(u.base+uint64(off), size)
+}
+
+func (u *unwindInfo) personalityValue(off uint32) Value {
+	addr := u.base + uint64(off)
+	v := u.f.addrSymValue(addr, uint64(u.ptrSize()))
+	if fx := u.f.Fixups.Lookup(addr); fx != nil {
+		v = v.appendText(" -> " + u.f.fixupString(u.f.Fixups, fx))
+	} else if sym := u.f.indirectSymbol(addr); sym != nil {
+		v = v.appendText(" -> " + u.f.symName(sym.Name))
+	}
+	return v
+}
+
+// encodingValue returns the interpretation of enc. funcOff is used to read the stack size of
+// UNWIND_X86_64_MODE_STACK_IND, which is 0 if enc isn't bound to a function.
+func (u *unwindInfo) encodingValue(enc uint32, funcOff uint32) Value {
+	if enc == 0 {
+		return Text(fmt.Sprintf("%#08x (no unwind info)", enc))
+	}
+
+	v := Text(fmt.Sprintf("%#08x ", enc))
+
+	switch u.f.Cpu {
+	case macho.Cpu386:
+		v = append(v, u.x86EncodingValue(enc, funcOff, 4, UNWIND_X86_MODE(enc&UNWIND_MODE_MASK).String(), unwindX86Registers[:])...)
+	case macho.CpuAmd64:
+		v = append(v, u.x86EncodingValue(enc, funcOff, 8, UNWIND_X86_64_MODE(enc&UNWIND_MODE_MASK).String(), unwindX86_64Registers[:])...)
+	case macho.CpuArm | 0x01000000:
+		v = append(v, u.arm64EncodingValue(enc)...)
+	default:
+		v = v.appendText("?")
+	}
+
+	var flags []string
+	if enc&UNWIND_IS_NOT_FUNCTION_START != 0 {
+		flags = append(flags, "not function start")
+	}
+	if enc&UNWIND_HAS_LSDA != 0 {
+		flags = append(flags, "has LSDA")
+	}
+	if p := (enc & UNWIND_PERSONALITY_MASK) >> 28; p != 0 {
+		flags = append(flags, fmt.Sprintf("personality %d", p))
+	}
+	if len(flags) != 0 {
+		v = v.appendText(", " + strings.Join(flags, ", "))
+	}
+
+	return v
+}
+
+func (u *unwindInfo) x86EncodingValue(enc uint32, funcOff uint32, regSize uint32, mode string, regs []string) Value {
+	regName := func(r uint32) string {
+		if int(r) < len(regs) && regs[r] != "" {
+			return regs[r]
+		}
+		return fmt.Sprintf("?%d", r)
+	}
+
+	switch enc & UNWIND_MODE_MASK {
+	case 0x01000000: // UNWIND_X86_MODE_EBP_FRAME, UNWIND_X86_64_MODE_RBP_FRAME
+		fp := regs[6]
+		offset := (enc & UNWIND_X86_FRAME_OFFSET) >> 16
+		saved := enc & UNWIND_X86_FRAME_REGISTERS
+		var ss []string
+		for i := uint32(0); i < 5; i++ {
+			if r := (saved >> (3 * i)) & 0x7; r != 0 {
+				ss = append(ss, fmt.Sprintf("%s at -%d(%s)", regName(r), (offset-i)*regSize, fp))
+			}
+		}
+		if len(ss) == 0 {
+			return Text(fmt.Sprintf("%s (%s frame)", mode, fp))
+		}
+		return Text(fmt.Sprintf("%s (%s frame, %s)", mode, fp, strings.Join(ss, ", ")))
+	case 0x02000000, 0x03000000: // UNWIND_X86_MODE_STACK_IMMD, UNWIND_X86_MODE_STACK_IND
+		size := (enc & UNWIND_X86_FRAMELESS_STACK_SIZE) >> 16
+		adjust := (enc & UNWIND_X86_FRAMELESS_STACK_ADJUST) >> 13
+		count := (enc & UNWIND_X86_FRAMELESS_STACK_REG_COUNT) >> 10
+		perm := enc & UNWIND_X86_FRAMELESS_STACK_REG_PERMUTATION
+
+		var stack string
+		if enc&UNWIND_MODE_MASK == 0x02000000 {
+			stack = fmt.Sprintf("stack size %d", size*regSize)
+		} else {
+			// the stack size is the immediate of "sub $imm32, %esp" at function+size
+			stack = fmt.Sprintf("stack size at function+%#x", size)
+			if funcOff != 0 {
+				if imm, ok := u.textUint32(u.base + uint64(funcOff) + uint64(size)); ok {
+					stack = fmt.Sprintf("stack size %d", imm+adjust*regSize)
+				}
+			}
+		}
+
+		pushed := x86FramelessRegisters(count, perm)
+		if len(pushed) == 0 {
+			return Text(fmt.Sprintf("%s (frameless, %s)", mode, stack))
+		}
+		ss := make([]string, len(pushed))
+		for i, r := range pushed {
+			ss[i] = regName(r)
+		}
+		return Text(fmt.Sprintf("%s (frameless, %s, pushed %s)", mode, stack, strings.Join(ss, ", ")))
+	case 0x04000000: // UNWIND_X86_MODE_DWARF
+		return u.dwarfValue(mode, enc&UNWIND_X86_DWARF_SECTION_OFFSET)
+	}
+
+	return Text(mode)
+}
+
+// x86FramelessRegisters decodes the permutation of saved registers, which are returned in push order.
+func x86FramelessRegisters(count, perm uint32) []uint32 {
+	if count > 6 {
+		return nil
+	}
+
+	// the permutation is encoded in a variable base number system
+	var digits [6]uint32
+	switch count {
+	case 6, 5:
+		digits[0] = perm / 120
+		perm -= digits[0] * 120
+		digits[1] = perm / 24
+		perm -= digits[1] * 24
+		digits[2] = perm / 6
+		perm -= digits[2] * 6
+		digits[3] = perm / 2
+		perm -= digits[3] * 2
+		digits[4] = perm
+	case 4:
+		digits[0] = perm / 60
+		perm -= digits[0] * 60
+		digits[1] = perm / 12
+		perm -= digits[1] * 12
+		digits[2] = perm / 3
+		perm -= digits[2] * 3
+		digits[3] = perm
+	case 3:
+		digits[0] = perm / 20
+		perm -= digits[0] * 20
+		digits[1] = perm / 4
+		perm -= digits[1] * 4
+		digits[2] = perm
+	case 2:
+		digits[0] = perm / 5
+		perm -= digits[0] * 5
+		digits[1] = perm
+	case 1:
+		digits[0] = perm
+	}
+
+	// registers are numbered from the lowest address, so the last one is pushed first
+	var used [7]bool
+	regs := make([]uint32, count)
+	for i := uint32(0); i < count; i++ {
+		n := uint32(0)
+		for r := uint32(1); r < 7; r++ {
+			if used[r] {
+				continue
+			}
+			if n == digits[i] {
+				used[r] = true
+				regs[count-1-i] = r
+				break
+			}
+			n++
+		}
+	}
+	return regs
+}
+
+func (u *unwindInfo) arm64EncodingValue(enc uint32) Value {
+	mode := UNWIND_ARM64_MODE(enc & UNWIND_MODE_MASK)
+
+	var pairs []string
+	for _, p := range unwindARM64Pairs {
+		if enc&p.bit != 0 {
+			pairs = append(pairs, p.name)
+		}
+	}
+	saved := ""
+	if len(pairs) != 0 {
+		saved = ", saved " + strings.Join(pairs, ", ")
+	}
+
+	switch mode {
+	case UNWIND_ARM64_MODE_FRAME:
+		return Text(fmt.Sprintf("%s (fp/lr frame%s)", mode, saved))
+	case UNWIND_ARM64_MODE_FRAMELESS:
+		size := (enc & UNWIND_ARM64_FRAMELESS_STACK_SIZE_MASK) >> 12
+		return Text(fmt.Sprintf("%s (frameless, stack size %d%s)", mode, size*16, saved))
+	case UNWIND_ARM64_MODE_DWARF:
+		return u.dwarfValue(mode.String(), enc&UNWIND_ARM64_DWARF_SECTION_OFFSET)
+	}
+
+	return Text(mode.String())
+}
+
+// dwarfValue returns the FDE at off of __eh_frame, which links to the address.
+func (u *unwindInfo) dwarfValue(mode string, off uint32) Value {
+	v := Text(mode + " (FDE at ")
+	if u.ehFrame != nil {
+		v = v.appendLink(fmt.Sprintf("%#x", u.ehFrame.Addr+uint64(off)), addressLink(u.ehFrame.Addr+uint64(off), 0))
+	} else {
+		v = v.appendText(fmt.Sprintf("__eh_frame+%#x", off))
+	}
+	return v.appendText(")")
+}
+
+func (u *unwindInfo) textUint32(addr uint64) (uint32, bool) {
+	for _, sect := range u.f.Sections {
+		if sect.Addr <= addr && addr+4 <= sect.Addr+sect.Size && !u.f.isZeroSect(sect) {
+			var b [4]byte
+			if _, err := sect.ReadAt(b[:], int64(addr-sect.Addr)); err != nil {
+				return 0, false
+			}
+			return u.f.ByteOrder.Uint32(b[:]), true
+		}
+	}
+	return 0, false
+}
+
+func (u *unwindInfo) appendUint32Row(n *DataNode, off uint32, name, val Value) {
+	n.appendRow(
+		Text(fmt.Sprintf("%#016x", u.addr(off))),
+		Text(fmt.Sprintf("% x", u.data[off:off+4])),
+		name,
+		val,
+	)
+}
+
+func (u *unwindInfo) appendUint16Row(n *DataNode, off uint32, name, val Value) {
+	n.appendRow(
+		Text(fmt.Sprintf("%#016x", u.addr(off))),
+		Text(fmt.Sprintf("% x", u.data[off:off+2])),
+		name,
+		val,
+	)
+}
+
+// has reports whether the section contains count items of size at off.
+func (u *unwindInfo) has(off, count, size uint32) bool {
+	end := uint64(off) + uint64(count)*uint64(size)
+	return end <= uint64(len(u.data))
+}
+
+func (u *unwindInfo) addr(off uint32) uint64 {
+	return u.sect.Addr + uint64(off)
+}
+
+func (u *unwindInfo) uint32(off uint32) uint32 {
+	return u.f.ByteOrder.Uint32(u.data[off:])
+}
+
+func (u *unwindInfo) uint16(off uint32) uint16 {
+	return u.f.ByteOrder.Uint16(u.data[off:])
+}
+
+func (u *unwindInfo) ptrSize() int {
+	if u.f.Cpu&0x01000000 == 0 { // 32bit
+		return 4
+	}
+	return 8
+}
@@ -0,0 +1,48 @@
+package macho_analysis
+
+import (
+	"debug/macho"
+	"reflect"
+	"testing"
+)
+
+func TestX86FramelessRegisters(t *testing.T) {
+	for _, tt := range []struct {
+		count, perm uint32
+		regs        []uint32
+	}{
+		{0, 0, []uint32{}},
+		{1, 0, []uint32{1}},
+		{2, 15, []uint32{1, 4}},              // push %rbx; push %r14
+		{3, 10, []uint32{5, 4, 1}},           // push %r15; push %r14; push %rbx
+		{6, 0, []uint32{6, 5, 4, 3, 2, 1}},   // push %rbp; push %r15; ...; push %rbx
+		{6, 719, []uint32{1, 2, 3, 4, 5, 6}}, // push %rbx; push %r12; ...; push %rbp
+		{7, 0, nil},
+	} {
+		if regs := x86FramelessRegisters(tt.count, tt.perm); !reflect.DeepEqual(regs, tt.regs) {
+			t.Errorf("x86FramelessRegisters(%d, %d) = %v; want %v", tt.count, tt.perm, regs, tt.regs)
+		}
+	}
+}
+
+func TestUnwindEncodingValue(t *testing.T) {
+	for _, tt := range []struct {
+		cpu macho.Cpu
+		enc uint32
+		out string
+	}{
+		{macho.CpuAmd64, 0, "0x00000000 (no unwind info)"},
+		{macho.CpuAmd64, 0x01000000, "0x01000000 UNWIND_X86_64_MODE_RBP_FRAME (%rbp frame)"},
+		{macho.CpuAmd64, 0x01020011, "0x01020011 UNWIND_X86_64_MODE_RBP_FRAME (%rbp frame, %rbx at -16(%rbp), %r12 at -8(%rbp))"},
+		{macho.CpuAmd64, 0x0203080f, "0x0203080f UNWIND_X86_64_MODE_STACK_IMMD (frameless, stack size 24, pushed %rbx, %r14)"},
+		{macho.Cpu386, 0x01010001, "0x01010001 UNWIND_X86_MODE_EBP_FRAME (%ebp frame, %ebx at -4(%ebp))"},
+		{macho.CpuAmd64, 0x51000000, "0x51000000 UNWIND_X86_64_MODE_RBP_FRAME (%rbp frame), has LSDA, personality 1"},
+		{macho.CpuArm | 0x01000000, 0x04000001, "0x04000001 UNWIND_ARM64_MODE_FRAME (fp/lr frame, saved x19/x20)"},
+		{macho.CpuArm | 0x01000000, 0x02002000, "0x02002000 UNWIND_ARM64_MODE_FRAMELESS (frameless, stack size 32)"},
+	} {
+		u := &unwindInfo{f: &File{File: &macho.File{FileHeader: macho.FileHeader{Cpu: tt.cpu}}}}
+		if out := u.encodingValue(tt.enc, 0).String(); out != tt.out {
+			t.Errorf("encodingValue(%#x) for %v = %q; want %q", tt.enc, tt.cpu, out, tt.out)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package macho_analysis
+
+// Link is a link target of a value.
+// It points to a symbol if Symnum != -1, otherwise to Addr.
+type Link struct {
+	Symnum int
+	Addend int64
+	Addr   uint64
+	Size   uint64
+}
+
+func symbolLink(symnum int, addend int64, size uint64) *Link {
+	return &Link{Symnum: symnum, Addend: addend, Size: size}
+}
+
+func addressLink(addr uint64, size uint64) *Link {
+	return &Link{Symnum: -1, Addr: addr, Size: size}
+}
+
+// Span is a part of a value. Link is nil if the span is a plain text.
+type Span struct {
+	Text string
+	Link *Link
+	Mark bool // highlighted, e.g. the bytes patched by relocations
+}
+
+// Value is a decoded value, which consists of spans.
+// A nil Value means that there is nothing to show.
+type Value []Span
+
+// Text returns a Value without links.
+func Text(s string) Value {
+	return Value{{Text: s}}
+}
+
+func (v Value) String() string {
+	if len(v) == 1 {
+		return v[0].Text
+	}
+	var text string
+	for _, s := range v {
+		text += s.Text
+	}
+	return text
+}
+
+func (v Value) appendText(s string) Value {
+	return append(v, Span{Text: s})
+}
+
+func (v Value) appendLink(s string, l *Link) Value {
+	return append(v, Span{Text: s, Link: l})
+}
+
+func texts(ss []string) []Value {
+	vals := make([]Value, len(ss))
+	for i, s := range ss {
+		vals[i] = Text(s)
+	}
+	return vals
+}
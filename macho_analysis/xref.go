@@ -0,0 +1,527 @@
+//go:generate stringer -type=XrefKind -trimprefix=Xref -output xref_string.go
+
+package macho_analysis
+
+import (
+	"debug/macho"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/arch/arm64/arm64asm"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// XrefKind is a kind of references.
+type XrefKind uint8
+
+const (
+	XrefCall    XrefKind = iota // call instructions
+	XrefJump                    // jump and branch instructions
+	XrefData                    // loads, stores and address computations
+	XrefPointer                 // pointers in data
+)
+
+// Xref is a reference from the instruction or the pointer at From.
+// The reference is to the undefined symbol Symnum, or to To if Symnum is -1.
+type Xref struct {
+	From   uint64
+	To     uint64
+	Symnum int
+	Kind   XrefKind
+}
+
+var xrefHeader = []string{"Address", "Kind", "Reference"}
+
+type xrefTable struct {
+	from []Xref // sorted by From
+	to   []Xref // sorted by To, references to undefined symbols are excluded
+}
+
+// xrefTarget is a reference decoded from an instruction.
+type xrefTarget struct {
+	to   uint64
+	kind XrefKind
+}
+
+// XrefsTo returns the references to [addr, addr+size).
+func (f *File) XrefsTo(addr, size uint64) []Xref {
+	xrefs := f.xrefTable().to
+	i := sort.Search(len(xrefs), func(i int) bool {
+		return addr <= xrefs[i].To
+	})
+	j := i
+	for j < len(xrefs) && (xrefs[j].To == addr || xrefs[j].To < addr+size) {
+		j++
+	}
+	return xrefs[i:j]
+}
+
+// XrefsFrom returns the references from [addr, addr+size).
+func (f *File) XrefsFrom(addr, size uint64) []Xref {
+	xrefs := f.xrefTable().from
+	i := sort.Search(len(xrefs), func(i int) bool {
+		return addr <= xrefs[i].From
+	})
+	j := i
+	for j < len(xrefs) && (xrefs[j].From == addr || xrefs[j].From < addr+size) {
+		j++
+	}
+	return xrefs[i:j]
+}
+
+// newXrefSymbolData returns the references to and from sym.
+// Jumps within sym are omitted.
+func (f *File) newXrefSymbolData(sym *macho.Symbol) *DataTree {
+	t := &DataTree{Header: xrefHeader}
+
+	addr := sym.Value
+	size := f.SymInfos[addr].Size
+
+	inside := func(a uint64) bool {
+		return addr <= a && a < addr+size
+	}
+
+	var to, from []Xref
+	for _, x := range f.XrefsTo(addr, size) {
+		if x.Kind != XrefJump || !inside(x.From) {
+			to = append(to, x)
+		}
+	}
+	for _, x := range f.XrefsFrom(addr, size) {
+		if x.Kind != XrefJump || x.Symnum != -1 || !inside(x.To) {
+			from = append(from, x)
+		}
+	}
+
+	for _, list := range []struct {
+		name  string
+		xrefs []Xref
+	}{
+		{"References to", to},
+		{"References from", from},
+	} {
+		n := t.appendRow(Text(fmt.Sprintf("%s (%d)", list.name, len(list.xrefs))))
+		for _, x := range list.xrefs {
+			n.appendRow(f.addrSymValue(x.From, 0), Text(x.Kind.String()), f.xrefTargetValue(x))
+		}
+	}
+
+	return t
+}
+
+// xrefTargetValue returns the referenced address or symbol of x.
+func (f *File) xrefTargetValue(x Xref) Value {
+	if x.Symnum != -1 {
+		return Value{}.appendLink(f.symName(f.Syms[x.Symnum].Name), symbolLink(x.Symnum, 0, 0))
+	}
+	return f.addrSymValue(x.To, 0)
+}
+
+func (f *File) xrefTable() *xrefTable {
+	if f.xrefs != nil {
+		return f.xrefs
+	}
+
+	var xrefs []Xref
+
+	for _, sect := range f.Sections {
+		if f.isZeroSect(sect) {
+			continue
+		}
+		if f.GuessSectType(sect) == "Code" {
+			xrefs = f.appendCodeXrefs(xrefs, sect)
+		} else {
+			xrefs = f.appendRelocXrefs(xrefs, sect)
+		}
+	}
+	xrefs = f.appendPointerXrefs(xrefs)
+
+	idx := new(xrefTable)
+
+	idx.from = xrefs
+	sort.SliceStable(idx.from, func(i, j int) bool {
+		return idx.from[i].From < idx.from[j].From
+	})
+
+	for _, x := range xrefs {
+		if x.Symnum == -1 {
+			idx.to = append(idx.to, x)
+		}
+	}
+	sort.SliceStable(idx.to, func(i, j int) bool {
+		return idx.to[i].To < idx.to[j].To
+	})
+
+	f.xrefs = idx
+
+	return f.xrefs
+}
+
+// appendCodeXrefs appends references from instructions in sect.
+// Relocations take precedence over the decoded operands.
+func (f *File) appendCodeXrefs(xrefs []Xref, sect *macho.Section) []Xref {
+	data, err := sect.Data()
+	if err != nil {
+		// TODO warning
+		return xrefs
+	}
+
+	relocs := f.relocTargets(sect, data)
+
+	decode := f.xrefFunc()
+	if decode == nil {
+		// TODO warning
+		for _, r := range sect.Relocs {
+			if t, ok := relocs[uint64(r.Addr)]; ok && t != nil {
+				xrefs = f.appendRelocXref(xrefs, sect.Addr+uint64(r.Addr), t, XrefData)
+			}
+		}
+		return xrefs
+	}
+
+	for off := 0; off < len(data); {
+		pc := sect.Addr + uint64(off)
+		targets, size := decode(data[off:], pc)
+
+		kind := XrefData
+		if len(targets) > 0 {
+			kind = targets[0].kind
+		}
+
+		var relocated bool
+		for i := 0; i < size; i++ {
+			if t, ok := relocs[uint64(off+i)]; ok {
+				relocated = true
+				if t != nil {
+					xrefs = f.appendRelocXref(xrefs, pc, t, kind)
+				}
+			}
+		}
+
+		if !relocated {
+			for _, t := range targets {
+				if f.isMapped(t.to) {
+					xrefs = append(xrefs, Xref{From: pc, To: t.to, Symnum: -1, Kind: t.kind})
+				}
+			}
+		}
+
+		off += size
+	}
+
+	return xrefs
+}
+
+// appendRelocXrefs appends pointers relocated in sect of an object file.
+func (f *File) appendRelocXrefs(xrefs []Xref, sect *macho.Section) []Xref {
+	if len(sect.Relocs) == 0 || sect.Seg == "__DWARF" || sect.Seg == "__LD" {
+		return xrefs
+	}
+
+	data, err := sect.Data()
+	if err != nil {
+		// TODO warning
+		return xrefs
+	}
+
+	relocs := f.relocTargets(sect, data)
+
+	for _, r := range sect.Relocs {
+		if t, ok := relocs[uint64(r.Addr)]; ok && t != nil {
+			xrefs = f.appendRelocXref(xrefs, sect.Addr+uint64(r.Addr), t, XrefPointer)
+		}
+	}
+
+	return xrefs
+}
+
+// appendPointerXrefs appends pointers rebased or bound by dyld.
+func (f *File) appendPointerXrefs(xrefs []Xref) []Xref {
+	undefs := make(map[string]int)
+	for i := range f.Syms {
+		sym := &f.Syms[i]
+		if sym.Type&N_STAB == 0 && SymbolType(sym.Type&N_TYPE) == N_UNDF {
+			undefs[sym.Name] = i
+		}
+	}
+
+	bind := func(from uint64, name string) {
+		if i, ok := undefs[name]; ok {
+			xrefs = append(xrefs, Xref{From: from, Symnum: i, Kind: XrefPointer})
+		}
+	}
+
+	if f.Fixups != nil {
+		for _, fx := range f.Fixups.Fixups {
+			if fx.Bind {
+				if 0 <= fx.Import && fx.Import < len(f.Fixups.Imports) {
+					bind(fx.Addr, f.Fixups.Imports[fx.Import].Name)
+				}
+			} else if f.isMapped(fx.Target) {
+				xrefs = append(xrefs, Xref{From: fx.Addr, To: fx.Target, Symnum: -1, Kind: XrefPointer})
+			}
+		}
+	}
+
+	if cmd := f.dyldInfoCmd(); cmd != nil && cmd.RebaseSize != 0 {
+		data, err := f.readFileData(uint64(cmd.RebaseOff), uint64(cmd.RebaseSize))
+		if err != nil {
+			// TODO warning
+		} else {
+			_, rebases, err := f.decodeRebase(data, uint64(cmd.RebaseOff))
+			if err != nil {
+				// TODO warning
+			}
+			psize := f.pointerSize()
+			for _, r := range rebases {
+				if r.Type != REBASE_TYPE_POINTER {
+					continue
+				}
+				b := make([]byte, psize)
+				if _, err := r.Seg.ReadAt(b, int64(r.Addr-r.Seg.Addr)); err != nil {
+					// TODO warning
+					continue
+				}
+				var to uint64
+				if psize == 4 {
+					to = uint64(f.ByteOrder.Uint32(b))
+				} else {
+					to = f.ByteOrder.Uint64(b)
+				}
+				if f.isMapped(to) {
+					xrefs = append(xrefs, Xref{From: r.Addr, To: to, Symnum: -1, Kind: XrefPointer})
+				}
+			}
+		}
+	}
+
+	for addr, name := range f.bindSymbols() {
+		bind(addr, name)
+	}
+
+	return xrefs
+}
+
+// relocTargets returns the targets of relocations in sect, which are keyed by the offsets.
+// The target is nil if it is unknown.
+func (f *File) relocTargets(sect *macho.Section, data []byte) map[uint64]*RelocTarget {
+	if len(sect.Relocs) == 0 {
+		return nil
+	}
+
+	targets := make(map[uint64]*RelocTarget)
+	for i, r := range sect.Relocs {
+		if i > 0 && isRelocPair(f.Cpu, sect.Relocs[i-1], r) {
+			// e.g. X86_64_RELOC_UNSIGNED after X86_64_RELOC_SUBTRACTOR
+			continue
+		}
+		off := uint64(r.Addr)
+		size := relocSize(f.Cpu, r)
+		if uint64(len(data)) < off+size {
+			continue
+		}
+		if t := targets[off]; t != nil {
+			// keep the first one
+			continue
+		}
+		_, targets[off] = f.relocDataString(sect, i, 0, data[off:off+size])
+	}
+	return targets
+}
+
+// sectRelocTargets is like relocTargets, but reads sect and caches the targets.
+func (f *File) sectRelocTargets(sect *macho.Section) map[uint64]*RelocTarget {
+	if len(sect.Relocs) == 0 {
+		return nil
+	}
+
+	if targets, ok := f.relocs[sect]; ok {
+		return targets
+	}
+
+	if f.relocs == nil {
+		f.relocs = make(map[*macho.Section]map[uint64]*RelocTarget)
+	}
+
+	data, err := sect.Data()
+	if err != nil {
+		// TODO warning
+		f.relocs[sect] = nil
+		return nil
+	}
+	f.relocs[sect] = f.relocTargets(sect, data)
+	return f.relocs[sect]
+}
+
+func (f *File) appendRelocXref(xrefs []Xref, from uint64, t *RelocTarget, kind XrefKind) []Xref {
+	if t.Symnum == -1 {
+		return append(xrefs, Xref{From: from, To: addAddend(t.Symaddr, t.Addend), Symnum: -1, Kind: kind})
+	}
+	sym := f.symIndex(uint32(t.Symnum))
+	if sym == nil {
+		return xrefs
+	}
+	if sym.Type&N_STAB == 0 && SymbolType(sym.Type&N_TYPE) == N_SECT {
+		return append(xrefs, Xref{From: from, To: addAddend(sym.Value, t.Addend), Symnum: -1, Kind: kind})
+	}
+	return append(xrefs, Xref{From: from, Symnum: t.Symnum, Kind: kind})
+}
+
+func addAddend(addr uint64, addend int64) uint64 {
+	if addend < 0 {
+		return addr - uint64(-addend)
+	}
+	return addr + uint64(addend)
+}
+
+// isMapped reports whether addr is in any section.
+func (f *File) isMapped(addr uint64) bool {
+	for _, sect := range f.Sections {
+		if sect.Addr <= addr && addr < sect.Addr+sect.Size {
+			return true
+		}
+	}
+	return false
+}
+
+// xrefFunc returns a function decoding references from the instruction at pc, or nil if the CPU isn't supported.
+// TODO support arm and ppc64
+func (f *File) xrefFunc() func(code []byte, pc uint64) ([]xrefTarget, int) {
+	switch f.Cpu {
+	case macho.Cpu386:
+		return func(code []byte, pc uint64) ([]xrefTarget, int) {
+			inst, err := x86asm.Decode(code, 32)
+			if err != nil {
+				return nil, 1
+			}
+			return x86Xrefs(inst, pc, 32), inst.Len
+		}
+	case macho.CpuAmd64:
+		return func(code []byte, pc uint64) ([]xrefTarget, int) {
+			inst, err := x86asm.Decode(code, 64)
+			if err != nil {
+				return nil, 1
+			}
+			return x86Xrefs(inst, pc, 64), inst.Len
+		}
+	case macho.CpuArm | 0x01000000:
+		// pages loaded by ADRP
+		pages := make(map[arm64asm.Reg]uint64)
+		return func(code []byte, pc uint64) ([]xrefTarget, int) {
+			inst, err := arm64asm.Decode(code)
+			if err != nil {
+				return nil, 4
+			}
+			return arm64Xrefs(inst, pc, pages), 4
+		}
+	}
+
+	return nil
+}
+
+func x86Xrefs(inst x86asm.Inst, pc uint64, mode int) []xrefTarget {
+	var targets []xrefTarget
+	next := pc + uint64(inst.Len)
+	for _, arg := range inst.Args {
+		switch arg := arg.(type) {
+		case x86asm.Rel:
+			kind := XrefJump
+			if inst.Op == x86asm.CALL {
+				kind = XrefCall
+			}
+			targets = append(targets, xrefTarget{to: addAddend(next, int64(arg)), kind: kind})
+		case x86asm.Mem:
+			switch {
+			case arg.Base == x86asm.RIP:
+				targets = append(targets, xrefTarget{to: addAddend(next, arg.Disp), kind: XrefData})
+			case mode == 32 && arg.Base == 0 && arg.Index == 0 && arg.Segment == 0:
+				targets = append(targets, xrefTarget{to: uint64(uint32(arg.Disp)), kind: XrefData})
+			}
+		}
+	}
+	return targets
+}
+
+func arm64Xrefs(inst arm64asm.Inst, pc uint64, pages map[arm64asm.Reg]uint64) []xrefTarget {
+	var targets []xrefTarget
+
+	switch inst.Op {
+	case arm64asm.ADRP:
+		if rd, ok := inst.Args[0].(arm64asm.Reg); ok {
+			if rel, ok := inst.Args[1].(arm64asm.PCRel); ok {
+				pages[rd] = addAddend(pc&^0xfff, int64(rel))
+			}
+		}
+		return nil
+	case arm64asm.ADD:
+		rn, ok := inst.Args[1].(arm64asm.RegSP)
+		if !ok {
+			break
+		}
+		page, ok := pages[arm64asm.Reg(rn)]
+		if !ok {
+			break
+		}
+		// ImmShift doesn't export the immediate
+		var imm uint64
+		if n, _ := fmt.Sscanf(fmt.Sprint(inst.Args[2]), "#%v", &imm); n == 1 && !strings.Contains(fmt.Sprint(inst.Args[2]), ",") {
+			targets = append(targets, xrefTarget{to: page + imm, kind: XrefData})
+		}
+	case arm64asm.B, arm64asm.BL, arm64asm.CBZ, arm64asm.CBNZ, arm64asm.TBZ, arm64asm.TBNZ:
+		kind := XrefJump
+		if inst.Op == arm64asm.BL {
+			kind = XrefCall
+		}
+		for _, arg := range inst.Args {
+			if rel, ok := arg.(arm64asm.PCRel); ok {
+				targets = append(targets, xrefTarget{to: addAddend(pc, int64(rel)), kind: kind})
+			}
+		}
+		if inst.Op == arm64asm.B && inst.Args[1] == nil {
+			// the end of the basic block
+			for r := range pages {
+				delete(pages, r)
+			}
+		}
+		return targets
+	case arm64asm.RET, arm64asm.BR:
+		for r := range pages {
+			delete(pages, r)
+		}
+		return nil
+	}
+
+	defer func() {
+		// the destination register is overwritten
+		if rd, ok := inst.Args[0].(arm64asm.Reg); ok && !strings.HasPrefix(inst.Op.String(), "ST") {
+			if arm64asm.W0 <= rd && rd <= arm64asm.WZR {
+				rd += arm64asm.X0 - arm64asm.W0
+			}
+			delete(pages, rd)
+		}
+	}()
+
+	for _, arg := range inst.Args {
+		switch arg := arg.(type) {
+		case arm64asm.PCRel:
+			// ADR and literal loads
+			targets = append(targets, xrefTarget{to: addAddend(pc, int64(arg)), kind: XrefData})
+		case arm64asm.MemImmediate:
+			page, ok := pages[arm64asm.Reg(arg.Base)]
+			if !ok || arg.Mode != arm64asm.AddrOffset {
+				continue
+			}
+			// MemImmediate doesn't export the offset
+			var imm int64
+			if s := arg.String(); strings.Contains(s, ",") {
+				if n, _ := fmt.Sscanf(s[strings.Index(s, ",")+1:], "#%d]", &imm); n != 1 {
+					continue
+				}
+			}
+			targets = append(targets, xrefTarget{to: addAddend(page, imm), kind: XrefData})
+		}
+	}
+
+	return targets
+}
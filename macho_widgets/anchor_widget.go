@@ -23,17 +23,20 @@ func (f *File) NewAnchorWidget(anchor string) widgets.QWidget_ITF {
 		if err != nil {
 			panic(err)
 		}
-		addend, err := strconv.ParseInt(q.Get("addend"), 10, 8)
+		addend, err := strconv.ParseInt(q.Get("addend"), 10, 64)
 		if err != nil {
 			panic(err)
 		}
-		size, err := strconv.ParseInt(q.Get("size"), 10, 8)
+		size, err := strconv.ParseInt(q.Get("size"), 10, 64)
 		if err != nil {
 			panic(err)
 		}
 
 		head := []string{"Name", "Type", "Sect", "Desc", "Value"}
-		row := []string{sym.Name, f.symTypeString(sym), f.symSectionString(sym), f.symDescString(sym), f.symValueString(sym)}
+		row := make([]string, len(head))
+		for i := range row {
+			row[i] = f.SymtabCell(sym, i)
+		}
 
 		h := widgets.NewQTableWidget(nil)
 		h.VerticalHeader().SetVisible(false)
@@ -89,13 +92,13 @@ func (f *File) NewAnchorWidget(anchor string) widgets.QWidget_ITF {
 		if err != nil {
 			panic(err)
 		}
-		size, err := strconv.ParseInt(q.Get("size"), 10, 8)
+		size, err := strconv.ParseInt(q.Get("size"), 10, 64)
 		if err != nil {
 			panic(err)
 		}
 
 		head := []string{"Sectname", "Segname", "Addr", "Size", "Offset", "Align", "Reloff", "Nreloc", "Flags"}
-		row := []string{sect.Name, sect.Seg, fmt.Sprintf("%#016x", sect.Addr), fmt.Sprint(sect.Size), fmt.Sprint(sect.Offset), fmt.Sprintf("%d (%d)", sect.Align, 1<<sect.Align), fmt.Sprint(sect.Reloff), fmt.Sprint(sect.Nreloc), f.sectionFlagsString(sect.Flags, false)}
+		row := []string{sect.Name, sect.Seg, fmt.Sprintf("%#016x", sect.Addr), fmt.Sprint(sect.Size), fmt.Sprint(sect.Offset), fmt.Sprintf("%d (%d)", sect.Align, 1<<sect.Align), fmt.Sprint(sect.Reloff), fmt.Sprint(sect.Nreloc), f.SectionFlagsString(sect.Flags)}
 
 		h := widgets.NewQTableWidget(nil)
 		h.VerticalHeader().SetVisible(false)
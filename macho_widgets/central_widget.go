@@ -2,18 +2,85 @@ package macho_widgets
 
 import (
 	"debug/macho"
+	"fmt"
 
+	"github.com/hirochachacha/goview/macho_analysis"
 	"github.com/therecipe/qt/widgets"
 )
 
 func NewCentralWidget(parent widgets.QWidget_ITF, mf *macho.File) widgets.QWidget_ITF {
-	f := NewFile(mf)
+	return NewFile(mf).NewCentralWidget(parent)
+}
 
+// NewCentralWidget shows f, which may have loaded the dSYM.
+func (f *File) NewCentralWidget(parent widgets.QWidget_ITF) widgets.QWidget_ITF {
 	tab := widgets.NewQTabWidget(parent)
 	tab.AddTab(f.NewStructWidget(nil), "Structure")
 	tab.AddTab(f.NewSymtabWidget(nil), "Symbols")
+	if f.HasExportTrie() {
+		tab.AddTab(f.NewExptabWidget(nil), "Exports")
+	}
+	if f.HasDebugInfo() {
+		tab.AddTab(f.NewDebugInfoWidget(nil), "Debug Info")
+	}
+	if f.HasObjC() {
+		tab.AddTab(f.NewObjCWidget(nil), "Objective-C")
+	}
+	if f.HasSwift() {
+		tab.AddTab(f.NewSwiftWidget(nil), "Swift")
+	}
 	if f.Type == macho.TypeObj {
 		tab.AddTab(f.NewReltabWidget(nil), "Relocations")
 	}
 	return tab
 }
+
+// NewFatCentralWidget shows the fat header, and each architecture in its own tab.
+// If dsym isn't empty, each architecture uses the dSYM which has the same UUID.
+// A dSYM which matches no architecture is an error if it's specified explicitly, otherwise it's ignored.
+func NewFatCentralWidget(parent widgets.QWidget_ITF, ff *macho_analysis.FatFile, dsym string, explicit bool) (widgets.QWidget_ITF, error) {
+	files := make([]*File, len(ff.Arches))
+	var dsymErr error
+	loaded := false
+	for i, arch := range ff.Arches {
+		if arch.File == nil {
+			continue
+		}
+		files[i] = NewFile(arch.File)
+		if dsym == "" {
+			continue
+		}
+		// architectures which don't match are skipped
+		switch err := files[i].LoadDSYM(dsym); {
+		case err == nil:
+			loaded = true
+		case dsymErr == nil:
+			dsymErr = err
+		}
+	}
+	if dsym != "" && !loaded && explicit {
+		if dsymErr == nil {
+			dsymErr = fmt.Errorf("%s: no matching architecture", dsym)
+		}
+		return nil, dsymErr
+	}
+
+	tab := widgets.NewQTabWidget(parent)
+
+	strct := NewFatStructWidget(nil, ff)
+	strct.ConnectArchActivated(func(i int) {
+		tab.SetCurrentIndex(i + 1)
+	})
+
+	tab.AddTab(strct, "Structure")
+	for i, f := range files {
+		arch := &ff.Arches[i]
+		name := fmt.Sprintf("%d (%s)", i, macho_analysis.ArchString(arch.Cpu, arch.SubCpu))
+		if f == nil {
+			tab.AddTab(widgets.NewQLabel2(arch.Err.Error(), nil, 0), name)
+			continue
+		}
+		tab.AddTab(f.NewCentralWidget(nil), name)
+	}
+	return tab, nil
+}
@@ -0,0 +1,207 @@
+package macho_widgets
+
+import (
+	"math"
+	"strings"
+
+	"github.com/hirochachacha/goview/macho_analysis"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+	"github.com/therecipe/qt/widgets"
+)
+
+const (
+	cfgPadding = 6
+	cfgHGap    = 30
+	cfgVGap    = 50
+)
+
+// CfgView shows the control-flow graph of a function.
+// Back-edges are drawn in red, and blocks in loops are filled in yellow.
+type CfgView struct {
+	*widgets.QGraphicsView
+
+	scene  *widgets.QGraphicsScene
+	blocks []*macho_analysis.BasicBlock
+	rects  []*core.QRectF
+
+	blockClicked func(addr uint64)
+}
+
+func (f *File) NewCfgView(parent widgets.QWidget_ITF) *CfgView {
+	v := new(CfgView)
+
+	v.scene = widgets.NewQGraphicsScene(nil)
+	v.scene.ConnectMousePressEvent(func(e *widgets.QGraphicsSceneMouseEvent) {
+		v.scene.MousePressEventDefault(e)
+
+		pos := e.ScenePos()
+		for i, r := range v.rects {
+			if r.Contains(pos) {
+				if v.blockClicked != nil {
+					v.blockClicked(v.blocks[i].Start)
+				}
+				return
+			}
+		}
+	})
+
+	v.QGraphicsView = widgets.NewQGraphicsView2(v.scene, parent)
+	v.SetRenderHint(gui.QPainter__Antialiasing, true)
+	v.SetDragMode(widgets.QGraphicsView__ScrollHandDrag)
+
+	return v
+}
+
+// ConnectBlockClicked sets the callback, which is called with the start address of the clicked block.
+func (v *CfgView) ConnectBlockClicked(f func(addr uint64)) {
+	v.blockClicked = f
+}
+
+func (v *CfgView) SetBlocks(blocks []*macho_analysis.BasicBlock) {
+	v.scene.Clear()
+	v.blocks = blocks
+	v.rects = nil
+
+	if len(blocks) == 0 {
+		return
+	}
+
+	font := gui.QFontDatabase_SystemFont(gui.QFontDatabase__FixedFont)
+
+	plain := gui.NewQBrush3(gui.NewQColor3(255, 255, 255, 255), core.Qt__SolidPattern)
+	loop := gui.NewQBrush3(gui.NewQColor3(255, 245, 200, 255), core.Qt__SolidPattern)
+	border := gui.NewQPen3(gui.NewQColor3(0, 0, 0, 255))
+	edge := newCfgPen(gui.NewQColor3(64, 64, 64, 255), 1)
+	back := newCfgPen(gui.NewQColor3(220, 0, 0, 255), 2)
+
+	texts := make([]*widgets.QGraphicsSimpleTextItem, len(blocks))
+	widths := make([]float64, len(blocks))
+	heights := make([]float64, len(blocks))
+	for i, b := range blocks {
+		texts[i] = v.scene.AddSimpleText(strings.Join(b.Lines, "\n"), font)
+		texts[i].SetZValue(1)
+		r := texts[i].BoundingRect()
+		widths[i] = r.Width() + 2*cfgPadding
+		heights[i] = r.Height() + 2*cfgPadding
+	}
+
+	layers := cfgLayers(blocks)
+
+	v.rects = make([]*core.QRectF, len(blocks))
+
+	var y float64
+	for _, layer := range layers {
+		var width, height float64
+		for _, i := range layer {
+			width += widths[i] + cfgHGap
+			height = math.Max(height, heights[i])
+		}
+		x := -width / 2
+		for _, i := range layer {
+			v.rects[i] = core.NewQRectF4(x, y, widths[i], heights[i])
+			brush := plain
+			if blocks[i].Loop {
+				brush = loop
+			}
+			v.scene.AddRect2(x, y, widths[i], heights[i], border, brush)
+			texts[i].SetPos2(x+cfgPadding, y+cfgPadding)
+			x += widths[i] + cfgHGap
+		}
+		y += height + cfgVGap
+	}
+
+	for i, b := range blocks {
+		from := v.rects[i]
+		for k, j := range b.Succs {
+			to := v.rects[j]
+
+			sx, sy := from.X()+from.Width()/2, from.Y()+from.Height()
+			ex, ey := to.X()+to.Width()/2, to.Y()
+
+			path := gui.NewQPainterPath()
+			path.MoveTo2(sx, sy)
+			pen := edge
+			if b.Back[k] {
+				// go around the blocks on the right
+				dx := math.Max(from.Width(), to.Width())/2 + cfgHGap
+				path.CubicTo2(sx+dx, sy+cfgVGap, ex+dx, ey-cfgVGap, ex, ey)
+				pen = back
+			} else {
+				path.CubicTo2(sx, sy+cfgVGap/2, ex, ey-cfgVGap/2, ex, ey)
+			}
+			// the arrowhead
+			path.MoveTo2(ex-4, ey-8)
+			path.LineTo2(ex, ey)
+			path.LineTo2(ex+4, ey-8)
+
+			v.scene.AddPath(path, pen, gui.NewQBrush())
+		}
+	}
+
+	v.scene.SetSceneRect(v.scene.ItemsBoundingRect())
+	v.CenterOn(v.rects[0].Center())
+}
+
+func newCfgPen(color *gui.QColor, width float64) *gui.QPen {
+	return gui.NewQPen4(gui.NewQBrush3(color, core.Qt__SolidPattern), width, core.Qt__SolidLine, core.Qt__RoundCap, core.Qt__RoundJoin)
+}
+
+// cfgLayers assigns blocks to layers by the longest path from the entry, ignoring back-edges.
+func cfgLayers(blocks []*macho_analysis.BasicBlock) [][]int {
+	indeg := make([]int, len(blocks))
+	for _, b := range blocks {
+		for k, j := range b.Succs {
+			if !b.Back[k] {
+				indeg[j]++
+			}
+		}
+	}
+
+	depth := make([]int, len(blocks))
+	done := make([]bool, len(blocks))
+
+	var queue []int
+	for i := range blocks {
+		if indeg[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	maxDepth := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		done[i] = true
+		if depth[i] > maxDepth {
+			maxDepth = depth[i]
+		}
+		b := blocks[i]
+		for k, j := range b.Succs {
+			if b.Back[k] {
+				continue
+			}
+			if depth[j] < depth[i]+1 {
+				depth[j] = depth[i] + 1
+			}
+			indeg[j]--
+			if indeg[j] == 0 {
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	// cycles unreachable from the entry
+	for i := range blocks {
+		if !done[i] {
+			maxDepth++
+			depth[i] = maxDepth
+		}
+	}
+
+	layers := make([][]int, maxDepth+1)
+	for i := range blocks {
+		layers[depth[i]] = append(layers[depth[i]], i)
+	}
+	return layers
+}
@@ -0,0 +1,149 @@
+package macho_widgets
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/hirochachacha/goview/macho_analysis"
+	"github.com/therecipe/qt/core"
+)
+
+// dataFetchSize is the number of rows indexed by a fetch.
+const dataFetchSize = 1024
+
+// dataPath is the row numbers from the top-level row to a parent row.
+// The internal pointer of an index points to the dataPath of its parent, or nil for top-level rows.
+type dataPath struct {
+	rows []int
+}
+
+// DataTreeModel shows macho_analysis.DataTree.
+// Rows are fetched as the view scrolls, and decoded when they are shown.
+type DataTreeModel struct {
+	*core.QAbstractItemModel
+
+	t     *macho_analysis.DataTree
+	nrows int
+	paths map[string]*dataPath // keep alive while Qt holds them
+}
+
+// newDataTreeModel returns the model of t for DataView, or nil if t is nil.
+func newDataTreeModel(t *macho_analysis.DataTree) core.QAbstractItemModel_ITF {
+	if t == nil {
+		return nil
+	}
+
+	m := &DataTreeModel{
+		QAbstractItemModel: core.NewQAbstractItemModel(nil),
+		t:                  t,
+		nrows:              t.Len(),
+		paths:              make(map[string]*dataPath),
+	}
+
+	m.ConnectIndex(func(row int, column int, parent *core.QModelIndex) *core.QModelIndex {
+		if !m.HasIndex(row, column, parent) {
+			return core.NewQModelIndex()
+		}
+		var p unsafe.Pointer
+		if parent.IsValid() {
+			p = unsafe.Pointer(m.path(m.rowsOf(parent)))
+		}
+		return m.CreateIndex(row, column, p)
+	})
+	m.ConnectParent(func(index *core.QModelIndex) *core.QModelIndex {
+		if !index.IsValid() {
+			return core.NewQModelIndex()
+		}
+		p := (*dataPath)(index.InternalPointer())
+		if p == nil {
+			return core.NewQModelIndex()
+		}
+		var pp unsafe.Pointer
+		if len(p.rows) > 1 {
+			pp = unsafe.Pointer(m.path(p.rows[:len(p.rows)-1]))
+		}
+		return m.CreateIndex(p.rows[len(p.rows)-1], 0, pp)
+	})
+	m.ConnectRowCount(func(parent *core.QModelIndex) int {
+		if !parent.IsValid() {
+			return m.nrows
+		}
+		if parent.Column() != 0 {
+			return 0
+		}
+		return len(m.node(m.rowsOf(parent)).Nodes())
+	})
+	m.ConnectColumnCount(func(parent *core.QModelIndex) int {
+		return len(t.Header)
+	})
+	m.ConnectHeaderData(func(section int, orientation core.Qt__Orientation, role int) *core.QVariant {
+		if role == int(core.Qt__DisplayRole) {
+			var val string
+			switch orientation {
+			case core.Qt__Horizontal:
+				val = t.Header[section]
+			case core.Qt__Vertical:
+				val = fmt.Sprint(section)
+			}
+			return core.NewQVariant14(val)
+		}
+		return core.NewQVariant()
+	})
+	m.ConnectData(func(index *core.QModelIndex, role int) *core.QVariant {
+		if role != int(core.Qt__DisplayRole) {
+			return core.NewQVariant()
+		}
+		if !index.IsValid() {
+			return core.NewQVariant()
+		}
+		n := m.node(m.rowsOf(index))
+		if col := index.Column(); col < len(n.Values) && n.Values[col] != nil {
+			return core.NewQVariant14(htmlString(n.Values[col]))
+		}
+		return core.NewQVariant()
+	})
+	m.ConnectCanFetchMore(func(parent *core.QModelIndex) bool {
+		return !parent.IsValid() && t.More()
+	})
+	m.ConnectFetchMore(func(parent *core.QModelIndex) {
+		if parent.IsValid() {
+			return
+		}
+		n := t.Fetch(dataFetchSize)
+		if n == 0 {
+			return
+		}
+		m.BeginInsertRows(parent, m.nrows, m.nrows+n-1)
+		m.nrows += n
+		m.EndInsertRows()
+	})
+
+	return m
+}
+
+// rowsOf returns the row numbers from the top-level row to index.
+func (m *DataTreeModel) rowsOf(index *core.QModelIndex) []int {
+	var rows []int
+	if p := (*dataPath)(index.InternalPointer()); p != nil {
+		rows = append(rows, p.rows...)
+	}
+	return append(rows, index.Row())
+}
+
+func (m *DataTreeModel) path(rows []int) *dataPath {
+	key := fmt.Sprint(rows)
+	p, ok := m.paths[key]
+	if !ok {
+		p = &dataPath{rows: append([]int(nil), rows...)}
+		m.paths[key] = p
+	}
+	return p
+}
+
+func (m *DataTreeModel) node(rows []int) *macho_analysis.DataNode {
+	n := m.t.Row(rows[0])
+	for _, row := range rows[1:] {
+		n = n.Nodes()[row]
+	}
+	return n
+}
@@ -16,7 +16,8 @@ const (
 type DataView struct {
 	*widgets.QWidget
 
-	tree *widgets.QTreeView
+	tree  *widgets.QTreeView
+	model *DataTreeModel
 }
 
 func (f *File) NewDataView(parent widgets.QWidget_ITF) *DataView {
@@ -76,9 +77,33 @@ func (d *DataView) SetAlternatingRowColors(b bool) {
 }
 
 func (d *DataView) SetModel(m core.QAbstractItemModel_ITF) {
+	d.model, _ = m.(*DataTreeModel)
 	d.tree.SetModel(m)
 }
 
+// SelectAddress selects the top-level row at addr, fetching rows as needed.
+func (d *DataView) SelectAddress(addr uint64) {
+	m := d.model
+	if m == nil {
+		return
+	}
+
+	root := core.NewQModelIndex()
+
+	row := m.t.RowAt(addr)
+	for row == -1 && m.CanFetchMore(root) {
+		m.FetchMore(root)
+		row = m.t.RowAt(addr)
+	}
+	if row == -1 {
+		return
+	}
+
+	index := m.Index(row, 0, root)
+	d.tree.SetCurrentIndex(index)
+	d.tree.ScrollTo(index, widgets.QAbstractItemView__PositionAtCenter)
+}
+
 func NewHtmlItemDelegate(parent core.QObject_ITF) widgets.QAbstractItemDelegate_ITF {
 	d := widgets.NewQStyledItemDelegate(parent)
 
@@ -0,0 +1,9 @@
+package macho_widgets
+
+import (
+	"github.com/therecipe/qt/core"
+)
+
+func (f *File) NewDebugInfoModel() core.QAbstractItemModel_ITF {
+	return newDataTreeModel(f.DebugInfo())
+}
@@ -0,0 +1,25 @@
+package macho_widgets
+
+import (
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// _________________
+// |___|___|___|___|
+// |___|___|___|___|
+// |___|___|___|___|
+func (f *File) NewDebugInfoWidget(parent widgets.QWidget_ITF) widgets.QWidget_ITF {
+	info := f.NewDataView(nil)
+	info.SetModel(f.NewDebugInfoModel())
+	info.SetAlternatingRowColors(true)
+	info.Header().SetDefaultAlignment(core.Qt__AlignLeft)
+	info.Header().SetSectionResizeMode(widgets.QHeaderView__ResizeToContents)
+
+	w := widgets.NewQWidget(parent, 0)
+	layout := widgets.NewQVBoxLayout()
+	layout.AddWidget(info, 0, 0)
+	w.SetLayout(layout)
+
+	return w
+}
@@ -0,0 +1,96 @@
+package macho_widgets
+
+import (
+	"fmt"
+
+	"github.com/hirochachacha/goview/macho_analysis"
+	"github.com/therecipe/qt/core"
+)
+
+const ExportItemRole = core.Qt__UserRole + 1
+
+type ExptabModel struct {
+	Exptab core.QAbstractItemModel_ITF
+	syms   []macho_analysis.ExportSymbol
+}
+
+func (f *File) NewExptabModel() *ExptabModel {
+	m := new(ExptabModel)
+
+	var err error
+	m.syms, err = f.ExportSymbols()
+	if err != nil {
+		// TODO warning
+	}
+
+	exptab := core.NewQSortFilterProxyModel(nil)
+	exptab.SetSourceModel(m.newExptabModel(f))
+	exptab.ConnectFilterAcceptsRow(func(sourceRow int, sourceParent *core.QModelIndex) bool {
+		sm := exptab.SourceModel()
+
+		name := sm.Index(sourceRow, 0, sourceParent).Data(int(ExportItemRole)).ToString()
+
+		return exptab.FilterRegExp().IndexIn(name, 0, core.QRegExp__CaretAtZero) != -1
+	})
+
+	m.Exptab = exptab
+
+	return m
+}
+
+func (m *ExptabModel) SetFilterName(s string) {
+	m.Exptab.(*core.QSortFilterProxyModel).SetFilterRegExp2(s)
+}
+
+func (m *ExptabModel) newExptabModel(f *File) core.QAbstractItemModel_ITF {
+	header := macho_analysis.ExptabHeader
+
+	exptab := core.NewQAbstractTableModel(nil)
+	exptab.ConnectRowCount(func(parent *core.QModelIndex) int {
+		return len(m.syms)
+	})
+	exptab.ConnectColumnCount(func(parent *core.QModelIndex) int {
+		return len(header)
+	})
+	exptab.ConnectHeaderData(func(section int, orientation core.Qt__Orientation, role int) *core.QVariant {
+		if role == int(core.Qt__DisplayRole) {
+			var val string
+			switch orientation {
+			case core.Qt__Horizontal:
+				val = header[section]
+			case core.Qt__Vertical:
+				val = fmt.Sprint(section)
+			}
+			return core.NewQVariant14(val)
+		}
+		return core.NewQVariant()
+	})
+	exptab.ConnectData(func(index *core.QModelIndex, role int) *core.QVariant {
+		if !index.IsValid() {
+			return core.NewQVariant()
+		}
+
+		row := index.Row()
+		if row < 0 || len(m.syms) <= row {
+			return core.NewQVariant()
+		}
+
+		sym := &m.syms[row]
+
+		switch core.Qt__ItemDataRole(role) {
+		case ExportItemRole:
+			return core.NewQVariant14(sym.Name)
+		case core.Qt__DisplayRole:
+			return core.NewQVariant14(htmlString(f.ExptabCell(sym, index.Column())))
+		case core.Qt__ToolTipRole:
+			// the mangled name
+			if index.Column() == 0 {
+				return core.NewQVariant14(sym.Name)
+			}
+		}
+
+		return core.NewQVariant()
+	})
+
+	return exptab
+}
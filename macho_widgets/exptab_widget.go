@@ -0,0 +1,38 @@
+package macho_widgets
+
+import (
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// _____________
+// |___________|
+// |___|___|___|
+// |___|___|___|
+// |___|___|___|
+func (f *File) NewExptabWidget(parent widgets.QWidget_ITF) widgets.QWidget_ITF {
+	exptabModel := f.NewExptabModel()
+
+	searchName := widgets.NewQLineEdit(nil)
+	searchName.SetPlaceholderText("Search...")
+
+	exptab := f.NewDataView(nil)
+	exptab.SetModel(exptabModel.Exptab)
+	exptab.SetAlternatingRowColors(true)
+	exptab.Header().SetDefaultAlignment(core.Qt__AlignLeft)
+	exptab.Header().SetSectionResizeMode(widgets.QHeaderView__ResizeToContents)
+
+	searchName.ConnectEditingFinished(func() {
+		exptabModel.SetFilterName(searchName.Text())
+	})
+
+	w := widgets.NewQWidget(parent, 0)
+	layout := widgets.NewQVBoxLayout()
+	layout.AddWidget(searchName, 0, 0)
+	layout.AddWidget(exptab, 0, 0)
+	w.SetLayout(layout)
+
+	searchName.SetFocus2()
+
+	return w
+}
@@ -0,0 +1,40 @@
+package macho_widgets
+
+import (
+	"github.com/hirochachacha/goview/macho_analysis"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/gui"
+)
+
+const FatArchItemRole = StructItemRole + 1
+
+func NewFatStructModel(ff *macho_analysis.FatFile) *StructModel {
+	m := new(StructModel)
+
+	tree := gui.NewQStandardItemModel(nil)
+
+	root := tree.InvisibleRootItem()
+
+	hdr := m.newStructItem(macho_analysis.FatStructTree(ff))
+	for i := range ff.Arches {
+		hdr.Child(i, 0).SetData(core.NewQVariant7(i+1), FatArchItemRole)
+	}
+
+	m.attrTabCache = make([]core.QAbstractItemModel_ITF, len(m.attrTabFuncs))
+
+	root.AppendRow2(hdr)
+
+	m.Tree = tree
+
+	return m
+}
+
+// FatArch returns the index of the architecture in the FatFile, or -1.
+func (m *StructModel) FatArch(index *core.QModelIndex) int {
+	if val := index.Data(FatArchItemRole); val.IsValid() {
+		if i := val.ToInt(false); 0 < i {
+			return i - 1
+		}
+	}
+	return -1
+}
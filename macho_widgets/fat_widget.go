@@ -0,0 +1,65 @@
+package macho_widgets
+
+import (
+	"github.com/hirochachacha/goview/macho_analysis"
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+type FatStructWidget struct {
+	*widgets.QWidget
+
+	strctModel *StructModel
+	strct      *widgets.QTreeView
+}
+
+// ___________________________
+// Fat Header        |___|___|
+//   Fat Arch 0      |___|___|
+//   Fat Arch 1      |   |   |
+func NewFatStructWidget(parent widgets.QWidget_ITF, ff *macho_analysis.FatFile) *FatStructWidget {
+	strctModel := NewFatStructModel(ff)
+
+	strct := widgets.NewQTreeView(nil)
+	strct.SetHeaderHidden(true)
+	strct.SetModel(strctModel.Tree)
+	strct.SetEditTriggers(widgets.QAbstractItemView__NoEditTriggers)
+	strct.ExpandAll()
+
+	attr := widgets.NewQTreeView(nil)
+	attr.SetSelectionBehavior(widgets.QAbstractItemView__SelectRows)
+	attr.SetEditTriggers(widgets.QAbstractItemView__NoEditTriggers)
+	attr.SetItemDelegate(NewHtmlItemDelegate(nil))
+	attr.SetAlternatingRowColors(true)
+
+	strct.ConnectCurrentChanged(func(current *core.QModelIndex, previous *core.QModelIndex) {
+		attr.SetModel(strctModel.AttrTab(current))
+	})
+
+	sp := widgets.NewQSplitter(nil)
+	sp.AddWidget(strct)
+	sp.AddWidget(attr)
+	sp.SetStretchFactor(0, 2)
+	sp.SetStretchFactor(1, 3)
+
+	layout := widgets.NewQVBoxLayout()
+	layout.AddWidget(sp, 0, 0)
+
+	w := widgets.NewQWidget(parent, 0)
+	w.SetLayout(layout)
+
+	return &FatStructWidget{
+		QWidget:    w,
+		strctModel: strctModel,
+		strct:      strct,
+	}
+}
+
+// ConnectArchActivated calls f with the index of the architecture when its item is double-clicked.
+func (w *FatStructWidget) ConnectArchActivated(f func(int)) {
+	w.strct.ConnectDoubleClicked(func(index *core.QModelIndex) {
+		if i := w.strctModel.FatArch(index); i != -1 {
+			f(i)
+		}
+	})
+}
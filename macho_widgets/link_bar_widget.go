@@ -0,0 +1,76 @@
+package macho_widgets
+
+import (
+	"debug/macho"
+	"fmt"
+	"strconv"
+
+	"github.com/hirochachacha/goview/macho_analysis"
+	"github.com/therecipe/qt/widgets"
+)
+
+// LinkBarWidget toggles the linked view of object files, where relocations are applied with the base address.
+// It is hidden for other files.
+type LinkBarWidget struct {
+	*widgets.QWidget
+
+	btn     *widgets.QPushButton
+	base    *widgets.QLineEdit
+	f       *File
+	changed func()
+}
+
+func (f *File) NewLinkBarWidget(parent widgets.QWidget_ITF) *LinkBarWidget {
+	w := new(LinkBarWidget)
+
+	w.btn = widgets.NewQPushButton2("Linked", nil)
+	w.btn.SetCheckable(true)
+
+	w.base = widgets.NewQLineEdit(nil)
+	w.base.SetText(fmt.Sprintf("%#x", f.DefaultLinkLayout().Base))
+	w.base.SetToolTip("Base address")
+	w.base.SetEnabled(false)
+
+	w.btn.ConnectToggled(func(checked bool) {
+		w.base.SetEnabled(checked)
+		if w.changed != nil {
+			w.changed()
+		}
+	})
+	w.base.ConnectEditingFinished(func() {
+		if w.changed != nil {
+			w.changed()
+		}
+	})
+
+	w.f = f
+
+	hlayout := widgets.NewQHBoxLayout()
+	hlayout.AddWidget(w.btn, 0, 0)
+	hlayout.AddWidget(w.base, 0, 0)
+	hlayout.SetContentsMargins(0, 0, 0, 0)
+
+	w.QWidget = widgets.NewQWidget(parent, 0)
+	w.QWidget.SetLayout(hlayout)
+	w.QWidget.SetVisible(f.Type == macho.TypeObj)
+
+	return w
+}
+
+// LinkLayout returns the layout of the linked view and whether the view is on.
+func (w *LinkBarWidget) LinkLayout() (macho_analysis.LinkLayout, bool) {
+	if !w.btn.IsChecked() {
+		return macho_analysis.LinkLayout{}, false
+	}
+	base, err := strconv.ParseUint(w.base.Text(), 0, 64)
+	if err != nil {
+		// TODO warning
+		return w.f.DefaultLinkLayout(), true
+	}
+	return macho_analysis.LinkLayout{Base: base}, true
+}
+
+// ConnectChanged sets f, which is called when the linked view is toggled or the base address is changed.
+func (w *LinkBarWidget) ConnectChanged(f func()) {
+	w.changed = f
+}
@@ -0,0 +1,25 @@
+package macho_widgets
+
+import (
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// _________________
+// |___|___|___|___|
+// |___|___|___|___|
+// |___|___|___|___|
+func (f *File) NewObjCWidget(parent widgets.QWidget_ITF) widgets.QWidget_ITF {
+	objc := f.NewDataView(nil)
+	objc.SetModel(f.NewObjCModel())
+	objc.SetAlternatingRowColors(true)
+	objc.Header().SetDefaultAlignment(core.Qt__AlignLeft)
+	objc.Header().SetSectionResizeMode(widgets.QHeaderView__ResizeToContents)
+
+	w := widgets.NewQWidget(parent, 0)
+	layout := widgets.NewQVBoxLayout()
+	layout.AddWidget(objc, 0, 0)
+	w.SetLayout(layout)
+
+	return w
+}
@@ -10,8 +10,10 @@ type SectdataWidget struct {
 	*widgets.QWidget
 
 	bb    *ButtonBarWidget
+	lbar  *LinkBarWidget
 	tree  *DataView
 	f     *File
+	typ   string
 	sect  *macho.Section
 	taddr uint64
 	tsize int64
@@ -20,7 +22,7 @@ type SectdataWidget struct {
 func (f *File) NewSectdataWidget(parent widgets.QWidget_ITF) *SectdataWidget {
 	w := new(SectdataWidget)
 
-	labels := []string{"Code", "CString", "Float32", "Float64", "Float128", "Pointer32", "Data"}
+	labels := []string{"Code", "Source", "CString", "Float32", "Float64", "Float128", "Pointer32", "Pointer64", "Data"}
 
 	w.bb = f.NewButtonBarWidget(nil, labels)
 	w.tree = f.NewDataView(nil)
@@ -36,10 +38,21 @@ func (f *File) NewSectdataWidget(parent widgets.QWidget_ITF) *SectdataWidget {
 		}
 	})
 
+	w.lbar = f.NewLinkBarWidget(nil)
+	w.lbar.ConnectChanged(func() {
+		w.SetModel(w.typ)
+	})
+
 	w.f = f
 
+	hlayout := widgets.NewQHBoxLayout()
+	hlayout.AddWidget(w.bb, 0, 0)
+	hlayout.AddWidget(w.lbar, 0, 0)
+	hlayout.AddStretch(1)
+	hlayout.SetContentsMargins(0, 0, 0, 0)
+
 	vlayout := widgets.NewQVBoxLayout()
-	vlayout.AddWidget(w.bb, 0, 0)
+	vlayout.AddLayout(hlayout, 0)
 	vlayout.AddWidget(w.tree, 0, 0)
 	vlayout.SetContentsMargins(0, 0, 0, 0)
 
@@ -54,7 +67,7 @@ func (w *SectdataWidget) SetSection(sect *macho.Section, taddr uint64, tsize int
 	w.taddr = taddr
 	w.tsize = tsize
 
-	typ := w.f.guessSectType(w.sect)
+	typ := w.f.GuessSectType(w.sect)
 
 	w.bb.SetChecked(typ, true)
 
@@ -62,9 +75,16 @@ func (w *SectdataWidget) SetSection(sect *macho.Section, taddr uint64, tsize int
 }
 
 func (w *SectdataWidget) SetModel(typ string) {
+	w.typ = typ
+
 	if w.sect == nil {
 		return
 	}
 
+	if layout, ok := w.lbar.LinkLayout(); ok {
+		w.tree.SetModel(w.f.NewLinkedSectionModel(typ, w.sect, layout))
+		return
+	}
+
 	w.tree.SetModel(w.f.NewSectionModel(typ, w.sect, w.taddr, w.tsize))
 }
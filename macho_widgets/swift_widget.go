@@ -0,0 +1,25 @@
+package macho_widgets
+
+import (
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// _________________
+// |___|___|___|___|
+// |___|___|___|___|
+// |___|___|___|___|
+func (f *File) NewSwiftWidget(parent widgets.QWidget_ITF) widgets.QWidget_ITF {
+	swift := f.NewDataView(nil)
+	swift.SetModel(f.NewSwiftModel())
+	swift.SetAlternatingRowColors(true)
+	swift.Header().SetDefaultAlignment(core.Qt__AlignLeft)
+	swift.Header().SetSectionResizeMode(widgets.QHeaderView__ResizeToContents)
+
+	w := widgets.NewQWidget(parent, 0)
+	layout := widgets.NewQVBoxLayout()
+	layout.AddWidget(swift, 0, 0)
+	w.SetLayout(layout)
+
+	return w
+}
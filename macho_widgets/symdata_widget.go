@@ -2,7 +2,9 @@ package macho_widgets
 
 import (
 	"debug/macho"
+	"os"
 
+	"github.com/hirochachacha/goview/macho_analysis"
 	"github.com/therecipe/qt/widgets"
 )
 
@@ -10,8 +12,13 @@ type SymdataWidget struct {
 	*widgets.QWidget
 
 	bb      *ButtonBarWidget
+	lbar    *LinkBarWidget
 	tree    *DataView
+	graph   *CfgView
+	gbtn    *widgets.QPushButton
+	dbtn    *widgets.QPushButton
 	f       *File
+	typ     string
 	sym     *macho.Symbol
 	taddend int64
 	tsize   int64
@@ -20,7 +27,7 @@ type SymdataWidget struct {
 func (f *File) NewSymdataWidget(parent widgets.QWidget_ITF) *SymdataWidget {
 	w := new(SymdataWidget)
 
-	labels := []string{"Code", "CString", "Float32", "Float64", "Float128", "Pointer32", "Data", "DwarfType"}
+	labels := []string{"Code", "Source", "CString", "Float32", "Float64", "Float128", "Pointer32", "Pointer64", "Data", "DwarfType", "Xrefs", "Callers", "Callees"}
 
 	w.bb = f.NewButtonBarWidget(nil, labels)
 	w.tree = f.NewDataView(nil)
@@ -36,11 +43,46 @@ func (f *File) NewSymdataWidget(parent widgets.QWidget_ITF) *SymdataWidget {
 		}
 	})
 
+	w.graph = f.NewCfgView(nil)
+	w.graph.ConnectBlockClicked(func(addr uint64) {
+		w.tree.SelectAddress(addr)
+	})
+	w.graph.Hide()
+
+	w.gbtn = widgets.NewQPushButton2("Graph", nil)
+	w.gbtn.SetCheckable(true)
+	w.gbtn.ConnectToggled(func(checked bool) {
+		w.updateGraph()
+	})
+
+	w.dbtn = widgets.NewQPushButton2("Export DOT...", nil)
+	w.dbtn.SetEnabled(false)
+	w.dbtn.ConnectClicked(func(checked bool) {
+		w.exportDOT()
+	})
+
+	w.lbar = f.NewLinkBarWidget(nil)
+	w.lbar.ConnectChanged(func() {
+		w.SetModel(w.typ)
+	})
+
 	w.f = f
 
+	hlayout := widgets.NewQHBoxLayout()
+	hlayout.AddWidget(w.bb, 0, 0)
+	hlayout.AddWidget(w.lbar, 0, 0)
+	hlayout.AddWidget(w.gbtn, 0, 0)
+	hlayout.AddWidget(w.dbtn, 0, 0)
+	hlayout.AddStretch(1)
+	hlayout.SetContentsMargins(0, 0, 0, 0)
+
+	sp := widgets.NewQSplitter(nil)
+	sp.AddWidget(w.tree)
+	sp.AddWidget(w.graph)
+
 	vlayout := widgets.NewQVBoxLayout()
-	vlayout.AddWidget(w.bb, 0, 0)
-	vlayout.AddWidget(w.tree, 0, 0)
+	vlayout.AddLayout(hlayout, 0)
+	vlayout.AddWidget(sp, 0, 0)
 	vlayout.SetContentsMargins(0, 0, 0, 0)
 
 	w.QWidget = widgets.NewQWidget(parent, 0)
@@ -54,7 +96,11 @@ func (w *SymdataWidget) SetSymbol(sym *macho.Symbol, taddend, tsize int64) {
 	w.taddend = taddend
 	w.tsize = tsize
 
-	typ := w.f.guessSymType(w.sym)
+	typ := w.f.GuessSymType(w.sym)
+	if typ == "" && sym != nil && sym.Type&macho_analysis.N_STAB == 0 && macho_analysis.SymbolType(sym.Type&macho_analysis.N_TYPE) == macho_analysis.N_UNDF {
+		// imported functions have only callers
+		typ = "Callers"
+	}
 
 	w.bb.SetChecked(typ, true)
 
@@ -62,6 +108,10 @@ func (w *SymdataWidget) SetSymbol(sym *macho.Symbol, taddend, tsize int64) {
 }
 
 func (w *SymdataWidget) SetModel(typ string) {
+	w.typ = typ
+	w.updateGraph()
+	w.dbtn.SetEnabled(typ == "Callers" || typ == "Callees")
+
 	if typ == "" {
 		w.tree.SetModel(nil)
 		return
@@ -71,9 +121,53 @@ func (w *SymdataWidget) SetModel(typ string) {
 		return
 	}
 
-	if w.sym.Type&N_STAB != 0 || SymbolType(w.sym.Type&N_TYPE) != N_SECT {
+	if w.sym.Type&macho_analysis.N_STAB != 0 {
+		return
+	}
+
+	if macho_analysis.SymbolType(w.sym.Type&macho_analysis.N_TYPE) != macho_analysis.N_SECT && typ != "Callers" && typ != "Callees" {
+		return
+	}
+
+	if layout, ok := w.lbar.LinkLayout(); ok {
+		w.tree.SetModel(w.f.NewLinkedSymbolModel(typ, w.sym, layout))
 		return
 	}
 
 	w.tree.SetModel(w.f.NewSymbolModel(typ, w.sym, w.taddend, w.tsize))
 }
+
+// updateGraph shows the control-flow graph next to the code if the graph button is checked.
+func (w *SymdataWidget) updateGraph() {
+	if !w.gbtn.IsChecked() || w.sym == nil || (w.typ != "Code" && w.typ != "Source") {
+		w.graph.Hide()
+		return
+	}
+
+	w.graph.SetBlocks(w.f.ControlFlowGraph(w.sym))
+	w.graph.Show()
+}
+
+// exportDOT saves the call graph of callers or callees of the symbol in the DOT language.
+func (w *SymdataWidget) exportDOT() {
+	path := widgets.QFileDialog_GetSaveFileName(w, "Export DOT...", "callgraph.dot", "DOT (*.dot *.gv)", "", 0)
+	if path == "" {
+		return
+	}
+
+	err := func() error {
+		out, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := w.f.WriteCallGraphDOT(out, w.sym, w.typ == "Callers"); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	}()
+	if err != nil {
+		msg := widgets.NewQErrorMessage(w)
+		msg.ShowMessage(err.Error())
+	}
+}